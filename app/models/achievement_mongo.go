@@ -1,6 +1,8 @@
 package models
 
 import (
+	"path"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -12,8 +14,9 @@ import (
 type MongoAchievement struct {
 	ID              primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
 	StudentID       string             `bson:"student_id" json:"student_id"`             // UUID reference to PostgreSQL
-	AchievementType string             `bson:"achievement_type" json:"achievement_type"` // 'academic', 'competition', 'organization', 'publication', 'certification', 'other'
+	AchievementType string             `bson:"achievement_type" json:"achievement_type"` // code from the achievement type registry (models.AchievementType)
 	Title           string             `bson:"title" json:"title"`
+	NormalizedTitle string             `bson:"normalized_title,omitempty" json:"-"` // lowercase, punctuation-free title used for duplicate detection
 	Description     string             `bson:"description" json:"description"`
 
 	// Dynamic details field based on achievement type
@@ -37,10 +40,104 @@ type MongoAchievement struct {
 
 // Attachment represents file attachment metadata in achievements
 type Attachment struct {
+	ID         string    `bson:"id,omitempty" json:"id"`
 	FileName   string    `bson:"file_name" json:"file_name"`
-	FileURL    string    `bson:"file_url" json:"file_url"`
+	Caption    string    `bson:"caption,omitempty" json:"caption,omitempty"`
+	Kind       string    `bson:"kind,omitempty" json:"kind,omitempty"`               // certificate, photo, assignment_letter or other
+	StorageKey string    `bson:"storage_key,omitempty" json:"storage_key,omitempty"` // key in the blob store
+	FileURL    string    `bson:"file_url,omitempty" json:"file_url,omitempty"`       // legacy "/uploads/..." path of attachments stored before storage keys
 	FileType   string    `bson:"file_type" json:"file_type"`
+	FileSize   int64     `bson:"file_size,omitempty" json:"file_size,omitempty"`
+	SHA256     string    `bson:"sha256,omitempty" json:"sha256,omitempty"` // content hash used for duplicate detection
 	UploadedAt time.Time `bson:"uploaded_at" json:"uploaded_at"`
+
+	ScanStatus    string     `bson:"scan_status,omitempty" json:"scan_status,omitempty"` // pending_scan, clean or infected; empty for files uploaded before scanning
+	ScanSignature string     `bson:"scan_signature,omitempty" json:"scan_signature,omitempty"`
+	ScannedAt     *time.Time `bson:"scanned_at,omitempty" json:"scanned_at,omitempty"`
+
+	Preview *AttachmentPreview `bson:"preview,omitempty" json:"preview,omitempty"` // set once a preview has been generated
+}
+
+// AttachmentPreview is a JPEG thumbnail of an image attachment or the first page of a PDF
+type AttachmentPreview struct {
+	StorageKey  string    `bson:"storage_key" json:"storage_key"`
+	ContentType string    `bson:"content_type" json:"content_type"`
+	Width       int       `bson:"width" json:"width"`
+	Height      int       `bson:"height" json:"height"`
+	GeneratedAt time.Time `bson:"generated_at" json:"generated_at"`
+}
+
+// Attachment kinds
+const (
+	AttachmentCertificate      = "certificate"
+	AttachmentPhoto            = "photo"
+	AttachmentAssignmentLetter = "assignment_letter"
+	AttachmentOther            = "other"
+)
+
+// AttachmentKinds lists the accepted attachment kinds
+var AttachmentKinds = []string{AttachmentCertificate, AttachmentPhoto, AttachmentAssignmentLetter, AttachmentOther}
+
+// Attachment malware scan statuses
+const (
+	ScanPending  = "pending_scan"
+	ScanClean    = "clean"
+	ScanInfected = "infected"
+)
+
+// Key returns the storage key of the attachment, derived from the legacy URL for old records
+func (a *Attachment) Key() string {
+	if a.StorageKey != "" {
+		return a.StorageKey
+	}
+	return strings.TrimPrefix(a.FileURL, "/uploads/")
+}
+
+// AttachmentID returns the ID used in download URLs; legacy records use the stored file name
+func (a *Attachment) AttachmentID() string {
+	if a.ID != "" {
+		return a.ID
+	}
+	return path.Base(a.Key())
+}
+
+// OrderAttachments returns the attachments in the order of the given IDs. Attachments missing from
+// the list keep their relative order after the listed ones; unknown IDs are ignored
+func OrderAttachments(attachments []Attachment, order []string) []Attachment {
+	position := make(map[string]int, len(order))
+	for i, id := range order {
+		if _, seen := position[id]; !seen {
+			position[id] = i
+		}
+	}
+
+	ordered := make([]Attachment, 0, len(attachments))
+	var rest []Attachment
+	slots := make([]*Attachment, len(order))
+	for i := range attachments {
+		if p, ok := position[attachments[i].AttachmentID()]; ok && slots[p] == nil {
+			slots[p] = &attachments[i]
+		} else {
+			rest = append(rest, attachments[i])
+		}
+	}
+	for _, a := range slots {
+		if a != nil {
+			ordered = append(ordered, *a)
+		}
+	}
+	return append(ordered, rest...)
+}
+
+// UpdateAttachmentRequest changes the caption or kind of an attachment; omitted fields are kept
+type UpdateAttachmentRequest struct {
+	Caption *string `json:"caption"`
+	Kind    *string `json:"kind"`
+}
+
+// ReorderAttachmentsRequest lists every attachment ID of an achievement in the new order
+type ReorderAttachmentsRequest struct {
+	AttachmentIDs []string `json:"attachment_ids"`
 }
 
 // CompetitionDetails represents details for competition achievement
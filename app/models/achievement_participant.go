@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Participant roles
+const (
+	ParticipantRoleLeader      = "leader"
+	ParticipantRoleMember      = "member"
+	ParticipantRoleFirstAuthor = "first_author"
+	ParticipantRoleCoAuthor    = "co_author"
+)
+
+// Participant invitation statuses
+const (
+	ParticipantInvited  = "invited"
+	ParticipantAccepted = "accepted"
+	ParticipantDeclined = "declined"
+)
+
+// AchievementParticipant links a student to a shared (team or co-authored) achievement
+// The achievement owner is always an accepted participant
+type AchievementParticipant struct {
+	ID            string     `json:"id" gorm:"primaryKey"`
+	AchievementID string     `json:"achievement_id" gorm:"index"` // AchievementReference.ID
+	StudentID     string     `json:"student_id" gorm:"index"`     // user ID of the student, same as AchievementReference.StudentID
+	Role          string     `json:"role"`                        // leader, member, first_author, co_author
+	Status        string     `json:"status"`                      // invited, accepted, declined
+	PointShare    *float64   `json:"point_share"`                 // optional weight; equal split when empty
+	Points        int        `json:"points"`                      // awarded share after verification
+	InvitedBy     string     `json:"invited_by"`
+	RespondedAt   *time.Time `json:"responded_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// ParticipantRequest represents a co-participant to invite
+type ParticipantRequest struct {
+	StudentID  string   `json:"student_id"` // NIM of the invited student
+	Role       string   `json:"role"`
+	PointShare *float64 `json:"point_share"`
+}
@@ -1,36 +1,105 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type AchievementReference struct {
 	ID                 string     `json:"id" gorm:"primaryKey"`
 	StudentID          string     `json:"student_id"`
 	MongoAchievementID string     `json:"mongo_achievement_id"`
-	Status             string     `json:"status"` // draft, submitted, verified, rejected
+	Title              string     `json:"title" gorm:"not null;default:''"`                  // copied from MongoDB for listing and sorting
+	AchievementType    string     `json:"achievement_type" gorm:"index;not null;default:''"` // copied from MongoDB for filtering
+	ListingSynced      bool       `json:"-"`                                                 // title and achievement_type have been copied from MongoDB
+	Status             string     `json:"status"`                                            // draft, submitted, verified, rejected
 	SubmittedAt        time.Time  `json:"submitted_at"`
 	VerifiedAt         time.Time  `json:"verified_at"`
 	VerifiedBy         string     `json:"verified_by"`
 	RejectionNote      string     `json:"rejection_note"`
+	Points             int        `json:"points"`            // awarded points (mirrored to MongoDB on verification)
+	SuggestedPoints    int        `json:"suggested_points"`  // computed by the scoring rubric
+	RubricVersion      int        `json:"rubric_version"`    // rubric version used for SuggestedPoints
+	PointsOverridden   bool       `json:"points_overridden"` // verifier awarded points different from suggestion
+	OverrideReason     string     `json:"override_reason"`   // mandatory when PointsOverridden
+	DuplicateFlags     int        `json:"duplicate_flags"`   // open potential-duplicate flags found on submission
+	ClaimedBy          string     `json:"claimed_by"`        // reviewer currently working on the submission
+	ClaimedAt          *time.Time `json:"claimed_at"`
+	ValidUntil         *time.Time `json:"valid_until"`                        // parsed certification validity, empty when it does not expire
+	ValidityChecked    bool       `json:"-"`                                  // valid_until has been parsed from the MongoDB details
+	ExpiryRemindedAt   *time.Time `json:"expiry_reminded_at"`                 // when the student was reminded of the upcoming expiry
+	ValidityStatus     string     `json:"validity_status,omitempty" gorm:"-"` // valid, expiring_soon or expired; computed on load
 	CreatedAt          time.Time  `json:"created_at"`
 	UpdatedAt          time.Time  `json:"updated_at"`
 	DeletedAt          *time.Time `json:"deleted_at"`
+
+	// Attachments with signed file and preview URLs, filled in detail responses only
+	Attachments []map[string]interface{} `json:"attachments,omitempty" gorm:"-"`
+}
+
+// AchievementListFilter selects one page of achievement references
+type AchievementListFilter struct {
+	StudentIDs      []string // owners whose achievements are listed; nil lists every student
+	IncludeIDs      []string // achievements listed whatever their owner, such as shared ones
+	Status          string
+	AchievementType string
+	SortBy          string // created_at, updated_at or title
+	Descending      bool
+	Cursor          *Cursor // keyset position to continue from; Offset is ignored when set
+	Offset          int
+	Limit           int
+}
+
+// Certification validity statuses
+const (
+	CertificationValid        = "valid"
+	CertificationExpiringSoon = "expiring_soon"
+	CertificationExpired      = "expired"
+)
+
+// CertificationWarningDays is how many days before expiry a certification counts as expiring soon
+var CertificationWarningDays = 30
+
+// CertificationValidity returns the validity status of a certification expiring at validUntil
+// Returns an empty string when there is no expiry date
+func CertificationValidity(validUntil *time.Time, now time.Time, warningDays int) string {
+	if validUntil == nil {
+		return ""
+	}
+	switch {
+	case now.After(*validUntil):
+		return CertificationExpired
+	case now.AddDate(0, 0, warningDays).After(*validUntil):
+		return CertificationExpiringSoon
+	default:
+		return CertificationValid
+	}
+}
+
+// AfterFind fills the computed certification validity status
+func (a *AchievementReference) AfterFind(tx *gorm.DB) error {
+	a.ValidityStatus = CertificationValidity(a.ValidUntil, time.Now(), CertificationWarningDays)
+	return nil
 }
 
 // CreateAchievementRequest represents request to create achievement
 type CreateAchievementRequest struct {
 	Title           string                 `json:"title" validate:"required"`
 	Description     string                 `json:"description"`
-	AchievementType string                 `json:"achievement_type" validate:"required"` // 'academic', 'competition', 'organization', 'publication', 'certification', 'other'
+	AchievementType string                 `json:"achievement_type" validate:"required"` // code from the achievement type registry (models.AchievementType)
 	Details         map[string]interface{} `json:"details"`
 	Tags            []string               `json:"tags"`
 	Points          int                    `json:"points"`
+	OwnerRole       string                 `json:"owner_role"`   // creator's role in a shared achievement (default: leader)
+	Participants    []ParticipantRequest   `json:"participants"` // co-participants to invite
 }
 
 // UpdateAchievementRequest represents request to update achievement
 type UpdateAchievementRequest struct {
 	Title           string                 `json:"title"`
 	Description     string                 `json:"description"`
-	AchievementType string                 `json:"achievement_type"`
+	AchievementType string                 `json:"achievement_type"` // omitted keeps the current type
 	Details         map[string]interface{} `json:"details"`
 	Tags            []string               `json:"tags"`
 	Points          int                    `json:"points"`
@@ -59,3 +128,36 @@ type SubmitAchievementRequest struct {
 type RejectAchievementRequest struct {
 	RejectionNote string `json:"rejection_note" validate:"required"`
 }
+
+// BulkVerifyItem represents one achievement in a bulk verification
+type BulkVerifyItem struct {
+	AchievementID  string `json:"achievement_id"`
+	Points         *int   `json:"points"`          // defaults to the rubric suggestion
+	OverrideReason string `json:"override_reason"` // required when points differ from the suggestion
+}
+
+// BulkVerifyRequest represents request to verify several achievements at once
+type BulkVerifyRequest struct {
+	Items []BulkVerifyItem `json:"items"`
+}
+
+// BulkRejectItem represents one achievement in a bulk rejection
+type BulkRejectItem struct {
+	AchievementID string `json:"achievement_id"`
+	RejectionNote string `json:"rejection_note"`
+}
+
+// BulkRejectRequest represents request to reject several achievements at once
+type BulkRejectRequest struct {
+	Items []BulkRejectItem `json:"items"`
+}
+
+// BulkReviewResult reports the outcome of one item of a bulk verify or reject
+type BulkReviewResult struct {
+	AchievementID string `json:"achievement_id"`
+	Success       bool   `json:"success"`
+	Code          int    `json:"code"` // HTTP status the single-item endpoint would have returned
+	Status        string `json:"status,omitempty"`
+	Points        *int   `json:"points,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Detail field types supported by achievement type schemas
+const (
+	FieldTypeString  = "string"
+	FieldTypeNumber  = "number"
+	FieldTypeInteger = "integer"
+	FieldTypeBoolean = "boolean"
+	FieldTypeDate    = "date" // YYYY-MM-DD
+	FieldTypeArray   = "array"
+)
+
+// AchievementType represents an admin-defined achievement category
+// Every validation, statistics and reporting path reads types from this registry
+type AchievementType struct {
+	Code                   string             `json:"code" gorm:"primaryKey"`        // e.g. 'competition', 'publication'
+	Labels                 map[string]string  `json:"labels" gorm:"serializer:json"` // display labels per locale: {"id": "...", "en": "..."}
+	Description            string             `json:"description"`
+	DefaultPoints          int                `json:"default_points"`
+	Fields                 []AchievementField `json:"fields" gorm:"serializer:json"`                   // custom detail schema
+	AllowedAttachmentTypes []string           `json:"allowed_attachment_types" gorm:"serializer:json"` // accepted upload types; empty accepts PDF, JPEG, PNG and WebP (video must be listed)
+	IsActive               bool               `json:"is_active"`
+	CreatedAt              time.Time          `json:"created_at"`
+	UpdatedAt              time.Time          `json:"updated_at"`
+}
+
+// AchievementField describes one key of MongoAchievement.Details for a type
+type AchievementField struct {
+	Name          string            `json:"name"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Type          string            `json:"type"` // string, number, integer, boolean, date, array
+	Required      bool              `json:"required"`
+	AllowedValues []string          `json:"allowed_values,omitempty"`
+}
+
+// FindField returns the schema field with the given name, or nil
+func (t *AchievementType) FindField(name string) *AchievementField {
+	for i := range t.Fields {
+		if t.Fields[i].Name == name {
+			return &t.Fields[i]
+		}
+	}
+	return nil
+}
+
+// AchievementTypeRequest represents request to create or update an achievement type
+type AchievementTypeRequest struct {
+	Code                   string             `json:"code"`
+	Labels                 map[string]string  `json:"labels"`
+	Description            string             `json:"description"`
+	DefaultPoints          *int               `json:"default_points"`
+	Fields                 []AchievementField `json:"fields"`
+	AllowedAttachmentTypes []string           `json:"allowed_attachment_types"`
+	IsActive               *bool              `json:"is_active"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Resumable upload statuses
+const (
+	UploadInProgress = "uploading"
+	UploadCompleted  = "completed"
+	UploadFailed     = "failed"
+)
+
+// AttachmentUpload is a resumable (tus) attachment upload. Every PATCH request stores its bytes as a
+// separate chunk in the blob store; when the last byte arrives the chunks are joined, validated and
+// recorded as an attachment with the upload's ID
+type AttachmentUpload struct {
+	ID            string    `json:"id" gorm:"primaryKey"` // also the ID of the resulting attachment
+	AchievementID string    `json:"achievement_id" gorm:"index"`
+	UserID        string    `json:"user_id"` // only the user who created the upload may continue it
+	FileName      string    `json:"file_name"`
+	FileType      string    `json:"file_type"` // declared by the client; the content decides on completion
+	Caption       string    `json:"caption"`
+	Kind          string    `json:"kind"`
+	Length        int64     `json:"length"`                             // total size in bytes
+	Offset        int64     `json:"offset" gorm:"column:upload_offset"` // bytes received so far
+	Chunks        []int64   `json:"-" gorm:"serializer:json"`           // start offsets of the stored chunks
+	Status        string    `json:"status" gorm:"index;default:uploading"`
+	Error         string    `json:"error,omitempty"` // why a failed upload was rejected
+	ExpiresAt     time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Duplicate flag statuses
+const (
+	DuplicateFlagOpen      = "open"
+	DuplicateFlagDismissed = "dismissed"
+)
+
+// AchievementDuplicateFlag records a potential duplicate found when an achievement is submitted
+type AchievementDuplicateFlag struct {
+	ID                   string     `json:"id" gorm:"primaryKey"`
+	AchievementID        string     `json:"achievement_id" gorm:"index"`         // submitted achievement
+	MatchedAchievementID string     `json:"matched_achievement_id" gorm:"index"` // existing achievement it resembles
+	Score                float64    `json:"score"`                               // similarity between 0 and 1
+	Reasons              []string   `json:"reasons" gorm:"serializer:json"`      // e.g. certification_number, attachment_hash, title
+	Status               string     `json:"status"`                              // open, dismissed
+	DismissedBy          string     `json:"dismissed_by,omitempty"`
+	DismissedAt          *time.Time `json:"dismissed_at,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Job run statuses
+const (
+	JobRunRunning   = "running"
+	JobRunSucceeded = "succeeded"
+	JobRunFailed    = "failed"
+)
+
+// Job run triggers
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+)
+
+// JobRun records one execution of a scheduled background job
+// ScheduledFor is set for scheduled runs; together with JobName it guarantees a slot runs only once
+// across API instances. Manual runs leave it empty
+type JobRun struct {
+	ID           string     `json:"id" gorm:"primaryKey"`
+	JobName      string     `json:"job_name" gorm:"index;uniqueIndex:idx_job_run_slot"`
+	ScheduledFor *time.Time `json:"scheduled_for" gorm:"uniqueIndex:idx_job_run_slot"`
+	Trigger      string     `json:"trigger"` // schedule, manual
+	TriggeredBy  string     `json:"triggered_by,omitempty"`
+	Status       string     `json:"status"` // running, succeeded, failed
+	Result       string     `json:"result"`
+	Error        string     `json:"error"`
+	Instance     string     `json:"instance"` // hostname of the API instance that ran the job
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+	DurationMs   int64      `json:"duration_ms"`
+}
@@ -0,0 +1,94 @@
+package models
+
+import "time"
+
+// Notification event types
+const (
+	NotificationSubmissionReceived    = "submission_received"    // to the owner, email only
+	NotificationAchievementSubmitted  = "achievement_submitted"  // to the student's advisor
+	NotificationAchievementVerified   = "achievement_verified"   // to the owner and participants
+	NotificationAchievementRejected   = "achievement_rejected"   // to the owner and participants
+	NotificationParticipantInvited    = "participant_invited"    // to the invited student
+	NotificationInvitationAnswered    = "invitation_answered"    // to the achievement owner
+	NotificationReviewEscalated       = "review_escalated"       // to the admins and the responsible reviewer
+	NotificationCertificationExpiring = "certification_expiring" // to the certified student
+	NotificationAdvisorChanged        = "advisor_changed"        // to the student and both advisors
+	NotificationAccountUpdated        = "account_updated"        // to the user whose account changed
+	NotificationWeeklyDigest          = "weekly_digest"          // summary of the past week, email only
+	NotificationAttachmentQuarantined = "attachment_quarantined" // to the achievement owner
+)
+
+// NotificationTypes describes every event type a user can receive, in display order
+var NotificationTypes = []NotificationTypeInfo{
+	{Type: NotificationSubmissionReceived, Description: "Your achievement was submitted for verification", EmailTemplate: "submission_received"},
+	{Type: NotificationAchievementSubmitted, Description: "An advisee submitted an achievement for verification", InApp: true, EmailTemplate: "review_pending"},
+	{Type: NotificationAchievementVerified, Description: "An achievement was verified", InApp: true, EmailTemplate: "achievement_verified"},
+	{Type: NotificationAchievementRejected, Description: "An achievement was rejected", InApp: true, EmailTemplate: "achievement_rejected"},
+	{Type: NotificationParticipantInvited, Description: "You were invited to a team or co-authored achievement", InApp: true},
+	{Type: NotificationInvitationAnswered, Description: "A participant accepted or declined an invitation", InApp: true},
+	{Type: NotificationReviewEscalated, Description: "A review passed its deadline", InApp: true},
+	{Type: NotificationCertificationExpiring, Description: "A certification is about to expire", InApp: true},
+	{Type: NotificationAdvisorChanged, Description: "An advisor was assigned or changed", InApp: true},
+	{Type: NotificationAccountUpdated, Description: "Your account details, role or status changed", InApp: true},
+	{Type: NotificationWeeklyDigest, Description: "Weekly summary of your notifications", EmailTemplate: "weekly_digest"},
+	{Type: NotificationAttachmentQuarantined, Description: "An uploaded file contained malware and was quarantined", InApp: true},
+}
+
+// NotificationTypeInfo describes a notification event type and the channels it is delivered on
+type NotificationTypeInfo struct {
+	Type          string
+	Description   string
+	InApp         bool   // shown in the notification center
+	EmailTemplate string // mail template used to email it, empty when it is not emailed
+}
+
+// FindNotificationType finds the description of an event type
+func FindNotificationType(t string) (NotificationTypeInfo, bool) {
+	for _, info := range NotificationTypes {
+		if info.Type == t {
+			return info, true
+		}
+	}
+	return NotificationTypeInfo{}, false
+}
+
+// Notification is an in-app message for one user
+type Notification struct {
+	ID        string                 `json:"id" gorm:"primaryKey"`
+	UserID    string                 `json:"user_id" gorm:"index:idx_notifications_user,priority:1"`
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Message   string                 `json:"message"`
+	Data      map[string]interface{} `json:"data" gorm:"serializer:json"` // e.g. achievement_id for linking
+	ReadAt    *time.Time             `json:"read_at"`
+	CreatedAt time.Time              `json:"created_at" gorm:"index:idx_notifications_user,priority:2"`
+}
+
+// NotificationPreference stores a user's choice for one event type
+// Users without a stored preference receive every event type on every channel
+type NotificationPreference struct {
+	UserID    string    `json:"-" gorm:"primaryKey"`
+	Type      string    `json:"type" gorm:"primaryKey"`
+	InApp     bool      `json:"in_app"`
+	Email     *bool     `json:"email"` // nil means enabled
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NotificationPreferenceItem is one entry of an update preferences request
+// Channels left out keep their current setting
+type NotificationPreferenceItem struct {
+	Type  string `json:"type"`
+	InApp *bool  `json:"in_app"`
+	Email *bool  `json:"email"`
+}
+
+// UpdateNotificationPreferencesRequest represents request to change notification preferences
+type UpdateNotificationPreferencesRequest struct {
+	Locale      string                       `json:"locale,omitempty"` // email language: id or en
+	Preferences []NotificationPreferenceItem `json:"preferences"`
+}
+
+// MarkNotificationsReadRequest represents request to mark notifications as read
+type MarkNotificationsReadRequest struct {
+	IDs []string `json:"ids"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// Outbox entry statuses
+const (
+	OutboxPending = "pending"
+	OutboxApplied = "applied"
+	OutboxFailed  = "failed" // gave up after the last attempt; later entries go ahead, cmd/reconcile -repair requeues it
+)
+
+// MongoDB operations recorded in the outbox. An empty operation only carries events
+const (
+	OutboxOpNone               = ""
+	OutboxOpCreate             = "create"
+	OutboxOpUpdate             = "update"
+	OutboxOpDelete             = "delete"
+	OutboxOpPoints             = "points"
+	OutboxOpAddAttachment      = "add_attachment"
+	OutboxOpRemoveAttachment   = "remove_attachment"
+	OutboxOpReplaceAttachment  = "replace_attachment"
+	OutboxOpUpdateAttachment   = "update_attachment"
+	OutboxOpReorderAttachments = "reorder_attachments"
+)
+
+// Kinds of side effects dispatched once an outbox entry is applied
+const (
+	OutboxEventNotification = "notification"
+	OutboxEventWebhook      = "webhook"
+	OutboxEventRealtime     = "realtime"
+	OutboxEventBlobDelete   = "blob_delete" // removes a stored file no longer referenced by the document
+)
+
+// OutboxEntry records an achievement change in the same PostgreSQL transaction as the reference
+// update. The MongoDB side of the change and its events are applied from the entry afterwards, so a
+// crash or MongoDB outage between the two stores is retried instead of leaving them disagreeing
+type OutboxEntry struct {
+	ID            int64         `json:"id" gorm:"primaryKey;autoIncrement"` // also the apply order per achievement
+	AchievementID string        `json:"achievement_id" gorm:"index"`
+	StudentID     string        `json:"student_id"` // owner's user ID
+	MongoID       string        `json:"mongo_id"`   // MongoAchievement ID the operation targets
+	Operation     string        `json:"operation"`  // one of the OutboxOp constants
+	Change        OutboxChange  `json:"change" gorm:"serializer:json"`
+	Events        []OutboxEvent `json:"events" gorm:"serializer:json"`
+	Status        string        `json:"status" gorm:"index;default:pending"`
+	Attempts      int           `json:"attempts"`
+	LastError     string        `json:"last_error"`
+	CreatedAt     time.Time     `json:"created_at"`
+	AppliedAt     *time.Time    `json:"applied_at"`
+}
+
+// OutboxChange is the MongoDB data of an outbox operation; only the fields the operation uses are set
+type OutboxChange struct {
+	Title           string                 `json:"title,omitempty"`
+	Description     string                 `json:"description,omitempty"`
+	AchievementType string                 `json:"achievement_type,omitempty"`
+	Details         map[string]interface{} `json:"details,omitempty"`
+	Tags            []string               `json:"tags,omitempty"`
+	Points          int                    `json:"points,omitempty"`
+	Attachment      *Attachment            `json:"attachment,omitempty"`       // added, replacing or updated attachment
+	Previous        *Attachment            `json:"previous,omitempty"`         // removed or replaced attachment
+	AttachmentOrder []string               `json:"attachment_order,omitempty"` // attachment IDs (file URLs when stored without one) in their new order
+	At              time.Time              `json:"at"`                         // when the change was made
+}
+
+// OutboxEvent is a notification, webhook or real-time event raised by an outbox entry
+type OutboxEvent struct {
+	Kind       string                 `json:"kind"`
+	Type       string                 `json:"type"`                 // event type; the storage key for blob_delete
+	Recipients []string               `json:"recipients,omitempty"` // notification recipients
+	Title      string                 `json:"title,omitempty"`
+	Message    string                 `json:"message,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
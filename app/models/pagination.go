@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Cursor is a position in a keyset-paginated list: the sort key and ID of a row. Clients get it
+// signed and opaque in the next/prev links of a list response
+type Cursor struct {
+	Sort     string `json:"s"`           // sort (and filters) it was issued for, e.g. "created_at:desc?status=verified"
+	Key      string `json:"k"`           // sort key of the row; timestamps in RFC 3339
+	ID       string `json:"i"`           // ID of the row, breaking ties between equal keys
+	Backward bool   `json:"b,omitempty"` // the page before the row instead of after it
+}
+
+// Flip returns the cursor of the page on the other side of the same row
+func (c Cursor) Flip() Cursor {
+	c.Backward = !c.Backward
+	return c
+}
+
+// CursorTime formats a timestamp sort key of a cursor
+func CursorTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// SortOldestFirst is the sort of the user, student and lecturer lists
+const SortOldestFirst = "created_at:asc"
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Queue job statuses
+const (
+	QueueJobPending   = "pending"
+	QueueJobRunning   = "running"
+	QueueJobSucceeded = "succeeded"
+	QueueJobDead      = "dead" // failed on every attempt, kept for inspection and manual retry
+)
+
+// QueueJob is a unit of background work stored in PostgreSQL
+type QueueJob struct {
+	ID          string     `json:"id" gorm:"primaryKey"`
+	Type        string     `json:"type" gorm:"index:idx_queue_jobs_claim,priority:1"`
+	Payload     string     `json:"payload" gorm:"type:text"` // JSON encoded handler input
+	Status      string     `json:"status" gorm:"index:idx_queue_jobs_claim,priority:2"`
+	RunAt       time.Time  `json:"run_at" gorm:"index:idx_queue_jobs_claim,priority:3"` // not picked up before this time
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts"`
+	LastError   string     `json:"last_error"`
+	LockedBy    string     `json:"locked_by"` // worker currently running the job
+	LockedAt    *time.Time `json:"locked_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
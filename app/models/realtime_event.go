@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Real-time event types streamed to clients
+const (
+	RealtimeAchievementCreated   = "achievement.created"
+	RealtimeAchievementUpdated   = "achievement.updated"
+	RealtimeAchievementDeleted   = "achievement.deleted"
+	RealtimeAchievementSubmitted = "achievement.submitted"
+	RealtimeAchievementVerified  = "achievement.verified"
+	RealtimeAchievementRejected  = "achievement.rejected"
+)
+
+// RealtimeEvent is an achievement change streamed over Server-Sent Events
+// The ID is the SSE event ID clients resume from with Last-Event-ID
+type RealtimeEvent struct {
+	ID            int64                  `json:"id" gorm:"primaryKey;autoIncrement"`
+	Type          string                 `json:"type"`
+	AchievementID string                 `json:"achievement_id"`
+	StudentID     string                 `json:"-"`                           // owner's user ID
+	AdvisorID     string                 `json:"-"`                           // owner's advisor (lecturer ID) when the event happened
+	Participants  []string               `json:"-" gorm:"serializer:json"`    // user IDs of co-participants
+	Data          map[string]interface{} `json:"data" gorm:"serializer:json"` // e.g. status, points
+	CreatedAt     time.Time              `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,37 @@
+package models
+
+// Kinds of inconsistency between achievement references (PostgreSQL) and documents (MongoDB)
+const (
+	ReconcileMissingDocument  = "missing_document"  // active reference whose document does not exist
+	ReconcileDocumentDeleted  = "document_deleted"  // active reference whose document is soft deleted
+	ReconcileReferenceDeleted = "reference_deleted" // deleted reference whose document is still active
+	ReconcileOrphanDocument   = "orphan_document"   // active document that no reference points to
+	ReconcileStudentMismatch  = "student_mismatch"  // reference and document belong to different students
+	ReconcilePointsMismatch   = "points_mismatch"   // verified reference and document disagree on points
+	ReconcileFailedOutbox     = "failed_outbox"     // outbox entry whose change never reached MongoDB
+)
+
+// ReconcileIssue is one inconsistency found by the reconciler. PostgreSQL is the source of truth;
+// Repair describes what the reconciler does (or would do in a dry run) to fix it
+type ReconcileIssue struct {
+	Kind          string `json:"kind"`
+	AchievementID string `json:"achievement_id,omitempty"`
+	MongoID       string `json:"mongo_id,omitempty"`
+	OutboxEntryID int64  `json:"outbox_entry_id,omitempty"` // set for failed_outbox
+	Detail        string `json:"detail"`
+	Repair        string `json:"repair"`          // empty when the issue needs manual attention
+	Repaired      bool   `json:"repaired"`        // set after a successful repair
+	Error         string `json:"error,omitempty"` // why the repair failed
+}
+
+// ReconcileReport summarizes a reconciliation run
+type ReconcileReport struct {
+	DryRun               bool             `json:"dry_run"`
+	ReferencesScanned    int              `json:"references_scanned"`
+	DocumentsScanned     int              `json:"documents_scanned"`
+	SkippedInFlight      int              `json:"skipped_in_flight"` // achievements with pending outbox entries
+	Issues               []ReconcileIssue `json:"issues"`
+	Repaired             int              `json:"repaired"`
+	Failed               int              `json:"failed"`
+	NeedsManualAttention int              `json:"needs_manual_attention"`
+}
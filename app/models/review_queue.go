@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Review stages with their own SLA deadline
+const (
+	ReviewStageSubmitted = "submitted" // waiting for a reviewer to claim it, measured from submitted_at
+	ReviewStageClaimed   = "claimed"   // claimed but not yet decided, measured from claimed_at
+)
+
+// ReviewSLA configures how long an achievement may stay in a review stage before it is escalated
+type ReviewSLA struct {
+	Stage         string    `json:"stage" gorm:"primaryKey"`
+	DeadlineHours int       `json:"deadline_hours"`
+	UpdatedBy     string    `json:"updated_by"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ReviewEscalation records an achievement that passed its review deadline
+type ReviewEscalation struct {
+	ID            string    `json:"id" gorm:"primaryKey"`
+	AchievementID string    `json:"achievement_id" gorm:"uniqueIndex:idx_escalation_deadline"`
+	Stage         string    `json:"stage" gorm:"uniqueIndex:idx_escalation_deadline"`
+	Deadline      time.Time `json:"deadline" gorm:"uniqueIndex:idx_escalation_deadline"`
+	ReviewerID    string    `json:"reviewer_id"`  // claimant, or the advisor's user ID when unclaimed
+	EscalatedTo   string    `json:"escalated_to"` // role notified, e.g. Admin
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// UpdateReviewSLARequest represents request to change the deadline of a review stage
+type UpdateReviewSLARequest struct {
+	DeadlineHours int `json:"deadline_hours"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// ScoringRubric is a versioned set of rules used to compute suggested achievement points
+// Only one rubric version is active at a time
+type ScoringRubric struct {
+	ID          string      `json:"id" gorm:"primaryKey"`
+	Version     int         `json:"version" gorm:"uniqueIndex"`
+	Description string      `json:"description"`
+	Rules       RubricRules `json:"rules" gorm:"serializer:json"`
+	IsActive    bool        `json:"is_active"`
+	CreatedBy   string      `json:"created_by"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// RubricRules holds the scoring tables of a rubric
+// Keys are matched case-insensitively against achievement details
+type RubricRules struct {
+	TypeBasePoints        map[string]int     `json:"type_base_points"`         // by achievement type code, falls back to AchievementType.DefaultPoints
+	LevelMultipliers      map[string]float64 `json:"level_multipliers"`        // by details.competition_level
+	RankBonus             map[string]int     `json:"rank_bonus"`               // by details.rank ("1", "2", "3")
+	MedalBonus            map[string]int     `json:"medal_bonus"`              // by details.medal_type
+	IndexationBonus       map[string]int     `json:"indexation_bonus"`         // by details.indexation (e.g. "scopus_q1", "sinta_2")
+	PositionBonus         map[string]int     `json:"position_bonus"`           // by details.position (e.g. "ketua", "chairman")
+	TeamSizeDecay         float64            `json:"team_size_decay"`          // fraction removed per additional team member
+	MinTeamSizeMultiplier float64            `json:"min_team_size_multiplier"` // lower bound for the team size multiplier
+}
+
+// ScoreComponent explains one step of a points calculation
+type ScoreComponent struct {
+	Rule   string  `json:"rule"`
+	Key    string  `json:"key,omitempty"`
+	Points int     `json:"points,omitempty"`
+	Factor float64 `json:"factor,omitempty"`
+}
+
+// ScoreResult is the outcome of applying a rubric to an achievement
+type ScoreResult struct {
+	Points        int              `json:"points"`
+	RubricVersion int              `json:"rubric_version"`
+	Breakdown     []ScoreComponent `json:"breakdown"`
+}
+
+// VerifyAchievementRequest represents request to verify achievement
+// Points default to the rubric suggestion; a different value requires override_reason
+type VerifyAchievementRequest struct {
+	Points         *int   `json:"points"`
+	OverrideReason string `json:"override_reason"`
+}
+
+// CreateRubricRequest represents request to publish a new rubric version
+type CreateRubricRequest struct {
+	Description string      `json:"description"`
+	Rules       RubricRules `json:"rules"`
+}
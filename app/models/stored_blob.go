@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// StoredBlob is a file in the blob store addressed by the SHA-256 of its content. Attachments with
+// identical content share one blob; RefCount counts the attachments using it and a blob nobody
+// references any more is removed by the blob-gc job
+type StoredBlob struct {
+	SHA256      string    `json:"sha256" gorm:"primaryKey"`
+	StorageKey  string    `json:"storage_key"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	RefCount    int       `json:"ref_count" gorm:"index"`
+	Quarantined bool      `json:"quarantined"`         // the content is malware and was moved under quarantine/
+	Signature   string    `json:"signature,omitempty"` // malware signature of a quarantined blob
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// BlobReference records that an attachment uses a stored blob and charges it to the storage quota
+// of the achievement's owner. A student's usage counts every distinct blob once, however many of
+// their attachments share it
+type BlobReference struct {
+	AttachmentID  string    `json:"attachment_id" gorm:"primaryKey"`
+	AchievementID string    `json:"achievement_id" gorm:"index"`
+	StudentID     string    `json:"student_id" gorm:"index"` // user ID of the achievement owner
+	SHA256        string    `json:"sha256" gorm:"index"`
+	Size          int64     `json:"size"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// StorageUsage is the attachment storage used by a student
+type StorageUsage struct {
+	StudentID      string `json:"student_id"`
+	UsedBytes      int64  `json:"used_bytes"`
+	QuotaBytes     int64  `json:"quota_bytes"`               // 0 means unlimited
+	RemainingBytes *int64 `json:"remaining_bytes,omitempty"` // absent when unlimited
+	Files          int64  `json:"files"`                     // distinct files counted against the quota
+	Attachments    int64  `json:"attachments"`               // attachments using them
+}
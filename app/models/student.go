@@ -9,6 +9,7 @@ type Student struct {
 	ProgramStudy string    `json:"program_study"`
 	AcademicYear string    `json:"academic_year"`
 	AdvisorID    string    `json:"advisor_id"`
+	StorageQuota *int64    `json:"storage_quota"` // attachment storage in bytes; nil uses the default quota
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
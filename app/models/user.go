@@ -10,6 +10,7 @@ type User struct {
 	FullName     string    `json:"full_name"`
 	RoleID       string    `json:"role_id"`
 	IsActive     bool      `json:"is_active"`
+	Locale       string    `json:"locale" gorm:"default:id"` // language of emails: id or en
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
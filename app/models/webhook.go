@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// Webhook event types
+const (
+	WebhookAchievementSubmitted = "achievement.submitted"
+	WebhookAchievementVerified  = "achievement.verified"
+	WebhookAchievementRejected  = "achievement.rejected"
+	WebhookAdvisorChanged       = "student.advisor_changed"
+)
+
+// WebhookEvents lists every event type a subscription can receive
+var WebhookEvents = []string{
+	WebhookAchievementSubmitted,
+	WebhookAchievementVerified,
+	WebhookAchievementRejected,
+	WebhookAdvisorChanged,
+}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"   // queued, or waiting for a retry
+	WebhookDeliverySucceeded = "succeeded" // receiver answered 2xx
+	WebhookDeliveryFailed    = "failed"    // every attempt failed
+)
+
+// WebhookSubscription is an external endpoint notified of achievement events
+type WebhookSubscription struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	Events      []string  `json:"events" gorm:"serializer:json"`
+	Secret      string    `json:"-"` // HMAC key, only returned when created or rotated
+	IsActive    bool      `json:"is_active"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Subscribes reports whether the subscription receives an event type
+func (w *WebhookSubscription) Subscribes(eventType string) bool {
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery records one event sent to one subscription, with the result of its last attempt
+type WebhookDelivery struct {
+	ID             string     `json:"id" gorm:"primaryKey"`
+	SubscriptionID string     `json:"subscription_id" gorm:"index:idx_webhook_deliveries_subscription,priority:1"`
+	EventID        string     `json:"event_id" gorm:"index"` // shared by redeliveries of the same event
+	EventType      string     `json:"event_type"`
+	Payload        string     `json:"payload" gorm:"type:text"` // JSON body sent to the receiver
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus int        `json:"response_status"`
+	ResponseBody   string     `json:"response_body"` // first 2 KB
+	Error          string     `json:"error"`
+	DurationMs     int64      `json:"duration_ms"`
+	RedeliveryOf   string     `json:"redelivery_of,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index:idx_webhook_deliveries_subscription,priority:2"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// WebhookRequest represents request to create or update a webhook subscription
+type WebhookRequest struct {
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	Events      []string `json:"events"`
+	IsActive    *bool    `json:"is_active,omitempty"`
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"UAS/app/models"
+	"UAS/database"
+)
+
+// AchievementParticipantRepository handles shared achievement participant operations
+type AchievementParticipantRepository struct{}
+
+// NewAchievementParticipantRepository creates a new instance of AchievementParticipantRepository
+func NewAchievementParticipantRepository() *AchievementParticipantRepository {
+	return &AchievementParticipantRepository{}
+}
+
+// Create creates a new participant record
+func (r *AchievementParticipantRepository) Create(participant *models.AchievementParticipant) error {
+	return database.DB.Create(participant).Error
+}
+
+// FindByID finds participant by ID
+func (r *AchievementParticipantRepository) FindByID(id string) (*models.AchievementParticipant, error) {
+	var participant models.AchievementParticipant
+	err := database.DB.Where("id = ?", id).First(&participant).Error
+	if err != nil {
+		return nil, err
+	}
+	return &participant, nil
+}
+
+// FindByAchievementID finds all participants of an achievement
+func (r *AchievementParticipantRepository) FindByAchievementID(achievementID string) ([]models.AchievementParticipant, error) {
+	var participants []models.AchievementParticipant
+	err := database.DB.Where("achievement_id = ?", achievementID).Order("created_at ASC").Find(&participants).Error
+	if err != nil {
+		return nil, err
+	}
+	return participants, nil
+}
+
+// FindByAchievementAndStudent finds the participation of a student in an achievement
+func (r *AchievementParticipantRepository) FindByAchievementAndStudent(achievementID, studentID string) (*models.AchievementParticipant, error) {
+	var participant models.AchievementParticipant
+	err := database.DB.Where("achievement_id = ? AND student_id = ?", achievementID, studentID).First(&participant).Error
+	if err != nil {
+		return nil, err
+	}
+	return &participant, nil
+}
+
+// FindByStudentAndStatus finds participations of a student with the given invitation status
+func (r *AchievementParticipantRepository) FindByStudentAndStatus(studentID, status string) ([]models.AchievementParticipant, error) {
+	var participants []models.AchievementParticipant
+	err := database.DB.Where("student_id = ? AND status = ?", studentID, status).Order("created_at DESC").Find(&participants).Error
+	if err != nil {
+		return nil, err
+	}
+	return participants, nil
+}
+
+// FindSharedAchievementIDs returns IDs of achievements a student accepted to join but does not own
+func (r *AchievementParticipantRepository) FindSharedAchievementIDs(studentID string) ([]string, error) {
+	var ids []string
+	err := database.DB.Model(&models.AchievementParticipant{}).
+		Joins("JOIN achievement_references ON achievement_references.id = achievement_participants.achievement_id").
+		Where("achievement_participants.student_id = ? AND achievement_participants.status = ?", studentID, models.ParticipantAccepted).
+		Where("achievement_references.student_id <> ? AND achievement_references.deleted_at IS NULL", studentID).
+		Pluck("achievement_participants.achievement_id", &ids).Error
+	return ids, err
+}
+
+// CountPending counts invitations of an achievement that have not been answered
+func (r *AchievementParticipantRepository) CountPending(achievementID string) (int64, error) {
+	var count int64
+	err := database.DB.Model(&models.AchievementParticipant{}).
+		Where("achievement_id = ? AND status = ?", achievementID, models.ParticipantInvited).
+		Count(&count).Error
+	return count, err
+}
+
+// Update updates a participant record
+func (r *AchievementParticipantRepository) Update(participant *models.AchievementParticipant) error {
+	return database.DB.Save(participant).Error
+}
+
+// UpdatePoints stores the point shares of participants, keyed by participant ID
+func (r *AchievementParticipantRepository) UpdatePoints(tx *gorm.DB, shares map[string]int) error {
+	for id, points := range shares {
+		err := tx.Model(&models.AchievementParticipant{}).Where("id = ?", id).
+			Updates(map[string]interface{}{"points": points, "updated_at": time.Now()}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes a participant record
+func (r *AchievementParticipantRepository) Delete(id string) error {
+	return database.DB.Where("id = ?", id).Delete(&models.AchievementParticipant{}).Error
+}
@@ -1,12 +1,18 @@
 package repository
 
 import (
+	"errors"
 	"time"
 
+	"gorm.io/gorm"
+
 	"UAS/app/models"
 	"UAS/database"
 )
 
+// ErrAchievementNotSubmitted is returned when a review targets an achievement that is no longer submitted
+var ErrAchievementNotSubmitted = errors.New("achievement is not in submitted status")
+
 // AchievementRepository handles achievement database operations
 type AchievementRepository struct{}
 
@@ -16,8 +22,11 @@ func NewAchievementRepository() *AchievementRepository {
 }
 
 // Create creates a new achievement (FR-003)
-func (r *AchievementRepository) Create(achievement *models.AchievementReference) error {
-	return database.DB.Create(achievement).Error
+// outbox runs in the same transaction to record the MongoDB side of the change; it may be nil
+func (r *AchievementRepository) Create(achievement *models.AchievementReference, outbox func(tx *gorm.DB) error) error {
+	return withOutbox(func(tx *gorm.DB) error {
+		return tx.Create(achievement).Error
+	}, outbox)
 }
 
 // FindByID finds achievement by ID
@@ -61,9 +70,21 @@ func (r *AchievementRepository) FindAll() ([]models.AchievementReference, error)
 	return achievements, nil
 }
 
-// Update updates an achievement
-func (r *AchievementRepository) Update(id string, achievement *models.AchievementReference) error {
-	return database.DB.Model(&models.AchievementReference{}).Where("id = ?", id).Updates(achievement).Error
+// FindAllWithDeleted finds every achievement reference, soft deleted ones included
+func (r *AchievementRepository) FindAllWithDeleted() ([]models.AchievementReference, error) {
+	var achievements []models.AchievementReference
+	err := database.DB.Order("created_at ASC").Find(&achievements).Error
+	if err != nil {
+		return nil, err
+	}
+	return achievements, nil
+}
+
+// Update updates an achievement; outbox is recorded in the same transaction and may be nil
+func (r *AchievementRepository) Update(id string, achievement *models.AchievementReference, outbox func(tx *gorm.DB) error) error {
+	return withOutbox(func(tx *gorm.DB) error {
+		return tx.Model(&models.AchievementReference{}).Where("id = ?", id).Updates(achievement).Error
+	}, outbox)
 }
 
 // UpdateStatus updates achievement status (FR-004)
@@ -73,12 +94,13 @@ func (r *AchievementRepository) UpdateStatus(id string, status string) error {
 		Update("status", status).Error
 }
 
-// Delete soft delete an achievement (FR-005)
-func (r *AchievementRepository) Delete(id string) error {
-	now := time.Now()
-	return database.DB.Model(&models.AchievementReference{}).
-		Where("id = ?", id).
-		Update("deleted_at", now).Error
+// Delete soft delete an achievement (FR-005); outbox is recorded in the same transaction and may be nil
+func (r *AchievementRepository) Delete(id string, outbox func(tx *gorm.DB) error) error {
+	return withOutbox(func(tx *gorm.DB) error {
+		return tx.Model(&models.AchievementReference{}).
+			Where("id = ?", id).
+			Update("deleted_at", time.Now()).Error
+	}, outbox)
 }
 
 // FindByStatus finds achievements by status
@@ -171,3 +193,298 @@ func (r *AchievementRepository) FindAllWithPagination(page, pageSize int) ([]mod
 
 	return achievements, total, nil
 }
+
+// UpdateScore updates points related columns of an achievement
+// Uses a column map so zero values (e.g. points_overridden=false) are written
+// outbox is recorded in the same transaction and may be nil
+func (r *AchievementRepository) UpdateScore(id string, points, suggestedPoints, rubricVersion int, overridden bool, overrideReason string, outbox func(tx *gorm.DB) error) error {
+	return withOutbox(func(tx *gorm.DB) error {
+		return tx.Model(&models.AchievementReference{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"points":            points,
+				"suggested_points":  suggestedPoints,
+				"rubric_version":    rubricVersion,
+				"points_overridden": overridden,
+				"override_reason":   overrideReason,
+				"updated_at":        time.Now(),
+			}).Error
+	}, outbox)
+}
+
+// FindScoredBeforeVersion finds achievements whose suggested points were computed with an older rubric
+func (r *AchievementRepository) FindScoredBeforeVersion(version int) ([]models.AchievementReference, error) {
+	var achievements []models.AchievementReference
+	err := database.DB.Where("rubric_version < ? AND deleted_at IS NULL", version).Order("created_at ASC").Find(&achievements).Error
+	if err != nil {
+		return nil, err
+	}
+	return achievements, nil
+}
+
+// FindByIDs finds achievements by multiple IDs
+func (r *AchievementRepository) FindByIDs(ids []string) ([]models.AchievementReference, error) {
+	var achievements []models.AchievementReference
+	if len(ids) == 0 {
+		return achievements, nil
+	}
+	err := database.DB.Where("id IN ? AND deleted_at IS NULL", ids).Order("created_at DESC").Find(&achievements).Error
+	if err != nil {
+		return nil, err
+	}
+	return achievements, nil
+}
+
+// FindByMongoIDs finds achievement references by their MongoDB document IDs
+func (r *AchievementRepository) FindByMongoIDs(mongoIDs []string) ([]models.AchievementReference, error) {
+	var achievements []models.AchievementReference
+	if len(mongoIDs) == 0 {
+		return achievements, nil
+	}
+	err := database.DB.Where("mongo_achievement_id IN ? AND deleted_at IS NULL", mongoIDs).Find(&achievements).Error
+	if err != nil {
+		return nil, err
+	}
+	return achievements, nil
+}
+
+// UpdateDuplicateFlags stores the number of open potential-duplicate flags of an achievement
+func (r *AchievementRepository) UpdateDuplicateFlags(id string, count int) error {
+	return database.DB.Model(&models.AchievementReference{}).Where("id = ?", id).
+		Update("duplicate_flags", count).Error
+}
+
+// ApplyReview records a verification or rejection in a single transaction. The reference update only
+// applies while the achievement is still submitted, so concurrent reviews cannot both succeed.
+// participantPoints maps participant IDs to their share of the awarded points. outbox runs last,
+// inside the transaction, to record the MongoDB side of the review; it may be nil
+func (r *AchievementRepository) ApplyReview(id string, updates map[string]interface{}, participantPoints map[string]int, outbox func(tx *gorm.DB) error) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		updates["updated_at"] = time.Now()
+		result := tx.Model(&models.AchievementReference{}).
+			Where("id = ? AND status = ? AND deleted_at IS NULL", id, "submitted").
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAchievementNotSubmitted
+		}
+
+		for participantID, points := range participantPoints {
+			if err := tx.Model(&models.AchievementParticipant{}).Where("id = ?", participantID).
+				Updates(map[string]interface{}{"points": points, "updated_at": time.Now()}).Error; err != nil {
+				return err
+			}
+		}
+
+		if outbox != nil {
+			return outbox(tx)
+		}
+		return nil
+	})
+}
+
+// Submit moves a draft achievement to submitted and starts its review clock
+// outbox is recorded in the same transaction and may be nil
+func (r *AchievementRepository) Submit(id string, outbox func(tx *gorm.DB) error) error {
+	return withOutbox(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.AchievementReference{}).
+			Where("id = ? AND status = ? AND deleted_at IS NULL", id, "draft").
+			Updates(map[string]interface{}{
+				"status":       "submitted",
+				"submitted_at": now,
+				"claimed_by":   "",
+				"claimed_at":   nil,
+				"updated_at":   now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("only draft achievements can be submitted")
+		}
+		return nil
+	}, outbox)
+}
+
+// FindReviewQueue finds submitted achievements, oldest submission first
+// studentIDs limits the queue to those students (nil means all). claimFilter is one of
+// "mine", "unclaimed" or empty for every item
+func (r *AchievementRepository) FindReviewQueue(studentIDs []string, claimFilter, reviewerID string, offset, limit int) ([]models.AchievementReference, int64, error) {
+	var achievements []models.AchievementReference
+	var total int64
+
+	query := database.DB.Model(&models.AchievementReference{}).Where("status = ? AND deleted_at IS NULL", "submitted")
+	if studentIDs != nil {
+		query = query.Where("student_id IN ?", studentIDs)
+	}
+	switch claimFilter {
+	case "mine":
+		query = query.Where("claimed_by = ?", reviewerID)
+	case "unclaimed":
+		query = query.Where("claimed_by IS NULL OR claimed_by = ''")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("submitted_at ASC").Order("created_at ASC").Offset(offset).Limit(limit).Find(&achievements).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return achievements, total, nil
+}
+
+// Claim assigns a submitted achievement to a reviewer unless someone else already holds it
+// Returns false when the achievement is not submitted or claimed by another reviewer
+func (r *AchievementRepository) Claim(id, reviewerID string) (bool, error) {
+	now := time.Now()
+	result := database.DB.Model(&models.AchievementReference{}).
+		Where("id = ? AND status = ? AND deleted_at IS NULL", id, "submitted").
+		Where("claimed_by IS NULL OR claimed_by = '' OR claimed_by = ?", reviewerID).
+		Updates(map[string]interface{}{
+			"claimed_by": reviewerID,
+			// Claiming again keeps the original claim time so the SLA clock is not reset
+			"claimed_at": gorm.Expr("CASE WHEN claimed_by = ? THEN claimed_at ELSE ? END", reviewerID, now),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Release removes the claim of a submitted achievement
+func (r *AchievementRepository) Release(id string) error {
+	return database.DB.Model(&models.AchievementReference{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"claimed_by": "", "claimed_at": nil}).Error
+}
+
+// UpdateValidity stores the parsed certification validity and resets the expiry reminder
+func (r *AchievementRepository) UpdateValidity(id string, validUntil *time.Time) error {
+	return database.DB.Model(&models.AchievementReference{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"valid_until":        validUntil,
+			"validity_checked":   true,
+			"expiry_reminded_at": nil,
+		}).Error
+}
+
+// FindValidityUnchecked finds achievements whose valid_until was not parsed yet
+func (r *AchievementRepository) FindValidityUnchecked(limit int) ([]models.AchievementReference, error) {
+	var achievements []models.AchievementReference
+	err := database.DB.Where("validity_checked = ? AND deleted_at IS NULL", false).
+		Order("created_at ASC").Limit(limit).Find(&achievements).Error
+	if err != nil {
+		return nil, err
+	}
+	return achievements, nil
+}
+
+// achievementSortColumns maps the sort keys of achievement lists to their columns
+var achievementSortColumns = map[string]keysetColumn{
+	"created_at": createdAtColumn,
+	"updated_at": {expr: "updated_at", param: "?", timestamp: true},
+	"title":      {expr: "LOWER(title)", param: "LOWER(?)"},
+}
+
+// FindPage finds one page of non-deleted achievements matching filter and counts every match.
+// With a cursor the page is read by keyset and more reports whether rows follow in the direction
+// read; otherwise by offset. Unknown sort keys fall back to created_at; ties are broken by ID
+func (r *AchievementRepository) FindPage(filter models.AchievementListFilter) (achievements []models.AchievementReference, total int64, more bool, err error) {
+	query := database.DB.Model(&models.AchievementReference{}).Where("deleted_at IS NULL")
+	if filter.StudentIDs != nil {
+		if len(filter.IncludeIDs) > 0 {
+			query = query.Where("student_id IN ? OR id IN ?", filter.StudentIDs, filter.IncludeIDs)
+		} else {
+			query = query.Where("student_id IN ?", filter.StudentIDs)
+		}
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.AchievementType != "" {
+		query = query.Where("achievement_type = ?", filter.AchievementType)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, false, err
+	}
+	if total == 0 {
+		return achievements, 0, false, nil
+	}
+
+	column, ok := achievementSortColumns[filter.SortBy]
+	if !ok {
+		column = createdAtColumn
+	}
+	if filter.Cursor == nil && filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	page, err := keysetQuery(query, column, filter.Descending, filter.Cursor, filter.Limit)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if err := page.Find(&achievements).Error; err != nil {
+		return nil, 0, false, err
+	}
+	achievements, more = keysetRows(achievements, filter.Cursor, filter.Limit)
+	return achievements, total, more, nil
+}
+
+// UpdateListing copies the title and type of an achievement from its MongoDB document, inside tx
+// when given
+func (r *AchievementRepository) UpdateListing(tx *gorm.DB, id, title, achievementType string) error {
+	if tx == nil {
+		tx = database.DB
+	}
+	return tx.Model(&models.AchievementReference{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"title":            title,
+			"achievement_type": achievementType,
+			"listing_synced":   true,
+		}).Error
+}
+
+// FindListingUnsynced finds achievements whose title and type were not copied from MongoDB yet
+func (r *AchievementRepository) FindListingUnsynced(limit int) ([]models.AchievementReference, error) {
+	var achievements []models.AchievementReference
+	err := database.DB.Where("listing_synced = ? AND deleted_at IS NULL", false).
+		Order("created_at ASC").Limit(limit).Find(&achievements).Error
+	if err != nil {
+		return nil, err
+	}
+	return achievements, nil
+}
+
+// FindExpiringUnreminded finds verified achievements expiring between now and before
+// whose owner was not reminded yet
+func (r *AchievementRepository) FindExpiringUnreminded(before time.Time) ([]models.AchievementReference, error) {
+	var achievements []models.AchievementReference
+	err := database.DB.Where("status = ? AND deleted_at IS NULL AND expiry_reminded_at IS NULL", "verified").
+		Where("valid_until > ? AND valid_until <= ?", time.Now(), before).
+		Find(&achievements).Error
+	if err != nil {
+		return nil, err
+	}
+	return achievements, nil
+}
+
+// MarkExpiryReminded records that the owner was reminded of the upcoming expiry
+func (r *AchievementRepository) MarkExpiryReminded(id string) error {
+	return database.DB.Model(&models.AchievementReference{}).Where("id = ?", id).
+		Update("expiry_reminded_at", time.Now()).Error
+}
+
+// withOutbox runs a write and the outbox hook recording its MongoDB counterpart in one transaction
+func withOutbox(write func(tx *gorm.DB) error, outbox func(tx *gorm.DB) error) error {
+	if outbox == nil {
+		return write(database.DB)
+	}
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := write(tx); err != nil {
+			return err
+		}
+		return outbox(tx)
+	})
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"UAS/app/models"
+	"UAS/database"
+)
+
+// AchievementTypeRepository handles achievement type registry operations
+type AchievementTypeRepository struct{}
+
+// NewAchievementTypeRepository creates a new instance of AchievementTypeRepository
+func NewAchievementTypeRepository() *AchievementTypeRepository {
+	return &AchievementTypeRepository{}
+}
+
+// Create creates a new achievement type
+func (r *AchievementTypeRepository) Create(achievementType *models.AchievementType) error {
+	return database.DB.Create(achievementType).Error
+}
+
+// FindByCode finds achievement type by code
+func (r *AchievementTypeRepository) FindByCode(code string) (*models.AchievementType, error) {
+	var achievementType models.AchievementType
+	err := database.DB.Where("code = ?", code).First(&achievementType).Error
+	if err != nil {
+		return nil, err
+	}
+	return &achievementType, nil
+}
+
+// FindAll retrieves all achievement types, including inactive ones
+func (r *AchievementTypeRepository) FindAll() ([]models.AchievementType, error) {
+	var types []models.AchievementType
+	err := database.DB.Order("code ASC").Find(&types).Error
+	if err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+// FindActive retrieves achievement types that can be used for new achievements
+func (r *AchievementTypeRepository) FindActive() ([]models.AchievementType, error) {
+	var types []models.AchievementType
+	err := database.DB.Where("is_active = ?", true).Order("code ASC").Find(&types).Error
+	if err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+// Update updates an achievement type
+func (r *AchievementTypeRepository) Update(achievementType *models.AchievementType) error {
+	return database.DB.Save(achievementType).Error
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"UAS/app/models"
+	"UAS/database"
+)
+
+// ErrUploadOffsetMismatch is returned when an upload moved on since it was read, e.g. because two
+// requests sent the same chunk
+var ErrUploadOffsetMismatch = errors.New("upload offset changed")
+
+// AttachmentUploadRepository handles resumable upload operations
+type AttachmentUploadRepository struct{}
+
+// NewAttachmentUploadRepository creates a new instance of AttachmentUploadRepository
+func NewAttachmentUploadRepository() *AttachmentUploadRepository {
+	return &AttachmentUploadRepository{}
+}
+
+// Create stores a new upload
+func (r *AttachmentUploadRepository) Create(upload *models.AttachmentUpload) error {
+	return database.DB.Create(upload).Error
+}
+
+// FindByID finds an upload by ID
+func (r *AttachmentUploadRepository) FindByID(id string) (*models.AttachmentUpload, error) {
+	var upload models.AttachmentUpload
+	if err := database.DB.Where("id = ?", id).First(&upload).Error; err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// Advance records a stored chunk and moves the offset, provided the upload is still at the offset
+// the chunk was written for
+func (r *AttachmentUploadRepository) Advance(upload *models.AttachmentUpload, from int64) error {
+	result := database.DB.Model(&models.AttachmentUpload{}).
+		Where("id = ? AND upload_offset = ? AND status = ?", upload.ID, from, models.UploadInProgress).
+		Select("upload_offset", "chunks", "updated_at").
+		Updates(upload)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUploadOffsetMismatch
+	}
+	return nil
+}
+
+// Complete marks an upload completed inside the transaction that records its attachment
+func (r *AttachmentUploadRepository) Complete(tx *gorm.DB, upload *models.AttachmentUpload, from int64) error {
+	result := tx.Model(&models.AttachmentUpload{}).
+		Where("id = ? AND upload_offset = ? AND status = ?", upload.ID, from, models.UploadInProgress).
+		Updates(map[string]interface{}{"upload_offset": upload.Length, "status": models.UploadCompleted, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUploadOffsetMismatch
+	}
+	return nil
+}
+
+// MarkFailed records why a completed upload was rejected
+func (r *AttachmentUploadRepository) MarkFailed(id, message string) error {
+	return database.DB.Model(&models.AttachmentUpload{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"status": models.UploadFailed, "error": message, "updated_at": time.Now()}).Error
+}
+
+// Delete removes an upload
+func (r *AttachmentUploadRepository) Delete(id string) error {
+	return database.DB.Where("id = ?", id).Delete(&models.AttachmentUpload{}).Error
+}
+
+// FindExpired finds uploads that expired before the given time
+func (r *AttachmentUploadRepository) FindExpired(before time.Time) ([]models.AttachmentUpload, error) {
+	var uploads []models.AttachmentUpload
+	err := database.DB.Where("expires_at < ?", before).Find(&uploads).Error
+	return uploads, err
+}
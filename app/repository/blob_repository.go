@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"UAS/app/models"
+	"UAS/database"
+)
+
+// BlobRepository handles content-addressed blobs, their reference counts and the attachment
+// references charged to student storage quotas
+type BlobRepository struct{}
+
+// NewBlobRepository creates a new instance of BlobRepository
+func NewBlobRepository() *BlobRepository {
+	return &BlobRepository{}
+}
+
+// LockStudent serializes quota checks of a student until the transaction ends
+func (r *BlobRepository) LockStudent(tx *gorm.DB, studentID string) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(?)", advisoryLockKey("storage:"+studentID)).Error
+}
+
+// Usage returns the distinct bytes and files charged to a student and the number of attachments
+// using them, inside tx when given
+func (r *BlobRepository) Usage(tx *gorm.DB, studentID string) (used, files, attachments int64, err error) {
+	db := database.DB
+	if tx != nil {
+		db = tx
+	}
+	var row struct {
+		Used  int64
+		Files int64
+	}
+	err = db.Raw(`SELECT COALESCE(SUM(size), 0) AS used, COUNT(*) AS files FROM (
+		SELECT sha256, MAX(size) AS size FROM blob_references WHERE student_id = ? GROUP BY sha256) AS distinct_blobs`, studentID).
+		Scan(&row).Error
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	err = db.Model(&models.BlobReference{}).Where("student_id = ?", studentID).Count(&attachments).Error
+	return row.Used, row.Files, attachments, err
+}
+
+// StudentHasContent reports whether a student already has an attachment with the given content
+func (r *BlobRepository) StudentHasContent(tx *gorm.DB, studentID, sha256 string) (bool, error) {
+	var count int64
+	err := tx.Model(&models.BlobReference{}).Where("student_id = ? AND sha256 = ?", studentID, sha256).Count(&count).Error
+	return count > 0, err
+}
+
+// Acquire adds a reference to the blob with the content of blob, creating it when the content is
+// new, and loads the stored row into blob. The row stays locked until the transaction ends, so the
+// blob cannot be collected meanwhile
+func (r *BlobRepository) Acquire(tx *gorm.DB, blob *models.StoredBlob) error {
+	now := time.Now()
+	blob.RefCount = 1
+	err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "sha256"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"ref_count":  gorm.Expr("stored_blobs.ref_count + 1"),
+			"updated_at": now,
+		}),
+	}).Create(blob).Error
+	if err != nil {
+		return err
+	}
+	return tx.Where("sha256 = ?", blob.SHA256).First(blob).Error
+}
+
+// AddReference records the attachment using a blob
+func (r *BlobRepository) AddReference(tx *gorm.DB, ref *models.BlobReference) error {
+	return tx.Create(ref).Error
+}
+
+// Release removes the reference of an attachment and drops the reference count of its blob.
+// Attachments stored before deduplication have no reference and are ignored
+func (r *BlobRepository) Release(tx *gorm.DB, attachmentID string) error {
+	var refs []models.BlobReference
+	if err := tx.Where("attachment_id = ?", attachmentID).Find(&refs).Error; err != nil {
+		return err
+	}
+	return r.release(tx, refs)
+}
+
+// ReleaseAchievement removes the references of every attachment of an achievement
+func (r *BlobRepository) ReleaseAchievement(tx *gorm.DB, achievementID string) error {
+	var refs []models.BlobReference
+	if err := tx.Where("achievement_id = ?", achievementID).Find(&refs).Error; err != nil {
+		return err
+	}
+	return r.release(tx, refs)
+}
+
+func (r *BlobRepository) release(tx *gorm.DB, refs []models.BlobReference) error {
+	for _, ref := range refs {
+		if err := tx.Where("attachment_id = ?", ref.AttachmentID).Delete(&models.BlobReference{}).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&models.StoredBlob{}).Where("sha256 = ? AND ref_count > 0", ref.SHA256).
+			Updates(map[string]interface{}{"ref_count": gorm.Expr("ref_count - 1"), "updated_at": time.Now()}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindBySHA256 finds a blob by the hash of its content
+func (r *BlobRepository) FindBySHA256(sha256 string) (*models.StoredBlob, error) {
+	var blob models.StoredBlob
+	if err := database.DB.Where("sha256 = ?", sha256).First(&blob).Error; err != nil {
+		return nil, err
+	}
+	return &blob, nil
+}
+
+// MarkQuarantined records that the content of a blob is malware and was moved to key
+func (r *BlobRepository) MarkQuarantined(sha256, key, signature string) error {
+	return database.DB.Model(&models.StoredBlob{}).Where("sha256 = ?", sha256).
+		Updates(map[string]interface{}{"storage_key": key, "quarantined": true, "signature": signature, "updated_at": time.Now()}).Error
+}
+
+// FindUnreferenced finds blobs without references since before, oldest first
+func (r *BlobRepository) FindUnreferenced(before time.Time, limit int) ([]models.StoredBlob, error) {
+	var blobs []models.StoredBlob
+	err := database.DB.Where("ref_count = 0 AND updated_at < ?", before).
+		Order("updated_at ASC").Limit(limit).Find(&blobs).Error
+	return blobs, err
+}
+
+// DeleteUnreferenced deletes a blob row that still has no references, calling remove first to
+// delete the stored file. The row is locked meanwhile, so an upload of the same content waits and
+// then stores the file again. Returns false when the blob was referenced again or is gone
+func (r *BlobRepository) DeleteUnreferenced(sha256 string, remove func(blob *models.StoredBlob) error) (bool, error) {
+	deleted := false
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var blob models.StoredBlob
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("sha256 = ? AND ref_count = 0", sha256).Limit(1).Find(&blob)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := remove(&blob); err != nil {
+			return err
+		}
+		if err := tx.Where("sha256 = ?", sha256).Delete(&models.StoredBlob{}).Error; err != nil {
+			return err
+		}
+		deleted = true
+		return nil
+	})
+	return deleted, err
+}
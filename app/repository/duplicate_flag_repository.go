@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"time"
+
+	"UAS/app/models"
+	"UAS/database"
+)
+
+// DuplicateFlagRepository handles potential duplicate flag operations
+type DuplicateFlagRepository struct{}
+
+// NewDuplicateFlagRepository creates a new instance of DuplicateFlagRepository
+func NewDuplicateFlagRepository() *DuplicateFlagRepository {
+	return &DuplicateFlagRepository{}
+}
+
+// ReplaceOpen removes the open flags of an achievement and stores the new ones in a single transaction
+func (r *DuplicateFlagRepository) ReplaceOpen(achievementID string, flags []models.AchievementDuplicateFlag) error {
+	tx := database.DB.Begin()
+	if err := tx.Where("achievement_id = ? AND status = ?", achievementID, models.DuplicateFlagOpen).
+		Delete(&models.AchievementDuplicateFlag{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i := range flags {
+		if err := tx.Create(&flags[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+// FindByAchievementID finds all flags of an achievement, highest score first
+func (r *DuplicateFlagRepository) FindByAchievementID(achievementID string) ([]models.AchievementDuplicateFlag, error) {
+	var flags []models.AchievementDuplicateFlag
+	err := database.DB.Where("achievement_id = ?", achievementID).Order("score DESC").Find(&flags).Error
+	if err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+// FindByID finds a flag by ID
+func (r *DuplicateFlagRepository) FindByID(id string) (*models.AchievementDuplicateFlag, error) {
+	var flag models.AchievementDuplicateFlag
+	err := database.DB.Where("id = ?", id).First(&flag).Error
+	if err != nil {
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// FindDismissedPairs returns matched achievement IDs whose flags a reviewer already dismissed
+func (r *DuplicateFlagRepository) FindDismissedPairs(achievementID string) ([]string, error) {
+	var ids []string
+	err := database.DB.Model(&models.AchievementDuplicateFlag{}).
+		Where("achievement_id = ? AND status = ?", achievementID, models.DuplicateFlagDismissed).
+		Pluck("matched_achievement_id", &ids).Error
+	return ids, err
+}
+
+// Dismiss marks a flag as reviewed and not a duplicate
+func (r *DuplicateFlagRepository) Dismiss(id, dismissedBy string) error {
+	now := time.Now()
+	return database.DB.Model(&models.AchievementDuplicateFlag{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       models.DuplicateFlagDismissed,
+			"dismissed_by": dismissedBy,
+			"dismissed_at": &now,
+		}).Error
+}
+
+// CountOpen counts open flags of an achievement
+func (r *DuplicateFlagRepository) CountOpen(achievementID string) (int64, error) {
+	var count int64
+	err := database.DB.Model(&models.AchievementDuplicateFlag{}).
+		Where("achievement_id = ? AND status = ?", achievementID, models.DuplicateFlagOpen).
+		Count(&count).Error
+	return count, err
+}
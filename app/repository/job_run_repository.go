@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"hash/fnv"
+	"time"
+
+	"UAS/app/models"
+	"UAS/database"
+)
+
+// JobRunRepository handles background job run records and cross-instance job locks
+type JobRunRepository struct{}
+
+// NewJobRunRepository creates a new instance of JobRunRepository
+func NewJobRunRepository() *JobRunRepository {
+	return &JobRunRepository{}
+}
+
+// advisoryLockKey maps a lock name to a PostgreSQL advisory lock key
+func advisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+// AdvisoryLock is a session-level PostgreSQL advisory lock held on a connection of its own, so
+// no transaction stays open while the lock is held
+type AdvisoryLock struct {
+	conn *sql.Conn
+	key  int64
+}
+
+// TryAdvisoryLock takes the advisory lock of name on a dedicated connection. It returns nil when
+// another session holds the lock. The lock is held until Release, or until the connection drops
+// if the instance crashes
+func (r *JobRunRepository) TryAdvisoryLock(ctx context.Context, name string) (*AdvisoryLock, error) {
+	db, err := database.DB.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key := advisoryLockKey(name)
+	acquired := false
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil || !acquired {
+		conn.Close()
+		return nil, err
+	}
+	return &AdvisoryLock{conn: conn, key: key}, nil
+}
+
+// Release unlocks and returns the connection to the pool. A connection that cannot be unlocked is
+// discarded instead, which also releases the lock
+func (l *AdvisoryLock) Release() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key)
+	if err != nil {
+		l.conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	}
+	l.conn.Close()
+	return err
+}
+
+// Create creates a job run record. A duplicate scheduled slot returns an error
+func (r *JobRunRepository) Create(run *models.JobRun) error {
+	return database.DB.Create(run).Error
+}
+
+// Update saves a job run record
+func (r *JobRunRepository) Update(run *models.JobRun) error {
+	return database.DB.Save(run).Error
+}
+
+// SlotExists reports whether a scheduled slot of a job was already run
+func (r *JobRunRepository) SlotExists(jobName string, scheduledFor time.Time) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.JobRun{}).
+		Where("job_name = ? AND scheduled_for = ?", jobName, scheduledFor).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindLatest finds the most recent run of every job, keyed by job name
+func (r *JobRunRepository) FindLatest() (map[string]models.JobRun, error) {
+	var runs []models.JobRun
+	err := database.DB.Raw(`
+		SELECT DISTINCT ON (job_name) * FROM job_runs
+		ORDER BY job_name, started_at DESC`).Scan(&runs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]models.JobRun, len(runs))
+	for _, run := range runs {
+		latest[run.JobName] = run
+	}
+	return latest, nil
+}
+
+// FindByJobName finds runs of a job, newest first
+func (r *JobRunRepository) FindByJobName(jobName string, offset, limit int) ([]models.JobRun, int64, error) {
+	var runs []models.JobRun
+	var total int64
+
+	query := database.DB.Model(&models.JobRun{}).Where("job_name = ?", jobName)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("started_at DESC").Offset(offset).Limit(limit).Find(&runs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return runs, total, nil
+}
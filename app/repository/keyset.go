@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+
+	"UAS/app/models"
+)
+
+// errCursorKey is returned for cursors whose sort key does not fit the sort column
+var errCursorKey = errors.New("invalid cursor key")
+
+// keysetColumn is a sort key of a keyset-paginated list
+type keysetColumn struct {
+	expr      string // ORDER BY expression
+	param     string // expression comparing a cursor key with expr, "?" unless it needs the same function
+	timestamp bool   // cursor keys are RFC 3339 timestamps
+}
+
+// createdAtColumn sorts by creation time
+var createdAtColumn = keysetColumn{expr: "created_at", param: "?", timestamp: true}
+
+// keysetQuery orders query by column and id and narrows it to the rows past cursor, fetching one
+// row more than limit so the caller can tell whether another page follows. A backward cursor
+// reads the rows before it, nearest first; keysetRows puts them back in list order
+func keysetQuery(query *gorm.DB, column keysetColumn, descending bool, cursor *models.Cursor, limit int) (*gorm.DB, error) {
+	// Reading backward walks the list in the opposite direction
+	reverse := cursor != nil && cursor.Backward
+	ascending := descending == reverse
+
+	if cursor != nil {
+		var key interface{} = cursor.Key
+		if column.timestamp {
+			t, err := time.Parse(time.RFC3339Nano, cursor.Key)
+			if err != nil {
+				return nil, errCursorKey
+			}
+			key = t
+		}
+		op := "<"
+		if ascending {
+			op = ">"
+		}
+		query = query.Where("("+column.expr+", id) "+op+" ("+column.param+", ?)", key, cursor.ID)
+	}
+
+	direction := "DESC"
+	if ascending {
+		direction = "ASC"
+	}
+	return query.Order(column.expr + " " + direction).Order("id " + direction).Limit(limit + 1), nil
+}
+
+// keysetRows trims the extra row fetched by keysetQuery and restores list order after reading
+// backward. Reports whether more rows follow in the direction read
+func keysetRows[T any](rows []T, cursor *models.Cursor, limit int) ([]T, bool) {
+	more := len(rows) > limit
+	if more {
+		rows = rows[:limit]
+	}
+	if cursor != nil && cursor.Backward {
+		slices.Reverse(rows)
+	}
+	return rows, more
+}
@@ -55,6 +55,25 @@ func (r *LecturerRepository) FindAll() ([]models.Lecturer, error) {
 	return lecturers, err
 }
 
+// FindPage finds one keyset page of lecturers, oldest first, and counts them all. more reports
+// whether rows follow in the direction read
+func (r *LecturerRepository) FindPage(cursor *models.Cursor, limit int) (lecturers []models.Lecturer, total int64, more bool, err error) {
+	query := database.DB.Model(&models.Lecturer{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, false, err
+	}
+
+	page, err := keysetQuery(query, createdAtColumn, false, cursor, limit)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if err := page.Find(&lecturers).Error; err != nil {
+		return nil, 0, false, err
+	}
+	lecturers, more = keysetRows(lecturers, cursor, limit)
+	return lecturers, total, more, nil
+}
+
 // CountTotal counts total lecturers
 func (r *LecturerRepository) CountTotal() (int64, error) {
 	var count int64
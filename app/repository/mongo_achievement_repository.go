@@ -11,8 +11,12 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrAttachmentNotFound is returned when an attachment update finds no attachment with the ID
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
 // MongoAchievementRepository handles MongoDB achievement operations
 type MongoAchievementRepository struct {
 	collection *mongo.Collection
@@ -143,3 +147,379 @@ func (r *MongoAchievementRepository) FindAll(ctx context.Context) ([]models.Mong
 
 	return achievements, nil
 }
+
+// UpdatePoints sets only the points field of an achievement document
+func (r *MongoAchievementRepository) UpdatePoints(ctx context.Context, id string, points int) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid achievement id")
+	}
+
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": bson.M{"points": points, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("achievement not found")
+	}
+
+	return nil
+}
+
+// FindDuplicateCandidates finds other achievements sharing a normalized title, certification number,
+// event date or attachment hash with the given one. Similarity is scored by the caller
+func (r *MongoAchievementRepository) FindDuplicateCandidates(ctx context.Context, achievement *models.MongoAchievement, limit int64) ([]models.MongoAchievement, error) {
+	var or []bson.M
+	if achievement.NormalizedTitle != "" {
+		or = append(or, bson.M{"normalized_title": achievement.NormalizedTitle})
+	}
+	if certNumber, ok := achievement.Details["certification_number"].(string); ok && certNumber != "" {
+		or = append(or, bson.M{"details.certification_number": certNumber})
+	}
+	if eventDate, ok := achievement.Details["event_date"]; ok && eventDate != nil && eventDate != "" {
+		or = append(or, bson.M{"details.event_date": eventDate})
+	}
+	var hashes []string
+	for _, a := range achievement.Attachments {
+		if a.SHA256 != "" {
+			hashes = append(hashes, a.SHA256)
+		}
+	}
+	if len(hashes) > 0 {
+		or = append(or, bson.M{"attachments.sha256": bson.M{"$in": hashes}})
+	}
+	if len(or) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"_id":        bson.M{"$ne": achievement.ID},
+		"deleted_at": bson.M{"$exists": false},
+		"$or":        or,
+	}, options.Find().SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var achievements []models.MongoAchievement
+	if err = cursor.All(ctx, &achievements); err != nil {
+		return nil, err
+	}
+
+	return achievements, nil
+}
+
+// FindByIDs finds achievements by multiple IDs in a single query, keyed by hex ID
+func (r *MongoAchievementRepository) FindByIDs(ctx context.Context, ids []string) (map[string]models.MongoAchievement, error) {
+	result := make(map[string]models.MongoAchievement, len(ids))
+	objIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if objID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objIDs = append(objIDs, objID)
+		}
+	}
+	if len(objIDs) == 0 {
+		return result, nil
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"_id":        bson.M{"$in": objIDs},
+		"deleted_at": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var achievements []models.MongoAchievement
+	if err = cursor.All(ctx, &achievements); err != nil {
+		return nil, err
+	}
+	for _, a := range achievements {
+		result[a.ID.Hex()] = a
+	}
+	return result, nil
+}
+
+// Insert stores a document under its preset ID. Inserting a document that already exists is a no-op,
+// so a retried create never duplicates or overwrites it
+func (r *MongoAchievementRepository) Insert(ctx context.Context, achievement *models.MongoAchievement) error {
+	// The upsert takes _id from the filter; repeating it in $setOnInsert is rejected
+	doc := *achievement
+	doc.ID = primitive.NilObjectID
+
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": achievement.ID},
+		bson.M{"$setOnInsert": doc},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// UpdateContent sets the editable fields of a document without touching its points or attachments
+func (r *MongoAchievementRepository) UpdateContent(ctx context.Context, id string, achievement *models.MongoAchievement) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid achievement id")
+	}
+
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": bson.M{
+			"title":            achievement.Title,
+			"normalized_title": achievement.NormalizedTitle,
+			"description":      achievement.Description,
+			"achievement_type": achievement.AchievementType,
+			"details":          achievement.Details,
+			"tags":             achievement.Tags,
+			"updated_at":       achievement.UpdatedAt,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("achievement not found")
+	}
+
+	return nil
+}
+
+// AddAttachment appends an attachment unless an attachment with the same ID (or, for attachments
+// without one, the identical attachment) is already stored
+func (r *MongoAchievementRepository) AddAttachment(ctx context.Context, id string, attachment models.Attachment) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid achievement id")
+	}
+
+	// Documents created without attachments store null, which $addToSet cannot extend
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": objID, "attachments": nil},
+		bson.M{"$set": bson.M{"attachments": []models.Attachment{}}}); err != nil {
+		return err
+	}
+
+	// Matching on the ID keeps a re-applied entry from adding a second copy once the stored
+	// attachment has changed, e.g. after its malware scan
+	filter := bson.M{"_id": objID}
+	if attachment.ID != "" {
+		filter["attachments.id"] = bson.M{"$ne": attachment.ID}
+	}
+	result, err := r.collection.UpdateOne(
+		ctx,
+		filter,
+		bson.M{
+			"$addToSet": bson.M{"attachments": attachment},
+			"$set":      bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": objID})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("achievement not found")
+		}
+	}
+
+	return nil
+}
+
+// UpdateAttachmentScan records the malware scan verdict of an attachment and where its file is stored
+func (r *MongoAchievementRepository) UpdateAttachmentScan(ctx context.Context, id, attachmentID string, attachment models.Attachment) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid achievement id")
+	}
+
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": objID, "attachments.id": attachmentID},
+		bson.M{"$set": bson.M{
+			"attachments.$.scan_status":    attachment.ScanStatus,
+			"attachments.$.scan_signature": attachment.ScanSignature,
+			"attachments.$.scanned_at":     attachment.ScannedAt,
+			"attachments.$.storage_key":    attachment.StorageKey,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrAttachmentNotFound
+	}
+
+	return nil
+}
+
+// SetAttachmentPreview records the generated preview of an attachment
+func (r *MongoAchievementRepository) SetAttachmentPreview(ctx context.Context, id, attachmentID string, preview models.AttachmentPreview) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid achievement id")
+	}
+
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": objID, "attachments.id": attachmentID},
+		bson.M{"$set": bson.M{"attachments.$.preview": preview}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrAttachmentNotFound
+	}
+
+	return nil
+}
+
+// RemoveAttachment removes an attachment; removing it twice is a no-op
+func (r *MongoAchievementRepository) RemoveAttachment(ctx context.Context, id string, attachment models.Attachment) error {
+	return r.updateFields(ctx, id, bson.M{
+		"$pull": bson.M{"attachments": attachmentMatch(attachment)},
+		"$set":  bson.M{"updated_at": time.Now()},
+	})
+}
+
+// ReplaceAttachment puts an attachment in the place of another one. Nothing changes once the old
+// attachment is gone, so a re-applied entry does not replace the new attachment again
+func (r *MongoAchievementRepository) ReplaceAttachment(ctx context.Context, id string, old, attachment models.Attachment) error {
+	return r.updateAttachment(ctx, id, old, bson.M{
+		"attachments.$": attachment,
+		"updated_at":    time.Now(),
+	})
+}
+
+// UpdateAttachmentMeta sets the caption and kind of an attachment
+func (r *MongoAchievementRepository) UpdateAttachmentMeta(ctx context.Context, id string, attachment models.Attachment) error {
+	return r.updateAttachment(ctx, id, attachment, bson.M{
+		"attachments.$.caption": attachment.Caption,
+		"attachments.$.kind":    attachment.Kind,
+		"updated_at":            time.Now(),
+	})
+}
+
+// ReorderAttachments moves the attachments into the given order: attachment IDs, or file URLs for
+// attachments stored without an ID. Attachments not listed keep their relative order at the end.
+// The reorder runs on the server, so it cannot undo a scan verdict recorded at the same time
+func (r *MongoAchievementRepository) ReorderAttachments(ctx context.Context, id string, order []string) error {
+	ref := bson.M{"$ifNull": bson.A{"$$a.id", "$$a.file_url"}}
+	listed := bson.M{"$map": bson.M{
+		"input": order,
+		"as":    "ref",
+		"in": bson.M{"$arrayElemAt": bson.A{
+			bson.M{"$filter": bson.M{"input": "$attachments", "as": "a", "cond": bson.M{"$eq": bson.A{ref, "$$ref"}}}},
+			0,
+		}},
+	}}
+	ordered := bson.M{"$filter": bson.M{"input": listed, "as": "a", "cond": bson.M{"$eq": bson.A{bson.M{"$type": "$$a"}, "object"}}}}
+	rest := bson.M{"$filter": bson.M{"input": "$attachments", "as": "a", "cond": bson.M{"$not": bson.A{bson.M{"$in": bson.A{ref, order}}}}}}
+
+	return r.updateFields(ctx, id, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"attachments": bson.M{"$concatArrays": bson.A{ordered, rest}},
+			"updated_at":  time.Now(),
+		}}},
+	})
+}
+
+// updateAttachment applies a positional update to one attachment. An attachment that is no longer
+// stored is not an error; only a missing document is
+func (r *MongoAchievementRepository) updateAttachment(ctx context.Context, id string, attachment models.Attachment, set bson.M) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid achievement id")
+	}
+
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": objID, "attachments": bson.M{"$elemMatch": attachmentMatch(attachment)}},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": objID})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("achievement not found")
+		}
+	}
+
+	return nil
+}
+
+// attachmentMatch selects a stored attachment by its ID, or by file URL for attachments stored without one
+func attachmentMatch(attachment models.Attachment) bson.M {
+	if attachment.ID != "" {
+		return bson.M{"id": attachment.ID}
+	}
+	return bson.M{"file_url": attachment.FileURL}
+}
+
+// FindAllWithDeleted finds every document, soft deleted ones included, without details or attachments
+func (r *MongoAchievementRepository) FindAllWithDeleted(ctx context.Context) ([]models.MongoAchievement, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{
+		"student_id": 1, "title": 1, "points": 1, "deleted_at": 1,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var achievements []models.MongoAchievement
+	if err = cursor.All(ctx, &achievements); err != nil {
+		return nil, err
+	}
+
+	return achievements, nil
+}
+
+// Restore clears the soft delete of a document
+func (r *MongoAchievementRepository) Restore(ctx context.Context, id string) error {
+	return r.updateFields(ctx, id, bson.M{"$unset": bson.M{"deleted_at": ""}, "$set": bson.M{"updated_at": time.Now()}})
+}
+
+// UpdateStudentID sets the owner of a document
+func (r *MongoAchievementRepository) UpdateStudentID(ctx context.Context, id, studentID string) error {
+	return r.updateFields(ctx, id, bson.M{"$set": bson.M{"student_id": studentID, "updated_at": time.Now()}})
+}
+
+func (r *MongoAchievementRepository) updateFields(ctx context.Context, id string, update interface{}) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid achievement id")
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("achievement not found")
+	}
+
+	return nil
+}
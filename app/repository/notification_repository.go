@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"UAS/app/models"
+	"UAS/database"
+)
+
+// NotificationRepository handles notification and notification preference operations
+type NotificationRepository struct{}
+
+// NewNotificationRepository creates a new instance of NotificationRepository
+func NewNotificationRepository() *NotificationRepository {
+	return &NotificationRepository{}
+}
+
+// CreateMany stores notifications in one insert
+func (r *NotificationRepository) CreateMany(notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return database.DB.Create(&notifications).Error
+}
+
+// FindByUserID finds the notifications of a user, newest first
+func (r *NotificationRepository) FindByUserID(userID string, unreadOnly bool, offset, limit int) ([]models.Notification, int64, error) {
+	var notifications []models.Notification
+	var total int64
+
+	query := database.DB.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&notifications).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
+
+// FindSince finds the notifications of a user created since a time, newest first
+func (r *NotificationRepository) FindSince(userID string, since time.Time, limit int) ([]models.Notification, error) {
+	var notifications []models.Notification
+	err := database.DB.Where("user_id = ? AND created_at >= ?", userID, since).
+		Order("created_at DESC").Limit(limit).Find(&notifications).Error
+	if err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// FindUserIDsWithNotificationsSince returns the users who received notifications since a time
+func (r *NotificationRepository) FindUserIDsWithNotificationsSince(since time.Time) ([]string, error) {
+	var ids []string
+	err := database.DB.Model(&models.Notification{}).
+		Where("created_at >= ?", since).
+		Distinct().Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// CountUnread counts the unread notifications of a user
+func (r *NotificationRepository) CountUnread(userID string) (int64, error) {
+	var count int64
+	err := database.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead marks notifications of a user as read; all unread ones when ids is empty
+// Returns the number of notifications that changed
+func (r *NotificationRepository) MarkRead(userID string, ids []string) (int64, error) {
+	query := database.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	result := query.Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// FindPreferences finds the stored preferences of a user
+func (r *NotificationRepository) FindPreferences(userID string) ([]models.NotificationPreference, error) {
+	var prefs []models.NotificationPreference
+	err := database.DB.Where("user_id = ?", userID).Find(&prefs).Error
+	if err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+// SavePreferences creates or updates preferences
+func (r *NotificationRepository) SavePreferences(prefs []models.NotificationPreference) error {
+	if len(prefs) == 0 {
+		return nil
+	}
+	return database.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&prefs).Error
+}
+
+// Notification delivery channels, named after their preference column
+const (
+	ChannelInApp = "in_app"
+	ChannelEmail = "email"
+)
+
+// FindOptedOut returns which of the given users turned off an event type on a channel
+func (r *NotificationRepository) FindOptedOut(channel, eventType string, userIDs []string) (map[string]bool, error) {
+	optedOut := make(map[string]bool)
+	if len(userIDs) == 0 {
+		return optedOut, nil
+	}
+	if channel != ChannelInApp && channel != ChannelEmail {
+		return nil, errors.New("unknown notification channel: " + channel)
+	}
+
+	var ids []string
+	err := database.DB.Model(&models.NotificationPreference{}).
+		Where("type = ? AND user_id IN ?", eventType, userIDs).
+		Where(channel+" = ?", false).
+		Pluck("user_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		optedOut[id] = true
+	}
+	return optedOut, nil
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"UAS/app/models"
+	"UAS/database"
+)
+
+// OutboxRepository handles achievement outbox operations
+type OutboxRepository struct{}
+
+// NewOutboxRepository creates a new instance of OutboxRepository
+func NewOutboxRepository() *OutboxRepository {
+	return &OutboxRepository{}
+}
+
+// Create stores an entry. Pass the transaction of the PostgreSQL change it belongs to
+func (r *OutboxRepository) Create(tx *gorm.DB, entry *models.OutboxEntry) error {
+	if tx == nil {
+		tx = database.DB
+	}
+	return tx.Create(entry).Error
+}
+
+// FindByID finds an entry by ID
+func (r *OutboxRepository) FindByID(id int64) (*models.OutboxEntry, error) {
+	var entry models.OutboxEntry
+	if err := database.DB.First(&entry, id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// HasPendingBefore reports whether an older entry of the same achievement is still pending
+// Entries are applied in order so a retried change never overwrites a newer one; failed entries
+// no longer hold the achievement back
+func (r *OutboxRepository) HasPendingBefore(achievementID string, id int64) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.OutboxEntry{}).
+		Where("achievement_id = ? AND id < ? AND status = ?", achievementID, id, models.OutboxPending).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// HasPending reports whether an achievement has entries that are not applied yet
+func (r *OutboxRepository) HasPending(achievementID string) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.OutboxEntry{}).
+		Where("achievement_id = ? AND status = ?", achievementID, models.OutboxPending).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// MarkApplied moves a pending (or retried failed) entry to applied. It returns false when another
+// worker applied it first, so only one caller dispatches the entry's events
+func (r *OutboxRepository) MarkApplied(id int64) (bool, error) {
+	now := time.Now()
+	result := database.DB.Model(&models.OutboxEntry{}).
+		Where("id = ? AND status IN ?", id, []string{models.OutboxPending, models.OutboxFailed}).
+		Updates(map[string]interface{}{"status": models.OutboxApplied, "applied_at": now, "last_error": ""})
+	return result.RowsAffected == 1, result.Error
+}
+
+// RecordFailure counts a failed attempt and keeps its error
+func (r *OutboxRepository) RecordFailure(id int64, message string) error {
+	return database.DB.Model(&models.OutboxEntry{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"attempts": gorm.Expr("attempts + 1"), "last_error": message}).Error
+}
+
+// MarkFailed gives up on a pending entry after its last attempt, so later entries of the same
+// achievement are no longer held back by it
+func (r *OutboxRepository) MarkFailed(id int64, message string) error {
+	return database.DB.Model(&models.OutboxEntry{}).
+		Where("id = ? AND status = ?", id, models.OutboxPending).
+		Updates(map[string]interface{}{"status": models.OutboxFailed, "last_error": message}).Error
+}
+
+// FindFailed finds the entries that were given up on, oldest first
+func (r *OutboxRepository) FindFailed() ([]models.OutboxEntry, error) {
+	var entries []models.OutboxEntry
+	err := database.DB.Where("status = ?", models.OutboxFailed).Order("id ASC").Find(&entries).Error
+	return entries, err
+}
+
+// HasAppliedAfter reports whether a newer entry of the same achievement was applied, which a
+// requeued older entry could overwrite
+func (r *OutboxRepository) HasAppliedAfter(achievementID string, id int64) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.OutboxEntry{}).
+		Where("achievement_id = ? AND id > ? AND status = ?", achievementID, id, models.OutboxApplied).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Requeue moves a failed entry back to pending with a fresh set of attempts; enqueue queues its
+// application in the same transaction
+func (r *OutboxRepository) Requeue(id int64, enqueue func(tx *gorm.DB) error) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.OutboxEntry{}).
+			Where("id = ? AND status = ?", id, models.OutboxFailed).
+			Updates(map[string]interface{}{"status": models.OutboxPending, "attempts": 0})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("outbox entry is no longer failed")
+		}
+		return enqueue(tx)
+	})
+}
+
+// CountPending counts entries not applied yet
+func (r *OutboxRepository) CountPending() (int64, error) {
+	var count int64
+	err := database.DB.Model(&models.OutboxEntry{}).Where("status = ?", models.OutboxPending).Count(&count).Error
+	return count, err
+}
+
+// DeleteAppliedBefore removes applied entries older than the given time
+func (r *OutboxRepository) DeleteAppliedBefore(before time.Time) (int64, error) {
+	result := database.DB.Where("status = ? AND applied_at < ?", models.OutboxApplied, before).Delete(&models.OutboxEntry{})
+	return result.RowsAffected, result.Error
+}
+
+// FindPendingAchievementIDs returns the achievements that still have changes waiting to be applied
+func (r *OutboxRepository) FindPendingAchievementIDs() ([]string, error) {
+	var ids []string
+	err := database.DB.Model(&models.OutboxEntry{}).Where("status = ?", models.OutboxPending).
+		Distinct().Pluck("achievement_id", &ids).Error
+	return ids, err
+}
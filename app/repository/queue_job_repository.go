@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"UAS/app/models"
+	"UAS/database"
+)
+
+// QueueJobRepository handles background queue job operations
+type QueueJobRepository struct{}
+
+// NewQueueJobRepository creates a new instance of QueueJobRepository
+func NewQueueJobRepository() *QueueJobRepository {
+	return &QueueJobRepository{}
+}
+
+// Create enqueues a job. Pass a transaction to enqueue atomically with other changes
+func (r *QueueJobRepository) Create(tx *gorm.DB, job *models.QueueJob) error {
+	if tx == nil {
+		tx = database.DB
+	}
+	return tx.Create(job).Error
+}
+
+// Claim locks the next due job of a type for a worker using FOR UPDATE SKIP LOCKED,
+// so concurrent workers on any instance never receive the same job. Running jobs whose
+// lock is older than staleBefore belong to a crashed worker and are claimed again
+// Returns nil when no job is due
+func (r *QueueJobRepository) Claim(jobType, workerID string, staleBefore time.Time) (*models.QueueJob, error) {
+	var job models.QueueJob
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("type = ?", jobType).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_at < ?)",
+				models.QueueJobPending, time.Now(), models.QueueJobRunning, staleBefore).
+			Order("run_at ASC").
+			First(&job).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		job.Status = models.QueueJobRunning
+		job.Attempts++
+		job.LockedBy = workerID
+		job.LockedAt = &now
+		job.UpdatedAt = now
+		return tx.Save(&job).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Complete marks a job as succeeded
+func (r *QueueJobRepository) Complete(id string) error {
+	now := time.Now()
+	return database.DB.Model(&models.QueueJob{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       models.QueueJobSucceeded,
+			"last_error":   "",
+			"locked_by":    "",
+			"completed_at": now,
+			"updated_at":   now,
+		}).Error
+}
+
+// Fail records a failed attempt. The job runs again at retryAt, or becomes dead when retryAt is nil
+func (r *QueueJobRepository) Fail(id, lastError string, retryAt *time.Time) error {
+	updates := map[string]interface{}{
+		"last_error": lastError,
+		"locked_by":  "",
+		"updated_at": time.Now(),
+	}
+	if retryAt != nil {
+		updates["status"] = models.QueueJobPending
+		updates["run_at"] = *retryAt
+	} else {
+		updates["status"] = models.QueueJobDead
+	}
+	return database.DB.Model(&models.QueueJob{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// Postpone puts a claimed job back until runAt and gives back the attempt it was claimed with
+func (r *QueueJobRepository) Postpone(id, lastError string, runAt time.Time) error {
+	return database.DB.Model(&models.QueueJob{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     models.QueueJobPending,
+			"attempts":   gorm.Expr("GREATEST(attempts - 1, 0)"),
+			"run_at":     runAt,
+			"last_error": lastError,
+			"locked_by":  "",
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// FindByID finds a job by ID
+func (r *QueueJobRepository) FindByID(id string) (*models.QueueJob, error) {
+	var job models.QueueJob
+	err := database.DB.Where("id = ?", id).First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FindAll finds jobs filtered by status and type (empty means any), newest first
+func (r *QueueJobRepository) FindAll(status, jobType string, offset, limit int) ([]models.QueueJob, int64, error) {
+	var jobs []models.QueueJob
+	var total int64
+
+	query := database.DB.Model(&models.QueueJob{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&jobs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
+
+// Retry puts a dead job back in the queue with a fresh set of attempts
+func (r *QueueJobRepository) Retry(id string) error {
+	result := database.DB.Model(&models.QueueJob{}).
+		Where("id = ? AND status = ?", id, models.QueueJobDead).
+		Updates(map[string]interface{}{
+			"status":     models.QueueJobPending,
+			"attempts":   0,
+			"run_at":     time.Now(),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("only dead jobs can be retried")
+	}
+	return nil
+}
+
+// DeleteSucceededBefore removes succeeded jobs completed before the given time
+func (r *QueueJobRepository) DeleteSucceededBefore(before time.Time) (int64, error) {
+	result := database.DB.Where("status = ? AND completed_at < ?", models.QueueJobSucceeded, before).
+		Delete(&models.QueueJob{})
+	return result.RowsAffected, result.Error
+}
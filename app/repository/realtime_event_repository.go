@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"time"
+
+	"UAS/app/models"
+	"UAS/database"
+)
+
+// RealtimeEventRepository handles real-time event operations
+type RealtimeEventRepository struct{}
+
+// NewRealtimeEventRepository creates a new instance of RealtimeEventRepository
+func NewRealtimeEventRepository() *RealtimeEventRepository {
+	return &RealtimeEventRepository{}
+}
+
+// Create stores an event and assigns its ID
+func (r *RealtimeEventRepository) Create(event *models.RealtimeEvent) error {
+	return database.DB.Create(event).Error
+}
+
+// FindAfter finds events with an ID greater than afterID, oldest first
+func (r *RealtimeEventRepository) FindAfter(afterID int64, limit int) ([]models.RealtimeEvent, error) {
+	var events []models.RealtimeEvent
+	err := database.DB.Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// LatestID returns the ID of the newest event, 0 when there is none
+func (r *RealtimeEventRepository) LatestID() (int64, error) {
+	var id int64
+	err := database.DB.Model(&models.RealtimeEvent{}).Select("COALESCE(MAX(id), 0)").Scan(&id).Error
+	return id, err
+}
+
+// DeleteBefore removes events created before the given time
+func (r *RealtimeEventRepository) DeleteBefore(before time.Time) (int64, error) {
+	result := database.DB.Where("created_at < ?", before).Delete(&models.RealtimeEvent{})
+	return result.RowsAffected, result.Error
+}
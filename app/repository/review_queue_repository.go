@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"gorm.io/gorm/clause"
+
+	"UAS/app/models"
+	"UAS/database"
+)
+
+// ReviewQueueRepository handles review SLA and escalation operations
+type ReviewQueueRepository struct{}
+
+// NewReviewQueueRepository creates a new instance of ReviewQueueRepository
+func NewReviewQueueRepository() *ReviewQueueRepository {
+	return &ReviewQueueRepository{}
+}
+
+// FindSLAs finds the deadline configuration of every review stage
+func (r *ReviewQueueRepository) FindSLAs() ([]models.ReviewSLA, error) {
+	var slas []models.ReviewSLA
+	err := database.DB.Order("stage ASC").Find(&slas).Error
+	if err != nil {
+		return nil, err
+	}
+	return slas, nil
+}
+
+// SaveSLA creates or updates the deadline of a review stage
+func (r *ReviewQueueRepository) SaveSLA(sla *models.ReviewSLA) error {
+	return database.DB.Save(sla).Error
+}
+
+// CreateEscalation stores an escalation once per achievement, stage and deadline
+// Returns false when the same deadline was already escalated
+func (r *ReviewQueueRepository) CreateEscalation(escalation *models.ReviewEscalation) (bool, error) {
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(escalation)
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindEscalations finds the most recent escalations
+func (r *ReviewQueueRepository) FindEscalations(offset, limit int) ([]models.ReviewEscalation, int64, error) {
+	var escalations []models.ReviewEscalation
+	var total int64
+
+	if err := database.DB.Model(&models.ReviewEscalation{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := database.DB.Order("created_at DESC").Offset(offset).Limit(limit).Find(&escalations).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return escalations, total, nil
+}
+
+// FindEscalatedAchievementIDs returns which of the given achievements were escalated
+func (r *ReviewQueueRepository) FindEscalatedAchievementIDs(achievementIDs []string) (map[string]bool, error) {
+	escalated := make(map[string]bool)
+	if len(achievementIDs) == 0 {
+		return escalated, nil
+	}
+
+	var ids []string
+	err := database.DB.Model(&models.ReviewEscalation{}).
+		Where("achievement_id IN ?", achievementIDs).
+		Distinct().Pluck("achievement_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		escalated[id] = true
+	}
+	return escalated, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"UAS/app/models"
+	"UAS/database"
+
+	"gorm.io/gorm"
+)
+
+// ScoringRubricRepository handles scoring rubric database operations
+type ScoringRubricRepository struct{}
+
+// NewScoringRubricRepository creates a new instance of ScoringRubricRepository
+func NewScoringRubricRepository() *ScoringRubricRepository {
+	return &ScoringRubricRepository{}
+}
+
+// FindActive finds the currently active rubric
+func (r *ScoringRubricRepository) FindActive() (*models.ScoringRubric, error) {
+	var rubric models.ScoringRubric
+	err := database.DB.Where("is_active = ?", true).Order("version DESC").First(&rubric).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rubric, nil
+}
+
+// FindAll retrieves all rubric versions, newest first
+func (r *ScoringRubricRepository) FindAll() ([]models.ScoringRubric, error) {
+	var rubrics []models.ScoringRubric
+	err := database.DB.Order("version DESC").Find(&rubrics).Error
+	if err != nil {
+		return nil, err
+	}
+	return rubrics, nil
+}
+
+// CreateActiveVersion stores a rubric as the next version and makes it the only active one
+func (r *ScoringRubricRepository) CreateActiveVersion(rubric *models.ScoringRubric) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&models.ScoringRubric{}).Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.ScoringRubric{}).Where("is_active = ?", true).Update("is_active", false).Error; err != nil {
+			return err
+		}
+
+		rubric.Version = latest + 1
+		rubric.IsActive = true
+		return tx.Create(rubric).Error
+	})
+}
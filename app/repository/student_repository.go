@@ -2,6 +2,7 @@ package repository
 
 import (
 	"fmt"
+	"time"
 
 	"UAS/app/models"
 	"UAS/database"
@@ -89,6 +90,29 @@ func (r *StudentRepository) FindAll() ([]models.Student, error) {
 	return students, nil
 }
 
+// FindPage retrieves one keyset page of students, oldest first, and counts them all. A non-empty
+// advisorID (lecturer ID) limits the page to that lecturer's advisees. more reports whether rows
+// follow in the direction read
+func (r *StudentRepository) FindPage(advisorID string, cursor *models.Cursor, limit int) (students []models.Student, total int64, more bool, err error) {
+	query := database.DB.Model(&models.Student{})
+	if advisorID != "" {
+		query = query.Where("advisor_id = ?", advisorID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, false, err
+	}
+
+	page, err := keysetQuery(query, createdAtColumn, false, cursor, limit)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if err := page.Find(&students).Error; err != nil {
+		return nil, 0, false, err
+	}
+	students, more = keysetRows(students, cursor, limit)
+	return students, total, more, nil
+}
+
 // FindByID finds student by ID
 func (r *StudentRepository) FindByID(id string) (*models.Student, error) {
 	var student models.Student
@@ -98,3 +122,9 @@ func (r *StudentRepository) FindByID(id string) (*models.Student, error) {
 	}
 	return &student, nil
 }
+
+// SetStorageQuota sets the attachment storage quota of a student; nil restores the default
+func (r *StudentRepository) SetStorageQuota(id string, quota *int64) error {
+	return database.DB.Model(&models.Student{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"storage_quota": quota, "updated_at": time.Now()}).Error
+}
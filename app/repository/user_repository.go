@@ -43,6 +43,21 @@ func (r *UserRepository) FindByID(id string) (*models.User, error) {
 	return &user, nil
 }
 
+// UpdateLocale sets the email language of a user
+func (r *UserRepository) UpdateLocale(userID, locale string) error {
+	return database.DB.Model(&models.User{}).Where("id = ?", userID).Update("locale", locale).Error
+}
+
+// FindActiveIDsByRoleName returns the IDs of active users holding the named role
+func (r *UserRepository) FindActiveIDsByRoleName(roleName string) ([]string, error) {
+	var ids []string
+	err := database.DB.Model(&models.User{}).
+		Joins("JOIN roles ON roles.id = users.role_id").
+		Where("roles.name = ? AND users.is_active = ?", roleName, true).
+		Pluck("users.id", &ids).Error
+	return ids, err
+}
+
 // GetUserWithRoleAndPermissions gets user with role and permissions
 func (r *UserRepository) GetUserWithRoleAndPermissions(userID string) (*models.User, []models.Permission, error) {
 	var user models.User
@@ -104,6 +119,25 @@ func (r *UserRepository) FindAll(page, pageSize int) ([]*models.User, int64, err
 	return users, total, nil
 }
 
+// FindPage retrieves one keyset page of active users, oldest first, and counts them all. more
+// reports whether rows follow in the direction read
+func (r *UserRepository) FindPage(cursor *models.Cursor, limit int) (users []*models.User, total int64, more bool, err error) {
+	query := database.DB.Model(&models.User{}).Where("is_active = ?", true)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, false, err
+	}
+
+	page, err := keysetQuery(query, createdAtColumn, false, cursor, limit)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if err := page.Find(&users).Error; err != nil {
+		return nil, 0, false, err
+	}
+	users, more = keysetRows(users, cursor, limit)
+	return users, total, more, nil
+}
+
 // Delete permanently deletes a user record (hard delete)
 func (r *UserRepository) Delete(userID string) error {
 	return database.DB.Unscoped().Delete(&models.User{}, "id = ?", userID).Error
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"UAS/app/models"
+	"UAS/database"
+)
+
+// WebhookRepository handles webhook subscription and delivery operations
+type WebhookRepository struct{}
+
+// NewWebhookRepository creates a new instance of WebhookRepository
+func NewWebhookRepository() *WebhookRepository {
+	return &WebhookRepository{}
+}
+
+// Create creates a webhook subscription
+func (r *WebhookRepository) Create(subscription *models.WebhookSubscription) error {
+	return database.DB.Create(subscription).Error
+}
+
+// Update saves a webhook subscription
+func (r *WebhookRepository) Update(subscription *models.WebhookSubscription) error {
+	return database.DB.Save(subscription).Error
+}
+
+// Delete removes a webhook subscription; its delivery log is kept
+func (r *WebhookRepository) Delete(id string) error {
+	return database.DB.Delete(&models.WebhookSubscription{}, "id = ?", id).Error
+}
+
+// FindByID finds a webhook subscription by ID
+func (r *WebhookRepository) FindByID(id string) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	err := database.DB.Where("id = ?", id).First(&subscription).Error
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// FindAll finds every webhook subscription, oldest first
+func (r *WebhookRepository) FindAll() ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	err := database.DB.Order("created_at ASC").Find(&subscriptions).Error
+	if err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// FindActive finds the active webhook subscriptions
+func (r *WebhookRepository) FindActive() ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	err := database.DB.Where("is_active = ?", true).Find(&subscriptions).Error
+	if err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// CreateDelivery stores a delivery
+func (r *WebhookRepository) CreateDelivery(delivery *models.WebhookDelivery) error {
+	return database.DB.Create(delivery).Error
+}
+
+// UpdateDelivery saves the result of a delivery attempt
+func (r *WebhookRepository) UpdateDelivery(delivery *models.WebhookDelivery) error {
+	return database.DB.Save(delivery).Error
+}
+
+// FindDeliveryByID finds a delivery by ID
+func (r *WebhookRepository) FindDeliveryByID(id string) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := database.DB.Where("id = ?", id).First(&delivery).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// FindDeliveries finds the deliveries of a subscription filtered by status (empty means any), newest first
+func (r *WebhookRepository) FindDeliveries(subscriptionID, status string, offset, limit int) ([]models.WebhookDelivery, int64, error) {
+	var deliveries []models.WebhookDelivery
+	var total int64
+
+	query := database.DB.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+	"UAS/scheduler"
+)
+
+// listingBackfillBatch caps the achievements copied from MongoDB per query
+const listingBackfillBatch = 500
+
+// RegisterListingJobs registers the backfill of the title and type that achievement lists filter
+// and sort on, for achievements created before they were copied to PostgreSQL
+func RegisterListingJobs(s *scheduler.Scheduler) {
+	s.Register(scheduler.Job{
+		Name:        "achievement-listing-backfill",
+		Schedule:    "*/10 * * * *",
+		Description: "Copy achievement titles and types from MongoDB for listing",
+		Run: func(ctx context.Context) (string, error) {
+			synced, err := backfillListing(ctx)
+			return fmt.Sprintf("%d achievements synced", synced), err
+		},
+	})
+}
+
+// BackfillListing copies the title and type of achievements created before they were stored in
+// PostgreSQL. main runs it before serving requests, so filters and title sorting see every
+// achievement right after the migration; the scheduled job catches anything left over
+func BackfillListing(ctx context.Context) (int, error) {
+	return backfillListing(ctx)
+}
+
+// backfillListing copies title and type of every unsynced achievement in batches, one MongoDB
+// lookup per batch. Achievements without a document are marked synced with empty values rather
+// than retried on every run
+func backfillListing(ctx context.Context) (int, error) {
+	pgRepo := repository.NewAchievementRepository()
+	mongoRepo := repository.NewMongoAchievementRepository()
+
+	synced := 0
+	for ctx.Err() == nil {
+		unsynced, err := pgRepo.FindListingUnsynced(listingBackfillBatch)
+		if err != nil || len(unsynced) == 0 {
+			return synced, err
+		}
+		mongoIDs := make([]string, len(unsynced))
+		for i, a := range unsynced {
+			mongoIDs[i] = a.MongoAchievementID
+		}
+		docs, err := mongoRepo.FindByIDs(ctx, mongoIDs)
+		if err != nil {
+			return synced, err
+		}
+		for _, a := range unsynced {
+			doc := docs[a.MongoAchievementID]
+			if err := pgRepo.UpdateListing(nil, a.ID, doc.Title, doc.AchievementType); err != nil {
+				return synced, err
+			}
+			synced++
+		}
+	}
+	return synced, ctx.Err()
+}
+
+// listFilter builds the filter of an achievement list page from its query parameters. Unknown
+// sort keys sort by created_at and anything but "asc" sorts newest first
+func listFilter(status, achievementType, sortBy, sortOrder string, page, pageSize int) models.AchievementListFilter {
+	switch sortBy {
+	case "created_at", "updated_at", "title":
+	default:
+		sortBy = "created_at"
+	}
+	return models.AchievementListFilter{
+		Status:          status,
+		AchievementType: achievementType,
+		SortBy:          sortBy,
+		Descending:      !strings.EqualFold(sortOrder, "asc"),
+		Offset:          (page - 1) * pageSize,
+		Limit:           pageSize,
+	}
+}
+
+// listScope names the sort and filters of an achievement list, e.g.
+// "title:asc?status=verified&type=competition". Cursors are bound to the scope they were issued for,
+// so a cursor reused with another sort or filter is rejected instead of returning a wrong page
+func listScope(filter models.AchievementListFilter) string {
+	scope := filter.SortBy + ":asc"
+	if filter.Descending {
+		scope = filter.SortBy + ":desc"
+	}
+
+	filters := url.Values{}
+	if filter.Status != "" {
+		filters.Set("status", filter.Status)
+	}
+	if filter.AchievementType != "" {
+		filters.Set("type", filter.AchievementType)
+	}
+	if len(filters) > 0 {
+		scope += "?" + filters.Encode()
+	}
+	return scope
+}
+
+// listCursor returns the keyset position of an achievement in a list sorted by sortBy
+func listCursor(achievement models.AchievementReference, sortBy string) *models.Cursor {
+	cursor := &models.Cursor{ID: achievement.ID}
+	switch sortBy {
+	case "updated_at":
+		cursor.Key = models.CursorTime(achievement.UpdatedAt)
+	case "title":
+		cursor.Key = achievement.Title
+	default:
+		cursor.Key = models.CursorTime(achievement.CreatedAt)
+	}
+	return cursor
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"UAS/app/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestListFilter tests building achievement list filters from query parameters
+func TestListFilter(t *testing.T) {
+	testCases := []struct {
+		name           string
+		sortBy         string
+		sortOrder      string
+		page           int
+		pageSize       int
+		expectedSortBy string
+		expectDesc     bool
+		expectedOffset int
+	}{
+		{name: "Defaults", sortBy: "created_at", sortOrder: "desc", page: 1, pageSize: 10, expectedSortBy: "created_at", expectDesc: true, expectedOffset: 0},
+		{name: "Title ascending", sortBy: "title", sortOrder: "asc", page: 2, pageSize: 10, expectedSortBy: "title", expectDesc: false, expectedOffset: 10},
+		{name: "Updated uppercase order", sortBy: "updated_at", sortOrder: "ASC", page: 3, pageSize: 25, expectedSortBy: "updated_at", expectDesc: false, expectedOffset: 50},
+		{name: "Unknown sort key", sortBy: "status; DROP TABLE users", sortOrder: "asc", page: 1, pageSize: 10, expectedSortBy: "created_at", expectDesc: false, expectedOffset: 0},
+		{name: "Unknown order sorts newest first", sortBy: "title", sortOrder: "sideways", page: 1, pageSize: 10, expectedSortBy: "title", expectDesc: true, expectedOffset: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter := listFilter("verified", "competition", tc.sortBy, tc.sortOrder, tc.page, tc.pageSize)
+			assert.Equal(t, tc.expectedSortBy, filter.SortBy)
+			assert.Equal(t, tc.expectDesc, filter.Descending)
+			assert.Equal(t, tc.expectedOffset, filter.Offset)
+			assert.Equal(t, tc.pageSize, filter.Limit)
+			assert.Equal(t, "verified", filter.Status)
+			assert.Equal(t, "competition", filter.AchievementType)
+			assert.Nil(t, filter.StudentIDs)
+		})
+	}
+}
+
+// TestListCursor tests the keyset position of achievements in a list and the scope cursors are bound to
+func TestListCursor(t *testing.T) {
+	created := time.Date(2026, 3, 1, 8, 30, 0, 123000, time.FixedZone("WIB", 7*3600))
+	achievement := models.AchievementReference{ID: "a1", Title: "Lomba Robotik", CreatedAt: created, UpdatedAt: created.Add(time.Hour)}
+
+	assert.Equal(t, "2026-03-01T01:30:00.000123Z", listCursor(achievement, "created_at").Key)
+	assert.Equal(t, "2026-03-01T02:30:00.000123Z", listCursor(achievement, "updated_at").Key)
+	assert.Equal(t, "Lomba Robotik", listCursor(achievement, "title").Key)
+	assert.Equal(t, "a1", listCursor(achievement, "title").ID)
+
+	assert.Equal(t, "title:asc", listScope(listFilter("", "", "title", "asc", 1, 10)))
+	assert.Equal(t, "created_at:desc", listScope(listFilter("", "", "bogus", "", 1, 10)))
+	assert.Equal(t, "title:asc?status=verified&type=competition", listScope(listFilter("verified", "competition", "title", "asc", 1, 10)))
+	assert.NotEqual(t, listScope(listFilter("verified", "", "title", "asc", 1, 10)), listScope(listFilter("draft", "", "title", "asc", 1, 10)))
+}
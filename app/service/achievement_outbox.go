@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+	"UAS/queue"
+	"UAS/scheduler"
+	"UAS/storage"
+)
+
+const (
+	// outboxApplyJob is the queue job type that applies one outbox entry
+	outboxApplyJob = "outbox-apply"
+	// outboxMaxAttempts spans roughly 16 hours of retries with the queue backoff, enough to ride out a MongoDB outage
+	outboxMaxAttempts = 20
+	// outboxWaitDelay is how often an entry waiting for an earlier change to the same achievement
+	// checks again; waiting does not use up its attempts
+	outboxWaitDelay = 30 * time.Second
+	// outboxRetention is how long applied entries are kept for troubleshooting
+	outboxRetention = 7 * 24 * time.Hour
+)
+
+// errOutboxWaiting is returned while an older change to the same achievement is still pending
+var errOutboxWaiting = errors.New("waiting for an earlier change to the same achievement")
+
+// achievementOutbox keeps PostgreSQL and MongoDB consistent. Every achievement mutation stores an
+// outbox entry in the PostgreSQL transaction of the reference change and queues an outbox-apply job
+// in that same transaction. The entry is applied right after commit and, if that fails, by the job
+// with retries. MongoDB operations are idempotent, entries of one achievement are applied in order,
+// and the entry's notifications, webhooks and real-time events are dispatched once it is applied
+type achievementOutbox struct {
+	repo      *repository.OutboxRepository
+	mongoRepo *repository.MongoAchievementRepository
+	notifier  *notifier
+	webhooks  *webhookPublisher
+	realtime  *realtimePublisher
+	blobs     storage.BlobStore
+}
+
+func newAchievementOutbox() *achievementOutbox {
+	return &achievementOutbox{
+		repo:      repository.NewOutboxRepository(),
+		mongoRepo: repository.NewMongoAchievementRepository(),
+		notifier:  newNotifier(),
+		webhooks:  newWebhookPublisher(),
+		realtime:  newRealtimePublisher(),
+		blobs:     storage.Default,
+	}
+}
+
+// newOutboxEntry starts an entry for a change to an achievement
+func newOutboxEntry(achievement *models.AchievementReference, operation string, change models.OutboxChange) *models.OutboxEntry {
+	now := time.Now()
+	if change.At.IsZero() {
+		change.At = now
+	}
+	return &models.OutboxEntry{
+		AchievementID: achievement.ID,
+		StudentID:     achievement.StudentID,
+		MongoID:       achievement.MongoAchievementID,
+		Operation:     operation,
+		Change:        change,
+		Status:        models.OutboxPending,
+		CreatedAt:     now,
+	}
+}
+
+// outboxContent copies the editable fields of a document into an outbox change
+func outboxContent(achievement *models.MongoAchievement) models.OutboxChange {
+	return models.OutboxChange{
+		Title:           achievement.Title,
+		Description:     achievement.Description,
+		AchievementType: achievement.AchievementType,
+		Details:         achievement.Details,
+		Tags:            achievement.Tags,
+		At:              achievement.UpdatedAt,
+	}
+}
+
+// outboxNotification is an in-app/email notification raised by an entry
+func outboxNotification(eventType string, recipients []string, title, message string, data map[string]interface{}) models.OutboxEvent {
+	return models.OutboxEvent{Kind: models.OutboxEventNotification, Type: eventType, Recipients: recipients, Title: title, Message: message, Data: data}
+}
+
+// outboxWebhook is a webhook event raised by an entry
+func outboxWebhook(eventType string, data map[string]interface{}) models.OutboxEvent {
+	return models.OutboxEvent{Kind: models.OutboxEventWebhook, Type: eventType, Data: data}
+}
+
+// outboxRealtime is a real-time event raised by an entry
+func outboxRealtime(eventType string, data map[string]interface{}) models.OutboxEvent {
+	return models.OutboxEvent{Kind: models.OutboxEventRealtime, Type: eventType, Data: data}
+}
+
+// outboxBlobDelete removes a stored file once the entry that stopped referencing it is applied
+func outboxBlobDelete(key string) models.OutboxEvent {
+	return models.OutboxEvent{Kind: models.OutboxEventBlobDelete, Type: key}
+}
+
+// record returns the repository hook that stores the entry and queues its application
+// inside the transaction of the PostgreSQL change
+func (o *achievementOutbox) record(entry *models.OutboxEntry) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		if err := o.repo.Create(tx, entry); err != nil {
+			return err
+		}
+		_, err := queue.Enqueue(outboxApplyJob, outboxApplyPayload{EntryID: entry.ID}, queue.EnqueueOptions{Tx: tx})
+		return err
+	}
+}
+
+// flush applies a committed entry immediately so the request that made the change reads both stores
+// updated. A failure is only logged; the queued job retries the entry
+func (o *achievementOutbox) flush(entry *models.OutboxEntry) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := o.apply(ctx, entry.ID); err != nil {
+		log.Printf("Outbox entry %d for achievement %s not applied yet, will retry: %v", entry.ID, entry.AchievementID, err)
+	}
+}
+
+// apply runs the MongoDB operation of an entry and dispatches its events. Applying an entry twice is
+// safe: the operations are idempotent and only the caller that marks the entry applied dispatches
+func (o *achievementOutbox) apply(ctx context.Context, id int64) error {
+	entry, err := o.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	// A failed entry only runs again when its dead job is retried or cmd/reconcile requeues it
+	if entry.Status == models.OutboxApplied {
+		return nil
+	}
+
+	waiting, err := o.repo.HasPendingBefore(entry.AchievementID, entry.ID)
+	if err != nil {
+		return err
+	}
+	if waiting {
+		return errOutboxWaiting
+	}
+
+	if err := o.applyMongo(ctx, entry); err != nil {
+		if recErr := o.repo.RecordFailure(entry.ID, err.Error()); recErr != nil {
+			log.Printf("Failed to record outbox failure of entry %d: %v", entry.ID, recErr)
+		}
+		return err
+	}
+
+	claimed, err := o.repo.MarkApplied(entry.ID)
+	if err != nil {
+		return err
+	}
+	if claimed {
+		o.dispatch(entry)
+	}
+	return nil
+}
+
+// applyMongo performs the MongoDB side of an entry
+func (o *achievementOutbox) applyMongo(ctx context.Context, entry *models.OutboxEntry) error {
+	switch entry.Operation {
+	case models.OutboxOpNone:
+		return nil
+	case models.OutboxOpCreate:
+		doc, err := outboxDocument(entry)
+		if err != nil {
+			return err
+		}
+		return o.mongoRepo.Insert(ctx, doc)
+	case models.OutboxOpUpdate:
+		doc, err := outboxDocument(entry)
+		if err != nil {
+			return err
+		}
+		return o.mongoRepo.UpdateContent(ctx, entry.MongoID, doc)
+	case models.OutboxOpDelete:
+		return o.mongoRepo.SoftDelete(ctx, entry.MongoID)
+	case models.OutboxOpPoints:
+		return o.mongoRepo.UpdatePoints(ctx, entry.MongoID, entry.Change.Points)
+	case models.OutboxOpAddAttachment:
+		if entry.Change.Attachment == nil {
+			return errors.New("outbox entry has no attachment")
+		}
+		return o.mongoRepo.AddAttachment(ctx, entry.MongoID, *entry.Change.Attachment)
+	case models.OutboxOpRemoveAttachment:
+		if entry.Change.Previous == nil {
+			return errors.New("outbox entry has no attachment")
+		}
+		return o.mongoRepo.RemoveAttachment(ctx, entry.MongoID, *entry.Change.Previous)
+	case models.OutboxOpReplaceAttachment:
+		if entry.Change.Attachment == nil || entry.Change.Previous == nil {
+			return errors.New("outbox entry has no attachment")
+		}
+		return o.mongoRepo.ReplaceAttachment(ctx, entry.MongoID, *entry.Change.Previous, *entry.Change.Attachment)
+	case models.OutboxOpUpdateAttachment:
+		if entry.Change.Attachment == nil {
+			return errors.New("outbox entry has no attachment")
+		}
+		return o.mongoRepo.UpdateAttachmentMeta(ctx, entry.MongoID, *entry.Change.Attachment)
+	case models.OutboxOpReorderAttachments:
+		return o.mongoRepo.ReorderAttachments(ctx, entry.MongoID, entry.Change.AttachmentOrder)
+	}
+	return fmt.Errorf("unknown outbox operation %q", entry.Operation)
+}
+
+// outboxDocument builds the MongoDB document a create or update entry writes
+func outboxDocument(entry *models.OutboxEntry) (*models.MongoAchievement, error) {
+	objID, err := primitive.ObjectIDFromHex(entry.MongoID)
+	if err != nil {
+		return nil, errors.New("invalid achievement id")
+	}
+	change := entry.Change
+	return &models.MongoAchievement{
+		ID:              objID,
+		StudentID:       entry.StudentID,
+		Title:           change.Title,
+		NormalizedTitle: normalizeTitle(change.Title),
+		Description:     change.Description,
+		AchievementType: change.AchievementType,
+		Details:         change.Details,
+		Attachments:     []models.Attachment{},
+		Tags:            change.Tags,
+		CreatedAt:       change.At,
+		UpdatedAt:       change.At,
+	}, nil
+}
+
+// dispatch sends the notifications, webhooks and real-time events of an applied entry
+func (o *achievementOutbox) dispatch(entry *models.OutboxEntry) {
+	for _, event := range entry.Events {
+		switch event.Kind {
+		case models.OutboxEventNotification:
+			o.notifier.notify(event.Type, event.Recipients, event.Title, event.Message, event.Data)
+		case models.OutboxEventWebhook:
+			o.webhooks.publish(event.Type, event.Data)
+		case models.OutboxEventRealtime:
+			o.realtime.publish(event.Type, entry.AchievementID, entry.StudentID, event.Data)
+		case models.OutboxEventBlobDelete:
+			// A file left behind only costs storage, so a failure is logged and not retried
+			if err := o.blobs.Delete(context.Background(), event.Type); err != nil {
+				log.Printf("Failed to delete file %s of achievement %s: %v", event.Type, entry.AchievementID, err)
+			}
+		default:
+			log.Printf("Unknown event kind %q in outbox entry %d", event.Kind, entry.ID)
+		}
+	}
+}
+
+// requeueOutboxEntry puts a failed entry back in the outbox with a fresh set of attempts
+func requeueOutboxEntry(repo *repository.OutboxRepository, id int64) error {
+	return repo.Requeue(id, func(tx *gorm.DB) error {
+		_, err := queue.Enqueue(outboxApplyJob, outboxApplyPayload{EntryID: id}, queue.EnqueueOptions{Tx: tx})
+		return err
+	})
+}
+
+// outboxApplyPayload is the payload of an outbox-apply job
+type outboxApplyPayload struct {
+	EntryID int64 `json:"entry_id"`
+}
+
+// RegisterOutboxJobs registers the outbox applier and the cleanup of applied entries
+func RegisterOutboxJobs(s *scheduler.Scheduler, q *queue.Queue) {
+	outbox := newAchievementOutbox()
+	q.Register(outboxApplyJob, func(ctx context.Context, job *models.QueueJob) error {
+		var payload outboxApplyPayload
+		if err := queue.Decode(job, &payload); err != nil {
+			return err
+		}
+		err := outbox.apply(ctx, payload.EntryID)
+		if errors.Is(err, errOutboxWaiting) {
+			// Nothing is wrong with the entry; its predecessor either gets applied or ends failed
+			return queue.Postpone(outboxWaitDelay, err)
+		}
+		if err != nil && job.Attempts >= job.MaxAttempts {
+			// The job is dead; stop holding back later changes to the achievement
+			if markErr := outbox.repo.MarkFailed(payload.EntryID, err.Error()); markErr != nil {
+				log.Printf("Failed to mark outbox entry %d failed: %v", payload.EntryID, markErr)
+			}
+		}
+		return err
+	}, queue.HandlerOptions{Concurrency: 2, MaxAttempts: outboxMaxAttempts, Timeout: time.Minute})
+
+	s.Register(scheduler.Job{
+		Name:        "outbox-cleanup",
+		Schedule:    "15 3 * * *",
+		Description: "Delete applied achievement outbox entries older than 7 days",
+		Run: func(ctx context.Context) (string, error) {
+			deleted, err := outbox.repo.DeleteAppliedBefore(time.Now().Add(-outboxRetention))
+			return fmt.Sprintf("%d outbox entries deleted", deleted), err
+		},
+	})
+}
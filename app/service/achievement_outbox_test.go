@@ -0,0 +1,68 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"UAS/app/models"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestNewOutboxEntry tests that an entry targets the achievement's documents and starts pending
+func TestNewOutboxEntry(t *testing.T) {
+	achievement := &models.AchievementReference{ID: "ach-1", StudentID: "u1", MongoAchievementID: "64b7f0c2a1b2c3d4e5f60718"}
+
+	entry := newOutboxEntry(achievement, models.OutboxOpPoints, models.OutboxChange{Points: 30})
+
+	assert.Equal(t, "ach-1", entry.AchievementID)
+	assert.Equal(t, "u1", entry.StudentID)
+	assert.Equal(t, "64b7f0c2a1b2c3d4e5f60718", entry.MongoID)
+	assert.Equal(t, models.OutboxPending, entry.Status)
+	assert.Equal(t, 30, entry.Change.Points)
+	assert.False(t, entry.Change.At.IsZero(), "change time defaults to now")
+}
+
+// TestOutboxDocument tests the MongoDB document written by create and update entries
+func TestOutboxDocument(t *testing.T) {
+	at := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	source := &models.MongoAchievement{
+		Title:           "Juara 1: Lomba Programming!",
+		Description:     "Tingkat nasional",
+		AchievementType: "competition",
+		Details:         map[string]interface{}{"competition_level": "national"},
+		Tags:            []string{"coding"},
+		UpdatedAt:       at,
+	}
+	objID := primitive.NewObjectID()
+	entry := &models.OutboxEntry{StudentID: "u1", MongoID: objID.Hex(), Operation: models.OutboxOpCreate, Change: outboxContent(source)}
+
+	doc, err := outboxDocument(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, objID, doc.ID)
+	assert.Equal(t, "u1", doc.StudentID)
+	assert.Equal(t, source.Title, doc.Title)
+	assert.Equal(t, normalizeTitle(source.Title), doc.NormalizedTitle)
+	assert.Equal(t, source.Details, doc.Details)
+	assert.Equal(t, source.Tags, doc.Tags)
+	assert.Equal(t, at, doc.CreatedAt)
+	assert.Equal(t, at, doc.UpdatedAt)
+	assert.NotNil(t, doc.Attachments, "attachments start as an empty list so they can be appended to")
+
+	entry.MongoID = "not-an-object-id"
+	_, err = outboxDocument(entry)
+	assert.Error(t, err)
+}
+
+// TestOutboxEvents tests the event helpers used to build an entry's side effects
+func TestOutboxEvents(t *testing.T) {
+	data := map[string]interface{}{"achievement_id": "ach-1"}
+
+	notification := outboxNotification(models.NotificationAchievementVerified, []string{"u1"}, "Verified", "Done", data)
+	assert.Equal(t, models.OutboxEventNotification, notification.Kind)
+	assert.Equal(t, []string{"u1"}, notification.Recipients)
+
+	assert.Equal(t, models.OutboxEventWebhook, outboxWebhook(models.WebhookAchievementVerified, data).Kind)
+	assert.Equal(t, models.OutboxEventRealtime, outboxRealtime(models.RealtimeAchievementVerified, data).Kind)
+}
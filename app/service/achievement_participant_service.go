@@ -0,0 +1,513 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+	"UAS/utils"
+)
+
+// ParticipantService defines operations on shared (team / co-authored) achievements
+type ParticipantService interface {
+	ListParticipants(c *fiber.Ctx) error
+	AddParticipant(c *fiber.Ctx) error
+	RemoveParticipant(c *fiber.Ctx) error
+	ListInvitations(c *fiber.Ctx) error
+	AcceptInvitation(c *fiber.Ctx) error
+	DeclineInvitation(c *fiber.Ctx) error
+}
+
+type participantServiceImpl struct {
+	pgRepo       *repository.AchievementRepository
+	participants *participantManager
+}
+
+func NewParticipantService() ParticipantService {
+	return &participantServiceImpl{
+		pgRepo:       repository.NewAchievementRepository(),
+		participants: newParticipantManager(),
+	}
+}
+
+// participantManager holds participant rules shared by the achievement and participant services
+type participantManager struct {
+	participantRepo *repository.AchievementParticipantRepository
+	studentRepo     *repository.StudentRepository
+	notifier        *notifier
+}
+
+func newParticipantManager() *participantManager {
+	return &participantManager{
+		participantRepo: repository.NewAchievementParticipantRepository(),
+		studentRepo:     repository.NewStudentRepository(),
+		notifier:        newNotifier(),
+	}
+}
+
+func isValidParticipantRole(role string) bool {
+	switch role {
+	case models.ParticipantRoleLeader, models.ParticipantRoleMember,
+		models.ParticipantRoleFirstAuthor, models.ParticipantRoleCoAuthor:
+		return true
+	}
+	return false
+}
+
+// validateInvites checks invitations before an achievement is created
+func (m *participantManager) validateInvites(reqs []models.ParticipantRequest, ownerUserID string) error {
+	seen := make(map[string]bool)
+	for _, req := range reqs {
+		if req.StudentID == "" {
+			return errors.New("participant student_id (NIM) is required")
+		}
+		if req.Role != "" && !isValidParticipantRole(req.Role) {
+			return fmt.Errorf("invalid participant role: %s", req.Role)
+		}
+		if seen[req.StudentID] {
+			return fmt.Errorf("student %s is listed more than once", req.StudentID)
+		}
+		seen[req.StudentID] = true
+
+		student, err := m.studentRepo.FindByStudentID(req.StudentID)
+		if err != nil {
+			return fmt.Errorf("student not found: %s", req.StudentID)
+		}
+		if student.UserID == ownerUserID {
+			return errors.New("the owner is already a participant")
+		}
+	}
+	return nil
+}
+
+// addOwner registers the achievement owner as an accepted participant
+func (m *participantManager) addOwner(achievementID, ownerUserID, role string) error {
+	if role == "" {
+		role = models.ParticipantRoleLeader
+	}
+	if !isValidParticipantRole(role) {
+		return fmt.Errorf("invalid participant role: %s", role)
+	}
+
+	now := time.Now()
+	return m.participantRepo.Create(&models.AchievementParticipant{
+		ID:            uuid.New().String(),
+		AchievementID: achievementID,
+		StudentID:     ownerUserID,
+		Role:          role,
+		Status:        models.ParticipantAccepted,
+		InvitedBy:     ownerUserID,
+		RespondedAt:   &now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	})
+}
+
+// invite creates an invitation for the student identified by NIM
+func (m *participantManager) invite(achievementID string, req models.ParticipantRequest, invitedBy string) (*models.AchievementParticipant, error) {
+	if req.StudentID == "" {
+		return nil, errors.New("participant student_id (NIM) is required")
+	}
+	if req.Role == "" {
+		req.Role = models.ParticipantRoleMember
+	}
+	if !isValidParticipantRole(req.Role) {
+		return nil, fmt.Errorf("invalid participant role: %s", req.Role)
+	}
+	if req.PointShare != nil && (*req.PointShare <= 0 || *req.PointShare > 1) {
+		return nil, errors.New("point_share must be greater than 0 and at most 1")
+	}
+
+	student, err := m.studentRepo.FindByStudentID(req.StudentID)
+	if err != nil {
+		return nil, fmt.Errorf("student not found: %s", req.StudentID)
+	}
+	if existing, _ := m.participantRepo.FindByAchievementAndStudent(achievementID, student.UserID); existing != nil {
+		return nil, fmt.Errorf("student %s is already a participant", req.StudentID)
+	}
+
+	participant := &models.AchievementParticipant{
+		ID:            uuid.New().String(),
+		AchievementID: achievementID,
+		StudentID:     student.UserID,
+		Role:          req.Role,
+		Status:        models.ParticipantInvited,
+		PointShare:    req.PointShare,
+		InvitedBy:     invitedBy,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if err := m.participantRepo.Create(participant); err != nil {
+		return nil, errors.New("failed to create invitation")
+	}
+
+	m.notifier.notify(models.NotificationParticipantInvited, []string{student.UserID},
+		"New achievement invitation",
+		"You were invited to join a shared achievement as "+req.Role+". Accept or decline it before it is submitted.",
+		map[string]interface{}{"achievement_id": achievementID, "participant_id": participant.ID, "invited_by": invitedBy})
+	return participant, nil
+}
+
+// acceptedCount returns the number of accepted participants, 0 for solo achievements
+func (m *participantManager) acceptedCount(achievementID string) int {
+	participants, err := m.participantRepo.FindByAchievementID(achievementID)
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, p := range participants {
+		if p.Status == models.ParticipantAccepted {
+			count++
+		}
+	}
+	return count
+}
+
+// isParticipant reports whether a user was invited to or joined an achievement
+func (m *participantManager) isParticipant(achievementID, userID string) bool {
+	participant, err := m.participantRepo.FindByAchievementAndStudent(achievementID, userID)
+	return err == nil && participant.Status != models.ParticipantDeclined
+}
+
+// memberIDs returns the user IDs of the owner and every participant who has not declined
+func (m *participantManager) memberIDs(achievementID, ownerUserID string) []string {
+	ids := []string{ownerUserID}
+	participants, err := m.participantRepo.FindByAchievementID(achievementID)
+	if err != nil {
+		return ids
+	}
+	for _, p := range participants {
+		if p.Status != models.ParticipantDeclined {
+			ids = append(ids, p.StudentID)
+		}
+	}
+	return ids
+}
+
+// pointSplit distributes awarded points among accepted participants. The shares are computed now
+// and written by the returned hook, inside the transaction that awards the points
+func (m *participantManager) pointSplit(achievementID string, total int) (func(tx *gorm.DB) error, error) {
+	shares, err := m.pointShares(achievementID, total)
+	if err != nil {
+		return nil, err
+	}
+	return func(tx *gorm.DB) error {
+		return m.participantRepo.UpdatePoints(tx, shares)
+	}, nil
+}
+
+// pointShares computes the points of each accepted participant, keyed by participant ID
+func (m *participantManager) pointShares(achievementID string, total int) (map[string]int, error) {
+	participants, err := m.participantRepo.FindByAchievementID(achievementID)
+	if err != nil {
+		return nil, err
+	}
+
+	var accepted []models.AchievementParticipant
+	for _, p := range participants {
+		if p.Status == models.ParticipantAccepted {
+			accepted = append(accepted, p)
+		}
+	}
+
+	shares := make(map[string]int, len(accepted))
+	if len(accepted) == 0 {
+		return shares, nil
+	}
+	for i, points := range splitPoints(total, accepted) {
+		shares[accepted[i].ID] = points
+	}
+	return shares, nil
+}
+
+// splitPoints divides total points among participants by their point_share weights
+// Participants without a share split the remaining weight equally. Rounding uses the
+// largest remainder method so the parts always add up to total.
+func splitPoints(total int, participants []models.AchievementParticipant) []int {
+	n := len(participants)
+	result := make([]int, n)
+	if n == 0 {
+		return result
+	}
+
+	weights := make([]float64, n)
+	explicit, unspecified := 0.0, 0
+	for i, p := range participants {
+		if p.PointShare != nil && *p.PointShare > 0 {
+			weights[i] = *p.PointShare
+			explicit += *p.PointShare
+		} else {
+			unspecified++
+		}
+	}
+	if unspecified > 0 {
+		remaining := math.Max(0, 1-explicit) / float64(unspecified)
+		if explicit >= 1 {
+			// Explicit shares already claim everything; fall back to equal split
+			for i := range weights {
+				weights[i] = 1
+			}
+		} else {
+			for i, p := range participants {
+				if p.PointShare == nil || *p.PointShare <= 0 {
+					weights[i] = remaining
+				}
+			}
+		}
+	}
+
+	sum := 0.0
+	for _, w := range weights {
+		sum += w
+	}
+
+	type remainder struct {
+		index int
+		frac  float64
+	}
+	remainders := make([]remainder, n)
+	assigned := 0
+	for i, w := range weights {
+		exact := float64(total) * w / sum
+		result[i] = int(math.Floor(exact))
+		assigned += result[i]
+		remainders[i] = remainder{i, exact - float64(result[i])}
+	}
+
+	sort.SliceStable(remainders, func(a, b int) bool { return remainders[a].frac > remainders[b].frac })
+	for i := 0; assigned < total; i++ {
+		result[remainders[i%n].index]++
+		assigned++
+	}
+
+	return result
+}
+
+// FunctionName godoc
+// @Summary List achievement participants
+// @Description Get participants of a shared achievement with their roles, invitation status and point shares
+// @Tags Achievements
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Success 200 {array} models.AchievementParticipant
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /achievements/{id}/participants [get]
+// @Security Bearer
+func (s *participantServiceImpl) ListParticipants(c *fiber.Ctx) error {
+	achievement, err := s.pgRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "achievement not found")
+	}
+
+	userID := c.Locals("userID").(string)
+	if c.Locals("role") == "Mahasiswa" && achievement.StudentID != userID && !s.participants.isParticipant(achievement.ID, userID) {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "you can only view your own achievements")
+	}
+
+	participants, err := s.participants.participantRepo.FindByAchievementID(achievement.ID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve participants")
+	}
+
+	return utils.SuccessResponse(c, "participants retrieved successfully", participants)
+}
+
+// FunctionName godoc
+// @Summary Invite participant
+// @Description Invite a co-participant (teammate or co-author) to a draft achievement. Only the owner can invite.
+// @Tags Achievements
+// @Accept json
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Param body body models.ParticipantRequest true "Participant data"
+// @Success 201 {object} models.AchievementParticipant
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /achievements/{id}/participants [post]
+// @Security Bearer
+func (s *participantServiceImpl) AddParticipant(c *fiber.Ctx) error {
+	var req models.ParticipantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "invalid request body")
+	}
+
+	achievement, err := s.pgRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "achievement not found")
+	}
+
+	userID := c.Locals("userID").(string)
+	if achievement.StudentID != userID {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "only the achievement owner can invite participants")
+	}
+	if achievement.Status != "draft" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "participants can only be changed on draft achievements")
+	}
+
+	// Solo achievements become shared: register the owner first
+	if _, err := s.participants.participantRepo.FindByAchievementAndStudent(achievement.ID, userID); err != nil {
+		if err := s.participants.addOwner(achievement.ID, userID, ""); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to register owner as participant")
+		}
+	}
+
+	participant, err := s.participants.invite(achievement.ID, req, userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return utils.CreatedResponse(c, "participant invited successfully", participant)
+}
+
+// FunctionName godoc
+// @Summary Remove participant
+// @Description Remove a co-participant from a draft achievement. Only the owner can remove participants.
+// @Tags Achievements
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Param participantId path string true "Participant ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /achievements/{id}/participants/{participantId} [delete]
+// @Security Bearer
+func (s *participantServiceImpl) RemoveParticipant(c *fiber.Ctx) error {
+	achievement, err := s.pgRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "achievement not found")
+	}
+
+	if achievement.StudentID != c.Locals("userID").(string) {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "only the achievement owner can remove participants")
+	}
+	if achievement.Status != "draft" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "participants can only be changed on draft achievements")
+	}
+
+	participant, err := s.participants.participantRepo.FindByID(c.Params("participantId"))
+	if err != nil || participant.AchievementID != achievement.ID {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "participant not found")
+	}
+	if participant.StudentID == achievement.StudentID {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "the owner cannot be removed")
+	}
+
+	if err := s.participants.participantRepo.Delete(participant.ID); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to remove participant")
+	}
+
+	return utils.DeletedResponse(c, "participant removed successfully")
+}
+
+// FunctionName godoc
+// @Summary List my invitations
+// @Description Get pending invitations to shared achievements for the logged-in student
+// @Tags Achievements
+// @Produce json
+// @Success 200 {array} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /achievements/invitations [get]
+// @Security Bearer
+func (s *participantServiceImpl) ListInvitations(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	invitations, err := s.participants.participantRepo.FindByStudentAndStatus(userID, models.ParticipantInvited)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve invitations")
+	}
+
+	results := []fiber.Map{}
+	for _, inv := range invitations {
+		achievement, err := s.pgRepo.FindByID(inv.AchievementID)
+		if err != nil {
+			continue
+		}
+		results = append(results, fiber.Map{
+			"participant_id": inv.ID,
+			"achievement_id": inv.AchievementID,
+			"owner_id":       achievement.StudentID,
+			"role":           inv.Role,
+			"point_share":    inv.PointShare,
+			"invited_by":     inv.InvitedBy,
+			"invited_at":     inv.CreatedAt,
+		})
+	}
+
+	return utils.SuccessResponse(c, "invitations retrieved successfully", results)
+}
+
+// FunctionName godoc
+// @Summary Accept invitation
+// @Description Accept an invitation to participate in a shared achievement
+// @Tags Achievements
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Success 200 {object} models.AchievementParticipant
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /achievements/{id}/invitation/accept [post]
+// @Security Bearer
+func (s *participantServiceImpl) AcceptInvitation(c *fiber.Ctx) error {
+	return s.respondToInvitation(c, models.ParticipantAccepted)
+}
+
+// FunctionName godoc
+// @Summary Decline invitation
+// @Description Decline an invitation to participate in a shared achievement
+// @Tags Achievements
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Success 200 {object} models.AchievementParticipant
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /achievements/{id}/invitation/decline [post]
+// @Security Bearer
+func (s *participantServiceImpl) DeclineInvitation(c *fiber.Ctx) error {
+	return s.respondToInvitation(c, models.ParticipantDeclined)
+}
+
+func (s *participantServiceImpl) respondToInvitation(c *fiber.Ctx, status string) error {
+	userID := c.Locals("userID").(string)
+
+	participant, err := s.participants.participantRepo.FindByAchievementAndStudent(c.Params("id"), userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "invitation not found")
+	}
+	if participant.Status != models.ParticipantInvited {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "invitation has already been answered")
+	}
+
+	achievement, err := s.pgRepo.FindByID(participant.AchievementID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "achievement not found")
+	}
+	if achievement.Status != "draft" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "invitations can only be answered while the achievement is a draft")
+	}
+
+	now := time.Now()
+	participant.Status = status
+	participant.RespondedAt = &now
+	participant.UpdatedAt = now
+	if err := s.participants.participantRepo.Update(participant); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to update invitation")
+	}
+
+	s.participants.notifier.notify(models.NotificationInvitationAnswered, []string{achievement.StudentID},
+		"Invitation "+status,
+		"A participant "+status+" the invitation to your shared achievement.",
+		map[string]interface{}{"achievement_id": achievement.ID, "participant_id": participant.ID, "status": status})
+
+	return utils.SuccessResponse(c, "invitation "+status, participant)
+}
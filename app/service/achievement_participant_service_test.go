@@ -0,0 +1,67 @@
+package service
+
+import (
+	"testing"
+
+	"UAS/app/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func share(v float64) *float64 {
+	return &v
+}
+
+// TestSplitPoints tests point splitting between participants
+func TestSplitPoints(t *testing.T) {
+	testCases := []struct {
+		name         string
+		total        int
+		participants []models.AchievementParticipant
+		expected     []int
+	}{
+		{
+			name:         "Equal split with remainder",
+			total:        100,
+			participants: []models.AchievementParticipant{{}, {}, {}},
+			expected:     []int{34, 33, 33},
+		},
+		{
+			name:         "Explicit shares",
+			total:        100,
+			participants: []models.AchievementParticipant{{PointShare: share(0.5)}, {PointShare: share(0.3)}, {PointShare: share(0.2)}},
+			expected:     []int{50, 30, 20},
+		},
+		{
+			name:         "Mixed shares split the remaining weight",
+			total:        90,
+			participants: []models.AchievementParticipant{{PointShare: share(0.6)}, {}, {}},
+			expected:     []int{54, 18, 18},
+		},
+		{
+			name:         "Shares are normalized",
+			total:        10,
+			participants: []models.AchievementParticipant{{PointShare: share(1)}, {PointShare: share(1)}},
+			expected:     []int{5, 5},
+		},
+		{
+			name:         "Single participant gets everything",
+			total:        7,
+			participants: []models.AchievementParticipant{{}},
+			expected:     []int{7},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := splitPoints(tc.total, tc.participants)
+			assert.Equal(t, tc.expected, result)
+
+			sum := 0
+			for _, p := range result {
+				sum += p
+			}
+			assert.Equal(t, tc.total, sum)
+		})
+	}
+}
@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+	"UAS/utils"
+)
+
+// maxBulkReviewItems caps how many achievements one bulk verify or reject request may contain
+const maxBulkReviewItems = 100
+
+// reviewError carries the HTTP status and message of a failed verification or rejection
+type reviewError struct {
+	code    int
+	message string
+}
+
+func (e *reviewError) Error() string {
+	return e.message
+}
+
+func newReviewError(code int, message string) *reviewError {
+	return &reviewError{code: code, message: message}
+}
+
+// authorizeReview checks that the user may review the achievement
+// Admin can review all achievements; Dosen Wali only those of their advisees
+func authorizeReview(studentRepo *repository.StudentRepository, lecturerRepo *repository.LecturerRepository, achievement *models.AchievementReference, userID, role, action string) *reviewError {
+	if role == "Mahasiswa" {
+		return newReviewError(fiber.StatusForbidden, "students cannot "+action+" achievements")
+	}
+
+	if role == "Dosen Wali" {
+		student, err := studentRepo.FindByUserID(achievement.StudentID)
+		if err != nil {
+			return newReviewError(fiber.StatusNotFound, "student not found")
+		}
+
+		lecturer, err := lecturerRepo.FindByUserID(userID)
+		if err != nil {
+			return newReviewError(fiber.StatusNotFound, "lecturer not found")
+		}
+
+		if student.AdvisorID != lecturer.ID {
+			return newReviewError(fiber.StatusForbidden, "only the student's advisor can "+action+" achievements")
+		}
+	}
+	return nil
+}
+
+// advisorUserID returns the user ID of a student's advisor, empty when the student has none
+func advisorUserID(studentRepo *repository.StudentRepository, lecturerRepo *repository.LecturerRepository, studentUserID string) string {
+	student, err := studentRepo.FindByUserID(studentUserID)
+	if err != nil || student.AdvisorID == "" {
+		return ""
+	}
+	lecturer, err := lecturerRepo.FindByID(student.AdvisorID)
+	if err != nil {
+		return ""
+	}
+	return lecturer.UserID
+}
+
+// checkClaim rejects a review by someone other than the reviewer who claimed the achievement
+// Admin may always step in
+func checkClaim(achievement *models.AchievementReference, userID, role string) *reviewError {
+	if achievement.ClaimedBy != "" && achievement.ClaimedBy != userID && role != "Admin" {
+		return newReviewError(fiber.StatusConflict, "achievement is claimed by another reviewer")
+	}
+	return nil
+}
+
+// resolveAwardedPoints applies a verifier's requested points to the rubric suggestion
+// Points default to the suggestion; a different value requires a reason
+func resolveAwardedPoints(suggested int, req models.VerifyAchievementRequest) (int, bool, *reviewError) {
+	if req.Points == nil || *req.Points == suggested {
+		return suggested, false, nil
+	}
+	if *req.Points < 0 {
+		return 0, false, newReviewError(fiber.StatusBadRequest, "points cannot be negative")
+	}
+	if req.OverrideReason == "" {
+		return 0, false, newReviewError(fiber.StatusBadRequest, "override_reason is required when points differ from the suggested points")
+	}
+	return *req.Points, true, nil
+}
+
+// achievementWebhookData builds the fields shared by every achievement webhook event
+func (s *achievementServiceImpl) achievementWebhookData(achievement *models.AchievementReference, title, achievementType string) map[string]interface{} {
+	data := map[string]interface{}{
+		"achievement_id":   achievement.ID,
+		"student_id":       achievement.StudentID,
+		"title":            title,
+		"achievement_type": achievementType,
+		"participants":     s.participants.memberIDs(achievement.ID, achievement.StudentID),
+	}
+	if student, err := s.studentRepo.FindByUserID(achievement.StudentID); err == nil {
+		data["student_nim"] = student.StudentID
+	}
+	return data
+}
+
+// verifyOne verifies a single achievement atomically across PostgreSQL and MongoDB
+func (s *achievementServiceImpl) verifyOne(achievementID, userID, role string, req models.VerifyAchievementRequest) (fiber.Map, *reviewError) {
+	achievement, err := s.pgRepo.FindByID(achievementID)
+	if err != nil {
+		return nil, newReviewError(fiber.StatusNotFound, "achievement not found")
+	}
+
+	if achievement.Status != "submitted" {
+		return nil, newReviewError(fiber.StatusBadRequest, "only submitted achievements can be verified")
+	}
+
+	if rerr := authorizeReview(s.studentRepo, s.lecturerRepo, achievement, userID, role, "verify"); rerr != nil {
+		return nil, rerr
+	}
+	if rerr := checkClaim(achievement, userID, role); rerr != nil {
+		return nil, rerr
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	mongoAch, err := s.mongoRepo.FindByID(ctx, achievement.MongoAchievementID)
+	if err != nil {
+		return nil, newReviewError(fiber.StatusNotFound, "achievement details not found in MongoDB")
+	}
+
+	suggestion, err := s.scorer.score(mongoAch, s.participants.acceptedCount(achievementID))
+	if err != nil {
+		return nil, newReviewError(fiber.StatusInternalServerError, err.Error())
+	}
+	points, overridden, rerr := resolveAwardedPoints(suggestion.Points, req)
+	if rerr != nil {
+		return nil, rerr
+	}
+
+	// One verification covers every participant; split the points between them
+	shares, err := s.participants.pointShares(achievementID, points)
+	if err != nil {
+		return nil, newReviewError(fiber.StatusInternalServerError, "failed to split points between participants")
+	}
+
+	updates := map[string]interface{}{
+		"status":            "verified",
+		"verified_at":       time.Now(),
+		"verified_by":       userID,
+		"points":            points,
+		"suggested_points":  suggestion.Points,
+		"rubric_version":    suggestion.RubricVersion,
+		"points_overridden": overridden,
+		"override_reason":   req.OverrideReason,
+	}
+
+	webhookData := s.achievementWebhookData(achievement, mongoAch.Title, mongoAch.AchievementType)
+	webhookData["points"] = points
+	webhookData["verified_by"] = userID
+	webhookData["verified_at"] = updates["verified_at"]
+
+	// The awarded points reach MongoDB through the outbox, committed together with the review
+	entry := newOutboxEntry(achievement, models.OutboxOpPoints, models.OutboxChange{Points: points})
+	entry.Events = append(entry.Events,
+		outboxNotification(models.NotificationAchievementVerified, s.participants.memberIDs(achievementID, achievement.StudentID),
+			"Achievement verified",
+			fmt.Sprintf("Your achievement %q was verified and awarded %d points.", mongoAch.Title, points),
+			map[string]interface{}{"achievement_id": achievementID, "title": mongoAch.Title, "points": points}),
+		outboxWebhook(models.WebhookAchievementVerified, webhookData),
+		outboxRealtime(models.RealtimeAchievementVerified, map[string]interface{}{
+			"status": "verified", "title": mongoAch.Title, "points": points}),
+	)
+
+	err = s.pgRepo.ApplyReview(achievementID, updates, shares, s.outbox.record(entry))
+	if err != nil {
+		if errors.Is(err, repository.ErrAchievementNotSubmitted) {
+			return nil, newReviewError(fiber.StatusBadRequest, "only submitted achievements can be verified")
+		}
+		return nil, newReviewError(fiber.StatusInternalServerError, "failed to verify achievement")
+	}
+
+	s.outbox.flush(entry)
+
+	return fiber.Map{
+		"id":               achievementID,
+		"status":           "verified",
+		"points":           points,
+		"suggested_points": suggestion.Points,
+		"rubric_version":   suggestion.RubricVersion,
+		"overridden":       overridden,
+	}, nil
+}
+
+// rejectOne rejects a single achievement with a note
+func (s *achievementServiceImpl) rejectOne(achievementID, userID, role, note string) *reviewError {
+	achievement, err := s.pgRepo.FindByID(achievementID)
+	if err != nil {
+		return newReviewError(fiber.StatusNotFound, "achievement not found")
+	}
+
+	if achievement.Status != "submitted" {
+		return newReviewError(fiber.StatusBadRequest, "only submitted achievements can be rejected")
+	}
+
+	if rerr := authorizeReview(s.studentRepo, s.lecturerRepo, achievement, userID, role, "reject"); rerr != nil {
+		return rerr
+	}
+	if rerr := checkClaim(achievement, userID, role); rerr != nil {
+		return rerr
+	}
+
+	if note == "" {
+		return newReviewError(fiber.StatusBadRequest, "rejection_note is required")
+	}
+
+	title, achievementType := "", ""
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if mongoAch, err := s.mongoRepo.FindByID(ctx, achievement.MongoAchievementID); err == nil {
+		title, achievementType = mongoAch.Title, mongoAch.AchievementType
+	}
+
+	now := time.Now()
+	webhookData := s.achievementWebhookData(achievement, title, achievementType)
+	webhookData["rejection_note"] = note
+	webhookData["rejected_by"] = userID
+	webhookData["rejected_at"] = now
+
+	entry := newOutboxEntry(achievement, models.OutboxOpNone, models.OutboxChange{})
+	entry.Events = append(entry.Events,
+		outboxNotification(models.NotificationAchievementRejected, s.participants.memberIDs(achievementID, achievement.StudentID),
+			"Achievement rejected",
+			fmt.Sprintf("Your achievement %q was rejected: %s", title, note),
+			map[string]interface{}{"achievement_id": achievementID, "title": title, "rejection_note": note}),
+		outboxWebhook(models.WebhookAchievementRejected, webhookData),
+		outboxRealtime(models.RealtimeAchievementRejected, map[string]interface{}{
+			"status": "rejected", "title": title, "rejection_note": note}),
+	)
+
+	err = s.pgRepo.ApplyReview(achievementID, map[string]interface{}{
+		"status":         "rejected",
+		"rejection_note": note,
+		"verified_at":    now,
+		"verified_by":    userID,
+	}, nil, s.outbox.record(entry))
+	if err != nil {
+		if errors.Is(err, repository.ErrAchievementNotSubmitted) {
+			return newReviewError(fiber.StatusBadRequest, "only submitted achievements can be rejected")
+		}
+		return newReviewError(fiber.StatusInternalServerError, "failed to reject achievement")
+	}
+	s.outbox.flush(entry)
+	return nil
+}
+
+// validateBulkIDs checks the size of a bulk request and reports IDs that are empty or repeated
+func validateBulkIDs(ids []string) (map[int]string, error) {
+	if len(ids) == 0 {
+		return nil, errors.New("items must not be empty")
+	}
+	if len(ids) > maxBulkReviewItems {
+		return nil, errors.New("too many items in one request")
+	}
+
+	invalid := make(map[int]string)
+	seen := make(map[string]bool, len(ids))
+	for i, id := range ids {
+		switch {
+		case id == "":
+			invalid[i] = "achievement_id is required"
+		case seen[id]:
+			invalid[i] = "achievement listed more than once"
+		default:
+			seen[id] = true
+		}
+	}
+	return invalid, nil
+}
+
+// bulkReviewResponse summarizes per-item results of a bulk review
+func bulkReviewResponse(c *fiber.Ctx, message string, results []models.BulkReviewResult) error {
+	succeeded := 0
+	for _, r := range results {
+		if r.Success {
+			succeeded++
+		}
+	}
+	return utils.SuccessResponse(c, message, fiber.Map{
+		"results":   results,
+		"total":     len(results),
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+	})
+}
+
+// FunctionName godoc
+// @Summary Bulk verify achievements
+// @Description Verify several submitted achievements with per-item points. Authorization and status are checked per item, each item is applied atomically and the response reports the result of every item (Dosen Wali / Admin)
+// @Tags Achievements
+// @Accept json
+// @Produce json
+// @Param body body models.BulkVerifyRequest true "Achievements to verify"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /achievements/bulk/verify [post]
+// @Security Bearer
+func (s *achievementServiceImpl) BulkVerifyAchievements(c *fiber.Ctx) error {
+	var req models.BulkVerifyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "invalid request body")
+	}
+
+	ids := make([]string, len(req.Items))
+	for i, item := range req.Items {
+		ids[i] = item.AchievementID
+	}
+	invalid, err := validateBulkIDs(ids)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	userID := c.Locals("userID").(string)
+	role := c.Locals("role").(string)
+
+	results := make([]models.BulkReviewResult, len(req.Items))
+	for i, item := range req.Items {
+		result := models.BulkReviewResult{AchievementID: item.AchievementID}
+		if msg, ok := invalid[i]; ok {
+			result.Code, result.Error = fiber.StatusBadRequest, msg
+			results[i] = result
+			continue
+		}
+
+		verified, rerr := s.verifyOne(item.AchievementID, userID, role, models.VerifyAchievementRequest{
+			Points:         item.Points,
+			OverrideReason: item.OverrideReason,
+		})
+		if rerr != nil {
+			result.Code, result.Error = rerr.code, rerr.message
+		} else {
+			points := verified["points"].(int)
+			result.Success, result.Code, result.Status, result.Points = true, fiber.StatusOK, "verified", &points
+		}
+		results[i] = result
+	}
+
+	return bulkReviewResponse(c, "bulk verification processed", results)
+}
+
+// FunctionName godoc
+// @Summary Bulk reject achievements
+// @Description Reject several submitted achievements with per-item notes. Authorization and status are checked per item and the response reports the result of every item (Dosen Wali / Admin)
+// @Tags Achievements
+// @Accept json
+// @Produce json
+// @Param body body models.BulkRejectRequest true "Achievements to reject"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /achievements/bulk/reject [post]
+// @Security Bearer
+func (s *achievementServiceImpl) BulkRejectAchievements(c *fiber.Ctx) error {
+	var req models.BulkRejectRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "invalid request body")
+	}
+
+	ids := make([]string, len(req.Items))
+	for i, item := range req.Items {
+		ids[i] = item.AchievementID
+	}
+	invalid, err := validateBulkIDs(ids)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	userID := c.Locals("userID").(string)
+	role := c.Locals("role").(string)
+
+	results := make([]models.BulkReviewResult, len(req.Items))
+	for i, item := range req.Items {
+		result := models.BulkReviewResult{AchievementID: item.AchievementID}
+		if msg, ok := invalid[i]; ok {
+			result.Code, result.Error = fiber.StatusBadRequest, msg
+			results[i] = result
+			continue
+		}
+
+		if rerr := s.rejectOne(item.AchievementID, userID, role, item.RejectionNote); rerr != nil {
+			result.Code, result.Error = rerr.code, rerr.message
+		} else {
+			result.Success, result.Code, result.Status = true, fiber.StatusOK, "rejected"
+		}
+		results[i] = result
+	}
+
+	return bulkReviewResponse(c, "bulk rejection processed", results)
+}
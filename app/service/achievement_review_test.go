@@ -0,0 +1,55 @@
+package service
+
+import (
+	"testing"
+
+	"UAS/app/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestResolveAwardedPoints tests how verifier points are reconciled with the rubric suggestion
+func TestResolveAwardedPoints(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+
+	testCases := []struct {
+		name               string
+		req                models.VerifyAchievementRequest
+		expectedPoints     int
+		expectedOverridden bool
+		expectedError      bool
+	}{
+		{name: "Default to suggestion", req: models.VerifyAchievementRequest{}, expectedPoints: 40},
+		{name: "Same as suggestion", req: models.VerifyAchievementRequest{Points: intPtr(40)}, expectedPoints: 40},
+		{name: "Override with reason", req: models.VerifyAchievementRequest{Points: intPtr(55), OverrideReason: "international jury"}, expectedPoints: 55, expectedOverridden: true},
+		{name: "Override without reason", req: models.VerifyAchievementRequest{Points: intPtr(55)}, expectedError: true},
+		{name: "Negative points", req: models.VerifyAchievementRequest{Points: intPtr(-1), OverrideReason: "typo"}, expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			points, overridden, rerr := resolveAwardedPoints(40, tc.req)
+			if tc.expectedError {
+				assert.NotNil(t, rerr)
+				assert.Equal(t, 400, rerr.code)
+				return
+			}
+			assert.Nil(t, rerr)
+			assert.Equal(t, tc.expectedPoints, points)
+			assert.Equal(t, tc.expectedOverridden, overridden)
+		})
+	}
+}
+
+// TestValidateBulkIDs tests bulk request validation
+func TestValidateBulkIDs(t *testing.T) {
+	_, err := validateBulkIDs(nil)
+	assert.Error(t, err)
+
+	_, err = validateBulkIDs(make([]string, maxBulkReviewItems+1))
+	assert.Error(t, err)
+
+	invalid, err := validateBulkIDs([]string{"a", "", "b", "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]string{1: "achievement_id is required", 3: "achievement listed more than once"}, invalid)
+}
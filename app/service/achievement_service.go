@@ -3,12 +3,12 @@ package service
 import (
 	"context"
 	"fmt"
-	"os"
-	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
 
 	"UAS/app/models"
 	"UAS/app/repository"
@@ -29,6 +29,11 @@ type AchievementService interface {
 	VerifyAchievement(c *fiber.Ctx) error
 	RejectAchievement(c *fiber.Ctx) error
 	UploadAttachment(c *fiber.Ctx) error
+	GetPointsSuggestion(c *fiber.Ctx) error
+	ListDuplicateFlags(c *fiber.Ctx) error
+	DismissDuplicateFlag(c *fiber.Ctx) error
+	BulkVerifyAchievements(c *fiber.Ctx) error
+	BulkRejectAchievements(c *fiber.Ctx) error
 }
 
 type achievementServiceImpl struct {
@@ -37,6 +42,12 @@ type achievementServiceImpl struct {
 	studentRepo  *repository.StudentRepository
 	userRepo     *repository.UserRepository
 	lecturerRepo *repository.LecturerRepository
+	typeRepo     *repository.AchievementTypeRepository
+	scorer       *pointsScorer
+	participants *participantManager
+	duplicates   *duplicateDetector
+	outbox       *achievementOutbox
+	uploader     *attachmentUploader
 }
 
 func NewAchievementService() AchievementService {
@@ -46,6 +57,12 @@ func NewAchievementService() AchievementService {
 		studentRepo:  repository.NewStudentRepository(),
 		userRepo:     repository.NewUserRepository(),
 		lecturerRepo: repository.NewLecturerRepository(),
+		typeRepo:     repository.NewAchievementTypeRepository(),
+		scorer:       newPointsScorer(),
+		participants: newParticipantManager(),
+		duplicates:   newDuplicateDetector(),
+		outbox:       newAchievementOutbox(),
+		uploader:     newAttachmentUploader(),
 	}
 }
 
@@ -75,36 +92,78 @@ func (s *achievementServiceImpl) CreateAchievement(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusForbidden, "only mahasiswa can create achievements")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	if err := s.validateTypeAndDetails(req.AchievementType, req.Details); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	validUntil, err := validUntilFromDetails(req.Details)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if len(req.Participants) > 0 {
+		if req.OwnerRole != "" && !isValidParticipantRole(req.OwnerRole) {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "invalid owner_role: "+req.OwnerRole)
+		}
+		if err := s.participants.validateInvites(req.Participants, c.Locals("userID").(string)); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		}
+	}
 
-	mongoAch, err := s.mongoRepo.Create(ctx, &models.MongoAchievement{
+	// The MongoDB document is written from the outbox, so its ID is chosen up front
+	now := time.Now()
+	mongoAch := &models.MongoAchievement{
+		ID:              primitive.NewObjectID(),
 		StudentID:       c.Locals("userID").(string),
 		Title:           req.Title,
+		NormalizedTitle: normalizeTitle(req.Title),
 		Description:     req.Description,
 		AchievementType: req.AchievementType,
 		Details:         req.Details,
 		Tags:            req.Tags,
 		Points:          0,
-		CreatedAt:       time.Now(),
-		UpdatedAt:       time.Now(),
-	})
-	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to save achievement")
+		CreatedAt:       now,
+		UpdatedAt:       now,
 	}
 
 	pgAch := &models.AchievementReference{
 		ID:                 uuid.New().String(),
 		StudentID:          c.Locals("userID").(string),
 		MongoAchievementID: mongoAch.ID.Hex(),
+		Title:              req.Title,
+		AchievementType:    req.AchievementType,
+		ListingSynced:      true,
 		Status:             "draft",
-		CreatedAt:          time.Now(),
-		UpdatedAt:          time.Now(),
+		ValidUntil:         validUntil,
+		ValidityChecked:    true,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+
+	// Suggested points are informational until a verifier awards them
+	if result, err := s.scorer.score(mongoAch, len(req.Participants)+1); err == nil {
+		pgAch.SuggestedPoints = result.Points
+		pgAch.RubricVersion = result.RubricVersion
 	}
 
-	if err := s.pgRepo.Create(pgAch); err != nil {
-		s.mongoRepo.SoftDelete(ctx, mongoAch.ID.Hex())
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to save achievement reference")
+	entry := newOutboxEntry(pgAch, models.OutboxOpCreate, outboxContent(mongoAch))
+	entry.Events = append(entry.Events,
+		outboxRealtime(models.RealtimeAchievementCreated, map[string]interface{}{"status": pgAch.Status, "title": req.Title}))
+	if err := s.pgRepo.Create(pgAch, s.outbox.record(entry)); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to save achievement")
+	}
+	defer s.outbox.flush(entry)
+
+	// Shared achievement: owner joins automatically, co-participants get invitations
+	if len(req.Participants) > 0 {
+		if err := s.participants.addOwner(pgAch.ID, pgAch.StudentID, req.OwnerRole); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to register owner as participant")
+		}
+		for _, p := range req.Participants {
+			if _, err := s.participants.invite(pgAch.ID, p, pgAch.StudentID); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to invite participant: "+err.Error())
+			}
+		}
 	}
 
 	return utils.CreatedResponse(c, "Prestasi berhasil dibuat", pgAch)
@@ -115,7 +174,11 @@ func (s *achievementServiceImpl) CreateAchievement(c *fiber.Ctx) error {
 // @Description Get list of achievements based on user role
 // @Tags Achievements
 // @Produce json
+// @Param cursor query string false "Cursor from the next/prev link of the previous page"
+// @Param limit query int false "Items per page" default(10)
+// @Param page query int false "Page number (offset pagination, deprecated in favour of cursor)"
 // @Success 200 {array} models.AchievementReference
+// @Failure 400 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /achievements [get]
 // @Security Bearer
@@ -138,49 +201,67 @@ func (s *achievementServiceImpl) ListAchievements(c *fiber.Ctx) error {
 		pageSize = 10
 	}
 
-	var achievements []models.AchievementReference
-	var err error
+	filter := listFilter(status, achievementType, sortBy, sortOrder, page, pageSize)
+
+	// A page number keeps the older offset pagination; otherwise pages are read by signed cursor,
+	// which stays consistent while achievements are created and submitted
+	offsetMode := c.Query("page") != ""
+	var params utils.CursorParams
+	if !offsetMode {
+		var err error
+		if params, err = utils.GetCursorParams(c, listScope(filter)); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		}
+		filter.Cursor = params.Cursor
+		filter.Offset = 0
+		filter.Limit = params.Limit
+	}
 
 	switch role {
 	case "Admin":
 		// Admin can see all achievements
-		achievements, err = s.pgRepo.FindAll()
 
 	case "Mahasiswa":
-		// Student can only see their own achievements
-		achievements, err = s.pgRepo.FindByStudentID(userID)
+		// Student can only see their own achievements and shared ones they joined
+		sharedIDs, err := s.participants.participantRepo.FindSharedAchievementIDs(userID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve achievements: "+err.Error())
+		}
+		filter.StudentIDs = []string{userID}
+		filter.IncludeIDs = sharedIDs
 
 	case "Dosen", "Dosen Wali":
 		// Lecturer can only see achievements from their advisees (anak wali)
-		// First, get all students where AdvisorID = current user
-		students, err := s.studentRepo.FindByAdvisorID(userID)
+		lecturer, err := s.lecturerRepo.FindByUserID(userID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, "lecturer profile not found")
+		}
+		students, err := s.studentRepo.FindByAdvisorID(lecturer.ID)
 		if err != nil {
 			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve advisees")
 		}
-
-		// Then get achievements for all advisees
-		achievements = []models.AchievementReference{}
-		for _, student := range students {
-			studentAchievements, err := s.pgRepo.FindByStudentID(student.UserID)
-			if err != nil {
-				continue // Skip if error
-			}
-			achievements = append(achievements, studentAchievements...)
+		filter.StudentIDs = make([]string, len(students))
+		for i, student := range students {
+			filter.StudentIDs[i] = student.UserID
 		}
 
 	default:
 		return utils.ErrorResponse(c, fiber.StatusForbidden, "insufficient permissions to view achievements")
 	}
 
+	// Filtering, sorting and paging happen in PostgreSQL on the title and type copied from MongoDB
+	achievements, total, more, err := s.pgRepo.FindPage(filter)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve achievements: "+err.Error())
 	}
 
-	// Debug: log jumlah achievements
-	// fmt.Printf("DEBUG - Role: %s, Total achievements found: %d\n", role, len(achievements))
-
 	// If no achievements found, return empty response with message
-	if len(achievements) == 0 {
+	if total == 0 && !offsetMode {
+		pagination := utils.CursorPagination(c, params, nil, nil, false)
+		pagination["total"] = 0
+		return utils.CursorResponse(c, "no achievements found for user (role: "+role+")", []fiber.Map{}, pagination)
+	}
+	if total == 0 {
 		return c.Status(fiber.StatusOK).JSON(fiber.Map{
 			"status":  true,
 			"message": "no achievements found for user (role: " + role + ")",
@@ -194,86 +275,24 @@ func (s *achievementServiceImpl) ListAchievements(c *fiber.Ctx) error {
 		})
 	}
 
-	// Apply filters
-	var filteredAchievements []models.AchievementReference
-	for _, ach := range achievements {
-		// Filter by status
-		if status != "" && ach.Status != status {
-			continue
-		}
-		// Filter by achievement type (need to fetch from MongoDB)
-		if achievementType != "" {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			mongoAch, err := s.mongoRepo.FindByID(ctx, ach.MongoAchievementID)
-			cancel()
-			if err != nil || mongoAch.AchievementType != achievementType {
-				continue
-			}
-		}
-		filteredAchievements = append(filteredAchievements, ach)
-	}
-
-	// Apply sorting
-	for i := 0; i < len(filteredAchievements)-1; i++ {
-		for j := 0; j < len(filteredAchievements)-1-i; j++ {
-			shouldSwap := false
-			if sortOrder == "asc" {
-				if sortBy == "title" {
-					shouldSwap = filteredAchievements[j].Status > filteredAchievements[j+1].Status
-				} else if sortBy == "updated_at" {
-					shouldSwap = filteredAchievements[j].UpdatedAt.After(filteredAchievements[j+1].UpdatedAt)
-				} else { // default: created_at
-					shouldSwap = filteredAchievements[j].CreatedAt.After(filteredAchievements[j+1].CreatedAt)
-				}
-			} else { // desc
-				if sortBy == "title" {
-					shouldSwap = filteredAchievements[j].Status < filteredAchievements[j+1].Status
-				} else if sortBy == "updated_at" {
-					shouldSwap = filteredAchievements[j].UpdatedAt.Before(filteredAchievements[j+1].UpdatedAt)
-				} else { // default: created_at
-					shouldSwap = filteredAchievements[j].CreatedAt.Before(filteredAchievements[j+1].CreatedAt)
-				}
-			}
-			if shouldSwap {
-				filteredAchievements[j], filteredAchievements[j+1] = filteredAchievements[j+1], filteredAchievements[j]
-			}
-		}
-	}
-
-	// Apply pagination
-	totalRecords := len(filteredAchievements)
-	totalPages := (totalRecords + pageSize - 1) / pageSize
-	startIdx := (page - 1) * pageSize
-	endIdx := startIdx + pageSize
-
-	if startIdx >= totalRecords {
-		startIdx = totalRecords
-	}
-	if endIdx > totalRecords {
-		endIdx = totalRecords
-	}
-
-	paginatedAchievements := filteredAchievements[startIdx:endIdx]
-
-	// Fetch MongoDB details for each achievement
+	// Fetch MongoDB details of the page in one lookup
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	response := fiber.Map{
-		"data": []fiber.Map{},
-		"pagination": fiber.Map{
-			"page":        page,
-			"page_size":   pageSize,
-			"total":       totalRecords,
-			"total_pages": totalPages,
-		},
+	mongoIDs := make([]string, len(achievements))
+	for i, ach := range achievements {
+		mongoIDs[i] = ach.MongoAchievementID
+	}
+	details, err := s.mongoRepo.FindByIDs(ctx, mongoIDs)
+	if err != nil {
+		details = nil // Details are optional, the references are still listed
 	}
 
-	responseData := make([]fiber.Map, len(paginatedAchievements))
-	for i, ach := range paginatedAchievements {
-		mongoAch, err := s.mongoRepo.FindByID(ctx, ach.MongoAchievementID)
-		if err != nil {
-			mongoAch = nil // If not found, just continue
+	responseData := make([]fiber.Map, len(achievements))
+	for i, ach := range achievements {
+		var mongoAch *models.MongoAchievement
+		if doc, ok := details[ach.MongoAchievementID]; ok {
+			mongoAch = &doc
 		}
 
 		responseData[i] = fiber.Map{
@@ -287,9 +306,25 @@ func (s *achievementServiceImpl) ListAchievements(c *fiber.Ctx) error {
 			"mongodb_details": mongoAch,
 		}
 	}
-	response["data"] = responseData
 
-	return c.Status(fiber.StatusOK).JSON(response)
+	if !offsetMode {
+		first, last := utils.PageBounds(achievements, func(a models.AchievementReference) *models.Cursor {
+			return listCursor(a, filter.SortBy)
+		})
+		pagination := utils.CursorPagination(c, params, first, last, more)
+		pagination["total"] = total
+		return utils.CursorResponse(c, "achievements retrieved successfully", responseData, pagination)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": responseData,
+		"pagination": fiber.Map{
+			"page":        page,
+			"page_size":   pageSize,
+			"total":       total,
+			"total_pages": (int(total) + pageSize - 1) / pageSize,
+		},
+	})
 }
 
 // FunctionName godoc
@@ -309,35 +344,48 @@ func (s *achievementServiceImpl) GetAchievementDetail(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "achievement not found")
 	}
 
-	role := c.Locals("role").(string)
-	userID := c.Locals("userID").(string)
+	if verr := authorizeView(s.studentRepo, s.lecturerRepo, s.participants, achievement, c.Locals("userID").(string), c.Locals("role").(string)); verr != nil {
+		return utils.ErrorResponse(c, verr.code, verr.message)
+	}
+
+	// Attachments come with signed URLs so the review UI can show previews inline
+	if doc, err := s.mongoRepo.FindByID(c.UserContext(), achievement.MongoAchievementID); err == nil {
+		now := time.Now()
+		achievement.Attachments = make([]map[string]interface{}, 0, len(doc.Attachments))
+		for i := range doc.Attachments {
+			achievement.Attachments = append(achievement.Attachments, attachmentView(achievement.ID, &doc.Attachments[i], now))
+		}
+	}
 
-	// Mahasiswa can only view their own achievements
-	if role == "Mahasiswa" && achievement.StudentID != userID {
-		return utils.ErrorResponse(c, fiber.StatusForbidden, "you can only view your own achievements")
+	return utils.SuccessResponse(c, "achievement detail retrieved", achievement)
+}
+
+// authorizeView checks that the user may view the achievement and its attachments
+// Mahasiswa see their own and shared achievements, Dosen Wali those of their advisees, Admin all
+func authorizeView(studentRepo *repository.StudentRepository, lecturerRepo *repository.LecturerRepository, participants *participantManager, achievement *models.AchievementReference, userID, role string) *reviewError {
+	// Mahasiswa can only view their own achievements or shared ones they participate in
+	if role == "Mahasiswa" && achievement.StudentID != userID && !participants.isParticipant(achievement.ID, userID) {
+		return newReviewError(fiber.StatusForbidden, "you can only view your own achievements")
 	}
 
 	// Dosen Wali can only view achievements of their advisees
 	if role == "Dosen Wali" {
-		// Get student info
-		student, err := s.studentRepo.FindByUserID(achievement.StudentID)
+		student, err := studentRepo.FindByUserID(achievement.StudentID)
 		if err != nil {
-			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to get student info")
+			return newReviewError(fiber.StatusInternalServerError, "failed to get student info")
 		}
 
-		// Get lecturer info
-		lecturer, err := s.lecturerRepo.FindByUserID(userID)
+		lecturer, err := lecturerRepo.FindByUserID(userID)
 		if err != nil {
-			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "lecturer profile not found")
+			return newReviewError(fiber.StatusInternalServerError, "lecturer profile not found")
 		}
 
 		// Check if this lecturer is the advisor
 		if student.AdvisorID != lecturer.ID {
-			return utils.ErrorResponse(c, fiber.StatusForbidden, "you can only view achievements of your advisees")
+			return newReviewError(fiber.StatusForbidden, "you can only view achievements of your advisees")
 		}
 	}
-
-	return utils.SuccessResponse(c, "achievement detail retrieved", achievement)
+	return nil
 }
 
 // FunctionName godoc
@@ -373,25 +421,63 @@ func (s *achievementServiceImpl) UpdateAchievement(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "only draft achievements can be updated")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// The document is replaced, so an omitted type keeps the current one and the details are
+	// still checked against its schema
+	if req.AchievementType == "" {
+		req.AchievementType = s.currentType(achievement)
+		if req.AchievementType == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "achievement_type is required")
+		}
+	}
+	if err := s.validateTypeAndDetails(req.AchievementType, req.Details); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
 
-	if _, err := s.mongoRepo.Update(ctx, achievement.MongoAchievementID, &models.MongoAchievement{
-		StudentID:       c.Locals("userID").(string),
+	validUntil, err := validUntilFromDetails(req.Details)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	mongoAch := &models.MongoAchievement{
+		StudentID:       achievement.StudentID,
 		Title:           req.Title,
+		NormalizedTitle: normalizeTitle(req.Title),
 		Description:     req.Description,
 		AchievementType: req.AchievementType,
 		Details:         req.Details,
 		Tags:            req.Tags,
-		Points:          0,
-	}); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to update achievement")
+		UpdatedAt:       time.Now(),
 	}
 
-	achievement.UpdatedAt = time.Now()
-	if err := s.pgRepo.Update(c.Params("id"), achievement); err != nil {
+	achievement.UpdatedAt = mongoAch.UpdatedAt
+	entry := newOutboxEntry(achievement, models.OutboxOpUpdate, outboxContent(mongoAch))
+	entry.Events = append(entry.Events,
+		outboxRealtime(models.RealtimeAchievementUpdated, map[string]interface{}{"status": achievement.Status, "title": req.Title}))
+	// The document is replaced, so the listing copy follows it even when fields are cleared
+	achievement.Title = req.Title
+	achievement.AchievementType = req.AchievementType
+	record := s.outbox.record(entry)
+	err = s.pgRepo.Update(c.Params("id"), achievement, func(tx *gorm.DB) error {
+		if err := s.pgRepo.UpdateListing(tx, achievement.ID, req.Title, req.AchievementType); err != nil {
+			return err
+		}
+		return record(tx)
+	})
+	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to update achievement")
 	}
+	s.outbox.flush(entry)
+
+	if err := s.pgRepo.UpdateValidity(achievement.ID, validUntil); err == nil {
+		achievement.ValidUntil = validUntil
+		achievement.ValidityStatus = models.CertificationValidity(validUntil, time.Now(), models.CertificationWarningDays)
+	}
+
+	if result, err := s.scorer.score(mongoAch, s.participants.acceptedCount(achievement.ID)); err == nil {
+		achievement.SuggestedPoints = result.Points
+		achievement.RubricVersion = result.RubricVersion
+		s.pgRepo.UpdateScore(achievement.ID, 0, result.Points, result.RubricVersion, false, "", nil)
+	}
 
 	return utils.SuccessResponse(c, "Prestasi berhasil diperbarui", achievement)
 }
@@ -422,16 +508,21 @@ func (s *achievementServiceImpl) DeleteAchievement(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "only draft achievements can be deleted")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if err := s.mongoRepo.SoftDelete(ctx, achievement.MongoAchievementID); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to delete achievement")
-	}
-
-	if err := s.pgRepo.Delete(c.Params("id")); err != nil {
+	entry := newOutboxEntry(achievement, models.OutboxOpDelete, models.OutboxChange{})
+	entry.Events = append(entry.Events,
+		outboxRealtime(models.RealtimeAchievementDeleted, map[string]interface{}{"status": "deleted"}))
+	// Attachment files stop counting against the student's quota
+	record := s.outbox.record(entry)
+	err = s.pgRepo.Delete(c.Params("id"), func(tx *gorm.DB) error {
+		if err := s.uploader.store.releaseAchievement(tx, achievement.ID); err != nil {
+			return err
+		}
+		return record(tx)
+	})
+	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to delete achievement")
 	}
+	s.outbox.flush(entry)
 
 	return utils.DeletedResponse(c, "Prestasi berhasil dihapus")
 }
@@ -462,11 +553,62 @@ func (s *achievementServiceImpl) SubmitAchievement(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "only draft achievements can be submitted")
 	}
 
-	if err := s.pgRepo.UpdateStatus(c.Params("id"), "submitted"); err != nil {
+	// Shared achievements need every invitation answered first
+	if pending, err := s.participants.participantRepo.CountPending(achievement.ID); err == nil && pending > 0 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "all participants must accept or decline their invitations before submitting")
+	}
+
+	// Duplicate detection only warns; it never blocks a submission. It runs before the status
+	// change so the submission's notifications can report the result
+	warnings := []fiber.Map{}
+	title, achievementType := "", ""
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if mongoAch, err := s.mongoRepo.FindByID(ctx, achievement.MongoAchievementID); err == nil {
+		title, achievementType = mongoAch.Title, mongoAch.AchievementType
+		if flags, err := s.duplicates.detect(ctx, achievement, mongoAch); err == nil {
+			s.pgRepo.UpdateDuplicateFlags(achievement.ID, len(flags))
+			for _, f := range flags {
+				warnings = append(warnings, duplicateFlagResponse(f))
+			}
+		}
+	}
+
+	studentName := "Your advisee"
+	if student, err := s.userRepo.FindByID(achievement.StudentID); err == nil && student.FullName != "" {
+		studentName = student.FullName
+	}
+
+	webhookData := s.achievementWebhookData(achievement, title, achievementType)
+	webhookData["submitted_at"] = time.Now()
+
+	entry := newOutboxEntry(achievement, models.OutboxOpNone, models.OutboxChange{})
+	entry.Events = append(entry.Events,
+		outboxNotification(models.NotificationSubmissionReceived, []string{achievement.StudentID},
+			"Achievement submitted",
+			fmt.Sprintf("%q was submitted for verification.", title),
+			map[string]interface{}{"achievement_id": achievement.ID, "title": title}),
+		outboxNotification(models.NotificationAchievementSubmitted,
+			[]string{advisorUserID(s.studentRepo, s.lecturerRepo, achievement.StudentID)},
+			"Achievement submitted for verification",
+			fmt.Sprintf("%s submitted %q for verification.", studentName, title),
+			map[string]interface{}{"achievement_id": achievement.ID, "title": title, "student_id": achievement.StudentID,
+				"student_name": studentName, "possible_duplicates": len(warnings)}),
+		outboxWebhook(models.WebhookAchievementSubmitted, webhookData),
+		outboxRealtime(models.RealtimeAchievementSubmitted, map[string]interface{}{
+			"status": "submitted", "title": title, "possible_duplicates": len(warnings)}),
+	)
+
+	if err := s.pgRepo.Submit(c.Params("id"), s.outbox.record(entry)); err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to submit achievement")
 	}
+	s.outbox.flush(entry)
 
-	return utils.SuccessResponse(c, "Prestasi berhasil disubmit untuk verifikasi", fiber.Map{"id": c.Params("id"), "status": "submitted"})
+	return utils.SuccessResponse(c, "Prestasi berhasil disubmit untuk verifikasi", fiber.Map{
+		"id":                  c.Params("id"),
+		"status":              "submitted",
+		"possible_duplicates": warnings,
+	})
 }
 
 // FunctionName godoc
@@ -592,22 +734,8 @@ func (s *achievementServiceImpl) buildStatistics(ctx context.Context, achievemen
 		statusCount[ach.Status]++
 	}
 
-	// 2. Achievement type distribution
-	typeCount := map[string]int64{
-		"competition":   0,
-		"publication":   0,
-		"organization":  0,
-		"certification": 0,
-	}
-
-	// 3. Competition level distribution
-	levelCount := map[string]int64{
-		"school":        0,
-		"city":          0,
-		"provincial":    0,
-		"national":      0,
-		"international": 0,
-	}
+	// 2 & 3. Achievement type and competition level distribution (from registry)
+	typeCount, levelCount := s.newTypeCounters()
 
 	// 4. Period distribution (by year)
 	periodCount := make(map[string]int64)
@@ -615,7 +743,18 @@ func (s *achievementServiceImpl) buildStatistics(ctx context.Context, achievemen
 	// 5. Student with most achievements
 	studentAchievementCount := make(map[string]int64)
 
+	// 6. Certification validity
+	validityCount := map[string]int64{
+		models.CertificationValid:        0,
+		models.CertificationExpiringSoon: 0,
+		models.CertificationExpired:      0,
+	}
+
 	for _, ach := range achievements {
+		if ach.ValidityStatus != "" {
+			validityCount[ach.ValidityStatus]++
+		}
+
 		// Get MongoDB details for type and level
 		mongoAch, err := s.mongoRepo.FindByID(ctx, ach.MongoAchievementID)
 		if err == nil && mongoAch != nil {
@@ -627,15 +766,9 @@ func (s *achievementServiceImpl) buildStatistics(ctx context.Context, achievemen
 			yearStr := fmt.Sprintf("%d", year)
 			periodCount[yearStr]++
 
-			// Count competition levels if it's competition type
-			if mongoAch.AchievementType == "competition" {
-				if mongoAch.Details != nil {
-					if level, exists := mongoAch.Details["competition_level"]; exists {
-						if levelStr, ok := level.(string); ok {
-							levelCount[levelStr]++
-						}
-					}
-				}
+			// Count competition levels for any type whose schema records one
+			if level, ok := mongoAch.Details["competition_level"].(string); ok && level != "" {
+				levelCount[level]++
 			}
 		}
 
@@ -675,11 +808,12 @@ func (s *achievementServiceImpl) buildStatistics(ctx context.Context, achievemen
 			"total":             total,
 			"verification_rate": verificationRate,
 		},
-		"by_status":            statusCount,
-		"by_type":              typeCount,
-		"by_competition_level": levelCount,
-		"by_period":            periodCount,
-		"top_students":         topStudents,
+		"by_status":                 statusCount,
+		"by_type":                   typeCount,
+		"by_competition_level":      levelCount,
+		"by_period":                 periodCount,
+		"by_certification_validity": validityCount,
+		"top_students":              topStudents,
 	}
 }
 
@@ -690,165 +824,175 @@ func (s *achievementServiceImpl) buildStatistics(ctx context.Context, achievemen
 // @Accept json
 // @Produce json
 // @Param id path string true "Achievement ID"
+// @Param body body models.VerifyAchievementRequest false "Awarded points (defaults to rubric suggestion) and override reason"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /achievements/{id}/verify [post]
 // @Security Bearer
 func (s *achievementServiceImpl) VerifyAchievement(c *fiber.Ctx) error {
-	achievementID := c.Params("id")
-	userID := c.Locals("userID").(string)
-	role := c.Locals("role").(string)
-
-	// Get achievement first to update it properly
-	achievement, err := s.pgRepo.FindByID(achievementID)
-	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusNotFound, "achievement not found")
-	}
-
-	// Check if status is submitted
-	if achievement.Status != "submitted" {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "only submitted achievements can be verified")
+	var req models.VerifyAchievementRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "invalid request body")
+		}
 	}
 
-	// Only Dosen Wali and Admin can verify
-	if role == "Mahasiswa" {
-		return utils.ErrorResponse(c, fiber.StatusForbidden, "students cannot verify achievements")
+	result, rerr := s.verifyOne(c.Params("id"), c.Locals("userID").(string), c.Locals("role").(string), req)
+	if rerr != nil {
+		return utils.ErrorResponse(c, rerr.code, rerr.message)
 	}
 
-	// If Dosen Wali, verify that they are the advisor of the student
-	if role == "Dosen Wali" {
-		student, err := s.studentRepo.FindByUserID(achievement.StudentID)
-		if err != nil {
-			return utils.ErrorResponse(c, fiber.StatusNotFound, "student not found")
-		}
-
-		// Get lecturer info to get lecturer ID
-		lecturer, err := s.lecturerRepo.FindByUserID(userID)
-		if err != nil {
-			return utils.ErrorResponse(c, fiber.StatusNotFound, "lecturer not found")
-		}
+	return utils.SuccessResponse(c, "achievement verified successfully", result)
+}
 
-		if student.AdvisorID != lecturer.ID {
-			return utils.ErrorResponse(c, fiber.StatusForbidden, "only the student's advisor can verify achievements")
-		}
+// FunctionName godoc
+// @Summary Get suggested points
+// @Description Compute suggested points for an achievement with the active scoring rubric, including a breakdown of the applied rules
+// @Tags Achievements
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Success 200 {object} models.ScoreResult
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /achievements/{id}/points-suggestion [get]
+// @Security Bearer
+func (s *achievementServiceImpl) GetPointsSuggestion(c *fiber.Ctx) error {
+	achievement, err := s.pgRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "achievement not found")
 	}
-	// Admin can verify all achievements (no additional check needed)
-
-	// Parse points from request body - accept both string and int
-	var reqBody map[string]interface{}
-	c.BodyParser(&reqBody) // ignore error, default to 0
 
-	points := 0
-	if p, ok := reqBody["points"]; ok {
-		switch v := p.(type) {
-		case float64:
-			points = int(v)
-		case string:
-			if val, err := strconv.Atoi(v); err == nil {
-				points = val
-			}
-		case int:
-			points = v
-		}
+	if verr := authorizeView(s.studentRepo, s.lecturerRepo, s.participants, achievement, c.Locals("userID").(string), c.Locals("role").(string)); verr != nil {
+		return utils.ErrorResponse(c, verr.code, verr.message)
 	}
 
-	// Update achievement status, verified_at, and verified_by
-	achievement.Status = "verified"
-	achievement.VerifiedAt = time.Now()
-	achievement.VerifiedBy = userID
-	achievement.UpdatedAt = time.Now()
-
-	if err := s.pgRepo.Update(achievementID, achievement); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to verify achievement")
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	// Update points in MongoDB
-	ctx := context.Background()
 	mongoAch, err := s.mongoRepo.FindByID(ctx, achievement.MongoAchievementID)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "achievement details not found in MongoDB")
 	}
 
-	mongoAch.Points = points
-	mongoAch.UpdatedAt = time.Now()
-
-	if _, err := s.mongoRepo.Update(ctx, achievement.MongoAchievementID, mongoAch); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to update achievement points")
+	result, err := s.scorer.score(mongoAch, s.participants.acceptedCount(achievement.ID))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, err.Error())
 	}
 
-	return utils.SuccessResponse(c, "achievement verified successfully", nil)
+	return utils.SuccessResponse(c, "suggested points computed", result)
 }
 
 // FunctionName godoc
-// @Summary Reject achievement
-// @Description Reject an achievement submission with notes (Dosen Wali only)
+// @Summary List potential duplicates
+// @Description List achievements flagged as potential duplicates of this one when it was submitted, with links to the matches
 // @Tags Achievements
-// @Accept json
 // @Produce json
 // @Param id path string true "Achievement ID"
-// @Param body body map[string]interface{} true "Rejection data"
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {array} models.AchievementDuplicateFlag
+// @Failure 403 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
-// @Router /achievements/{id}/reject [post]
+// @Router /achievements/{id}/duplicates [get]
 // @Security Bearer
-func (s *achievementServiceImpl) RejectAchievement(c *fiber.Ctx) error {
-	achievementID := c.Params("id")
-	userID := c.Locals("userID").(string)
-	role := c.Locals("role").(string)
-
-	var req struct {
-		RejectionNote string `json:"rejection_note" validate:"required"`
+func (s *achievementServiceImpl) ListDuplicateFlags(c *fiber.Ctx) error {
+	achievement, err := s.pgRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "achievement not found")
 	}
-	if err := c.BodyParser(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "invalid request body")
+
+	if verr := authorizeView(s.studentRepo, s.lecturerRepo, s.participants, achievement, c.Locals("userID").(string), c.Locals("role").(string)); verr != nil {
+		return utils.ErrorResponse(c, verr.code, verr.message)
 	}
 
-	// Get achievement first to update it properly
-	achievement, err := s.pgRepo.FindByID(achievementID)
+	flags, err := s.duplicates.flagRepo.FindByAchievementID(achievement.ID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusNotFound, "achievement not found")
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve duplicate flags")
 	}
 
-	// Check if status is submitted
-	if achievement.Status != "submitted" {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "only submitted achievements can be rejected")
+	response := make([]fiber.Map, 0, len(flags))
+	for _, f := range flags {
+		response = append(response, duplicateFlagResponse(f))
 	}
+	return utils.SuccessResponse(c, "duplicate flags retrieved", response)
+}
 
-	// Only Dosen Wali and Admin can reject
-	if role == "Mahasiswa" {
-		return utils.ErrorResponse(c, fiber.StatusForbidden, "students cannot reject achievements")
+// FunctionName godoc
+// @Summary Dismiss potential duplicate
+// @Description Mark a potential duplicate flag as reviewed and not a duplicate. Dismissed matches are not flagged again on resubmission (Dosen Wali / Admin)
+// @Tags Achievements
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Param flagId path string true "Duplicate flag ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /achievements/{id}/duplicates/{flagId}/dismiss [post]
+// @Security Bearer
+func (s *achievementServiceImpl) DismissDuplicateFlag(c *fiber.Ctx) error {
+	achievement, err := s.pgRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "achievement not found")
 	}
 
-	// If Dosen Wali, verify that they are the advisor of the student
+	userID := c.Locals("userID").(string)
+	role := c.Locals("role").(string)
+	if role == "Mahasiswa" {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "students cannot dismiss duplicate flags")
+	}
 	if role == "Dosen Wali" {
 		student, err := s.studentRepo.FindByUserID(achievement.StudentID)
 		if err != nil {
 			return utils.ErrorResponse(c, fiber.StatusNotFound, "student not found")
 		}
-
-		// Get lecturer info to get lecturer ID
 		lecturer, err := s.lecturerRepo.FindByUserID(userID)
 		if err != nil {
 			return utils.ErrorResponse(c, fiber.StatusNotFound, "lecturer not found")
 		}
-
 		if student.AdvisorID != lecturer.ID {
-			return utils.ErrorResponse(c, fiber.StatusForbidden, "only the student's advisor can reject achievements")
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "only the student's advisor can review this achievement")
 		}
 	}
-	// Admin can reject all achievements (no additional check needed)
 
-	// Update achievement status, rejection note, verified_at, and verified_by
-	achievement.Status = "rejected"
-	achievement.RejectionNote = req.RejectionNote
-	achievement.VerifiedAt = time.Now()
-	achievement.VerifiedBy = userID
-	achievement.UpdatedAt = time.Now()
+	flag, err := s.duplicates.flagRepo.FindByID(c.Params("flagId"))
+	if err != nil || flag.AchievementID != achievement.ID {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "duplicate flag not found")
+	}
+	if flag.Status != models.DuplicateFlagOpen {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "duplicate flag already dismissed")
+	}
 
-	if err := s.pgRepo.Update(achievementID, achievement); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to reject achievement")
+	if err := s.duplicates.flagRepo.Dismiss(flag.ID, userID); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to dismiss duplicate flag")
+	}
+	if open, err := s.duplicates.flagRepo.CountOpen(achievement.ID); err == nil {
+		s.pgRepo.UpdateDuplicateFlags(achievement.ID, int(open))
+	}
+
+	return utils.SuccessResponse(c, "duplicate flag dismissed", fiber.Map{"id": flag.ID, "status": models.DuplicateFlagDismissed})
+}
+
+// FunctionName godoc
+// @Summary Reject achievement
+// @Description Reject an achievement submission with notes (Dosen Wali only)
+// @Tags Achievements
+// @Accept json
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Param body body map[string]interface{} true "Rejection data"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /achievements/{id}/reject [post]
+// @Security Bearer
+func (s *achievementServiceImpl) RejectAchievement(c *fiber.Ctx) error {
+	var req models.RejectAchievementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "invalid request body")
+	}
+
+	if rerr := s.rejectOne(c.Params("id"), c.Locals("userID").(string), c.Locals("role").(string), req.RejectionNote); rerr != nil {
+		return utils.ErrorResponse(c, rerr.code, rerr.message)
 	}
 
 	return utils.SuccessResponse(c, "achievement rejected successfully", nil)
@@ -856,12 +1000,14 @@ func (s *achievementServiceImpl) RejectAchievement(c *fiber.Ctx) error {
 
 // FunctionName godoc
 // @Summary Upload achievement attachment
-// @Description Upload proof files for an achievement
+// @Description Upload proof files for an achievement, optionally with a caption and kind (certificate, photo, assignment_letter, other)
 // @Tags Achievements
 // @Accept multipart/form-data
 // @Produce json
 // @Param id path string true "Achievement ID"
 // @Param file formData file true "File to upload"
+// @Param caption formData string false "Caption, at most 200 characters"
+// @Param kind formData string false "certificate, photo, assignment_letter or other"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
@@ -876,10 +1022,9 @@ func (s *achievementServiceImpl) UploadAttachment(c *fiber.Ctx) error {
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "file is required")
 	}
-
-	// Validate file size (max 10MB)
-	if file.Size > 10*1024*1024 {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "file size exceeds 10MB limit")
+	caption, kind, err := normalizeAttachmentMeta(c.FormValue("caption"), c.FormValue("kind"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
 
 	// Verify achievement exists
@@ -888,77 +1033,25 @@ func (s *achievementServiceImpl) UploadAttachment(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "achievement not found")
 	}
 
-	// Check if achievement is still in draft status
-	// Can only upload attachments when status is "draft"
-	if achievement.Status != "draft" {
-		return utils.ErrorResponse(c, fiber.StatusForbidden, "attachments can only be uploaded for draft achievements. Current status: "+achievement.Status)
-	}
-
-	// Verify ownership
-	if c.Locals("role") == "Mahasiswa" && achievement.StudentID != c.Locals("userID").(string) {
-		return utils.ErrorResponse(c, fiber.StatusForbidden, "you can only upload attachments to your own achievements")
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Get MongoDB achievement to add attachment
-	mongoAch, err := s.mongoRepo.FindByID(ctx, achievement.MongoAchievementID)
-	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusNotFound, "achievement not found in database")
+	// Attachments can only be uploaded to the caller's own draft achievements
+	if aerr := authorizeAttachmentChange(achievement, c.Locals("userID").(string), c.Locals("role").(string)); aerr != nil {
+		return utils.ErrorResponse(c, aerr.code, aerr.message)
 	}
 
-	// Save file to local storage or cloud storage
-	// For now, we'll store the file in a local directory
-	uploadDir := "uploads/achievements"
-
-	// Create directory if it doesn't exist
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to create upload directory")
+	attachment, uerr := s.uploader.prepareFile(c.UserContext(), achievement, file)
+	if uerr != nil {
+		return utils.ErrorResponse(c, uerr.code, uerr.message)
 	}
+	attachment.Caption = caption
+	attachment.Kind = kind
 
-	filename := uuid.New().String() + "_" + file.Filename
-	filepath := uploadDir + "/" + filename
-
-	// Save the file
-	if err := c.SaveFile(file, filepath); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to save file: "+err.Error())
+	// Record the attachment; the MongoDB document is updated from the outbox
+	if err := s.uploader.attach(c.UserContext(), achievement, attachment, nil, nil); err != nil {
+		aerr := attachError(err)
+		return utils.ErrorResponse(c, aerr.code, aerr.message)
 	}
 
-	// Create attachment object
-	attachment := models.Attachment{
-		FileName:   file.Filename,
-		FileURL:    "/uploads/achievements/" + filename, // URL path for serving file
-		FileType:   file.Header.Get("Content-Type"),
-		UploadedAt: time.Now(),
-	}
-
-	// Add attachment to MongoDB achievement
-	if mongoAch.Attachments == nil {
-		mongoAch.Attachments = []models.Attachment{}
-	}
-	mongoAch.Attachments = append(mongoAch.Attachments, attachment)
-	mongoAch.UpdatedAt = time.Now()
-
-	// Update MongoDB document
-	if _, err := s.mongoRepo.Update(ctx, achievement.MongoAchievementID, mongoAch); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to save attachment record")
-	}
-
-	// Update PostgreSQL timestamp
-	achievement.UpdatedAt = time.Now()
-	if err := s.pgRepo.Update(achievementID, achievement); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to update achievement")
-	}
-
-	return utils.SuccessResponse(c, "file uploaded successfully", fiber.Map{
-		"achievement_id": achievementID,
-		"file_name":      file.Filename,
-		"file_url":       "/uploads/achievements/" + filename,
-		"file_type":      file.Header.Get("Content-Type"),
-		"file_size":      file.Size,
-		"uploaded_at":    time.Now(),
-	})
+	return utils.SuccessResponse(c, "file uploaded successfully", attachmentView(achievementID, attachment.Attachment, time.Now()))
 }
 
 // GetStudentReport godoc
@@ -968,6 +1061,7 @@ func (s *achievementServiceImpl) UploadAttachment(c *fiber.Ctx) error {
 // @Tags Reports
 // @Produce json
 // @Param id path string true "Student User ID (UUID)"
+// @Param exclude_expired query bool false "Leave expired certifications out of total_points (default from EXCLUDE_EXPIRED_CERTIFICATION_POINTS)"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 403 {object} map[string]interface{}
@@ -1011,8 +1105,8 @@ func (s *achievementServiceImpl) GetStudentReport(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "user not found")
 	}
 
-	// Get all achievements for student
-	achievements, err := s.pgRepo.FindByStudentID(studentUserID)
+	// Get all achievements for student, including shared ones they joined
+	achievements, err := s.ownAndSharedAchievements(studentUserID)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve achievements")
 	}
@@ -1021,12 +1115,14 @@ func (s *achievementServiceImpl) GetStudentReport(c *fiber.Ctx) error {
 	defer cancel()
 
 	// Build detailed report
-	report := s.buildStudentReport(ctx, student, user, achievements)
+	excludeExpired := c.QueryBool("exclude_expired", excludeExpiredPointsDefault())
+	report := s.buildStudentReport(ctx, student, user, achievements, excludeExpired)
 	return utils.SuccessResponse(c, "student report retrieved successfully", report)
 }
 
 // buildStudentReport builds comprehensive student achievement report
-func (s *achievementServiceImpl) buildStudentReport(ctx context.Context, student *models.Student, user *models.User, achievements []models.AchievementReference) fiber.Map {
+// Expired certifications are left out of total_points when excludeExpired is set
+func (s *achievementServiceImpl) buildStudentReport(ctx context.Context, student *models.Student, user *models.User, achievements []models.AchievementReference, excludeExpired bool) fiber.Map {
 	// Basic student info
 	report := fiber.Map{
 		"student_id":    student.ID,
@@ -1046,53 +1142,62 @@ func (s *achievementServiceImpl) buildStudentReport(ctx context.Context, student
 		"rejected":  0,
 	}
 
-	// Count by type
-	typeCount := map[string]int64{
-		"competition":   0,
-		"publication":   0,
-		"organization":  0,
-		"certification": 0,
-	}
+	// Count by type and competition level (from registry)
+	typeCount, levelCount := s.newTypeCounters()
 
-	// Competition levels
-	levelCount := map[string]int64{
-		"school":        0,
-		"city":          0,
-		"provincial":    0,
-		"national":      0,
-		"international": 0,
+	// Certification validity and verified point totals
+	validityCount := map[string]int64{
+		models.CertificationValid:        0,
+		models.CertificationExpiringSoon: 0,
+		models.CertificationExpired:      0,
 	}
+	totalPoints := 0
 
 	// Detailed achievements with mongo details
 	var detailedAchievements []fiber.Map
 
 	for _, ach := range achievements {
 		statusCount[ach.Status]++
+		if ach.ValidityStatus != "" {
+			validityCount[ach.ValidityStatus]++
+		}
 
 		mongoAch, err := s.mongoRepo.FindByID(ctx, ach.MongoAchievementID)
 		if err == nil && mongoAch != nil {
 			typeCount[mongoAch.AchievementType]++
 
 			// Count competition levels
-			if mongoAch.AchievementType == "competition" && mongoAch.Details != nil {
-				if level, exists := mongoAch.Details["competition_level"]; exists {
-					if levelStr, ok := level.(string); ok {
-						levelCount[levelStr]++
-					}
-				}
+			if level, ok := mongoAch.Details["competition_level"].(string); ok && level != "" {
+				levelCount[level]++
+			}
+
+			// Shared achievements report the student's own share of the points
+			points := mongoAch.Points
+			participantRole := ""
+			if participant, err := s.participants.participantRepo.FindByAchievementAndStudent(ach.ID, student.UserID); err == nil {
+				points = participant.Points
+				participantRole = participant.Role
+			}
+			if ach.Status == "verified" && !(excludeExpired && ach.ValidityStatus == models.CertificationExpired) {
+				totalPoints += points
 			}
 
 			// Add to detailed list
 			detailedAchievements = append(detailedAchievements, fiber.Map{
-				"achievement_id": ach.ID,
-				"title":          mongoAch.Title,
-				"type":           mongoAch.AchievementType,
-				"description":    mongoAch.Description,
-				"status":         ach.Status,
-				"points":         mongoAch.Points,
-				"details":        mongoAch.Details,
-				"created_at":     mongoAch.CreatedAt,
-				"updated_at":     mongoAch.UpdatedAt,
+				"achievement_id":   ach.ID,
+				"title":            mongoAch.Title,
+				"type":             mongoAch.AchievementType,
+				"description":      mongoAch.Description,
+				"status":           ach.Status,
+				"points":           points,
+				"total_points":     mongoAch.Points,
+				"shared":           ach.StudentID != student.UserID || participantRole != "",
+				"participant_role": participantRole,
+				"details":          mongoAch.Details,
+				"valid_until":      ach.ValidUntil,
+				"validity_status":  ach.ValidityStatus,
+				"created_at":       mongoAch.CreatedAt,
+				"updated_at":       mongoAch.UpdatedAt,
 			})
 		}
 	}
@@ -1112,10 +1217,92 @@ func (s *achievementServiceImpl) buildStudentReport(ctx context.Context, student
 		"draft":             statusCount["draft"],
 		"rejected":          statusCount["rejected"],
 		"verification_rate": verificationRate,
+		"total_points":      totalPoints,
+		"expired_excluded":  excludeExpired,
 	}
+	report["by_certification_validity"] = validityCount
 	report["by_type"] = typeCount
 	report["by_competition_level"] = levelCount
 	report["achievements"] = detailedAchievements
 
 	return report
 }
+
+// validateTypeAndDetails checks that the type exists in the registry, is active,
+// and that details match its schema
+func (s *achievementServiceImpl) validateTypeAndDetails(code string, details map[string]interface{}) error {
+	achievementType, err := s.typeRepo.FindByCode(code)
+	if err != nil || !achievementType.IsActive {
+		return fmt.Errorf("unknown achievement_type: %s", code)
+	}
+	return validateAchievementDetails(achievementType, details)
+}
+
+// currentType returns the stored type of an achievement, reading the document when the reference
+// has no copy of it yet
+func (s *achievementServiceImpl) currentType(achievement *models.AchievementReference) string {
+	if achievement.AchievementType != "" {
+		return achievement.AchievementType
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	doc, err := s.mongoRepo.FindByID(ctx, achievement.MongoAchievementID)
+	if err != nil {
+		return ""
+	}
+	return doc.AchievementType
+}
+
+// newTypeCounters initializes type and competition level counters from the registry
+// so every registered type appears in statistics, even with zero achievements
+func (s *achievementServiceImpl) newTypeCounters() (map[string]int64, map[string]int64) {
+	typeCount := make(map[string]int64)
+	levelCount := make(map[string]int64)
+
+	types, err := s.typeRepo.FindAll()
+	if err != nil {
+		return typeCount, levelCount
+	}
+
+	for _, t := range types {
+		typeCount[t.Code] = 0
+		if field := t.FindField("competition_level"); field != nil {
+			for _, level := range field.AllowedValues {
+				levelCount[level] = 0
+			}
+		}
+	}
+
+	return typeCount, levelCount
+}
+
+// ownAndSharedAchievements returns a student's own achievements plus shared ones they accepted to join
+func (s *achievementServiceImpl) ownAndSharedAchievements(studentUserID string) ([]models.AchievementReference, error) {
+	achievements, err := s.pgRepo.FindByStudentID(studentUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	sharedIDs, err := s.participants.participantRepo.FindSharedAchievementIDs(studentUserID)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := s.pgRepo.FindByIDs(sharedIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(achievements, shared...), nil
+}
+
+// duplicateFlagResponse renders a duplicate flag with a link to the matched achievement
+func duplicateFlagResponse(f models.AchievementDuplicateFlag) fiber.Map {
+	return fiber.Map{
+		"id":                     f.ID,
+		"matched_achievement_id": f.MatchedAchievementID,
+		"link":                   "/api/v1/achievements/" + f.MatchedAchievementID,
+		"score":                  f.Score,
+		"reasons":                f.Reasons,
+		"status":                 f.Status,
+	}
+}
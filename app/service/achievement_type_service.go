@@ -0,0 +1,350 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+	"UAS/utils"
+)
+
+// AchievementTypeService defines admin-configurable achievement type operations
+type AchievementTypeService interface {
+	ListAchievementTypes(c *fiber.Ctx) error
+	GetAchievementType(c *fiber.Ctx) error
+	CreateAchievementType(c *fiber.Ctx) error
+	UpdateAchievementType(c *fiber.Ctx) error
+	DeleteAchievementType(c *fiber.Ctx) error
+}
+
+type achievementTypeServiceImpl struct {
+	typeRepo *repository.AchievementTypeRepository
+}
+
+func NewAchievementTypeService() AchievementTypeService {
+	return &achievementTypeServiceImpl{
+		typeRepo: repository.NewAchievementTypeRepository(),
+	}
+}
+
+var typeCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// FunctionName godoc
+// @Summary List achievement types
+// @Description Get the achievement type registry. Inactive types are only returned to Admin with include_inactive=true.
+// @Tags Achievement Types
+// @Produce json
+// @Param include_inactive query bool false "Include inactive types (Admin only)"
+// @Success 200 {array} models.AchievementType
+// @Failure 500 {object} map[string]interface{}
+// @Router /achievement-types [get]
+// @Security Bearer
+func (s *achievementTypeServiceImpl) ListAchievementTypes(c *fiber.Ctx) error {
+	var types []models.AchievementType
+	var err error
+
+	if c.QueryBool("include_inactive", false) && c.Locals("role") == "Admin" {
+		types, err = s.typeRepo.FindAll()
+	} else {
+		types, err = s.typeRepo.FindActive()
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve achievement types")
+	}
+
+	return utils.SuccessResponse(c, "achievement types retrieved successfully", types)
+}
+
+// FunctionName godoc
+// @Summary Get achievement type
+// @Description Get a single achievement type with its detail schema
+// @Tags Achievement Types
+// @Produce json
+// @Param code path string true "Achievement type code"
+// @Success 200 {object} models.AchievementType
+// @Failure 404 {object} map[string]interface{}
+// @Router /achievement-types/{code} [get]
+// @Security Bearer
+func (s *achievementTypeServiceImpl) GetAchievementType(c *fiber.Ctx) error {
+	achievementType, err := s.typeRepo.FindByCode(c.Params("code"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, "achievement type not found")
+		}
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to find achievement type")
+	}
+
+	return utils.SuccessResponse(c, "achievement type retrieved successfully", achievementType)
+}
+
+// FunctionName godoc
+// @Summary Create achievement type
+// @Description Define a new achievement type with labels, default points and detail schema (Admin only)
+// @Tags Achievement Types
+// @Accept json
+// @Produce json
+// @Param body body models.AchievementTypeRequest true "Achievement type data"
+// @Success 201 {object} models.AchievementType
+// @Failure 400 {object} map[string]interface{}
+// @Router /achievement-types [post]
+// @Security Bearer
+func (s *achievementTypeServiceImpl) CreateAchievementType(c *fiber.Ctx) error {
+	var req models.AchievementTypeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "invalid request body")
+	}
+
+	if !typeCodePattern.MatchString(req.Code) {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "code must be 2-50 lowercase letters, digits or underscores")
+	}
+	if existing, _ := s.typeRepo.FindByCode(req.Code); existing != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "achievement type already exists")
+	}
+	if err := validateFieldSchema(req.Fields); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if err := validateAttachmentTypes(req.AllowedAttachmentTypes); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	achievementType := &models.AchievementType{
+		Code:                   req.Code,
+		Labels:                 req.Labels,
+		Description:            req.Description,
+		Fields:                 req.Fields,
+		AllowedAttachmentTypes: req.AllowedAttachmentTypes,
+		IsActive:               true,
+		CreatedAt:              time.Now(),
+		UpdatedAt:              time.Now(),
+	}
+	if req.DefaultPoints != nil {
+		achievementType.DefaultPoints = *req.DefaultPoints
+	}
+	if req.IsActive != nil {
+		achievementType.IsActive = *req.IsActive
+	}
+
+	if err := s.typeRepo.Create(achievementType); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to create achievement type")
+	}
+
+	return utils.CreatedResponse(c, "achievement type created successfully", achievementType)
+}
+
+// FunctionName godoc
+// @Summary Update achievement type
+// @Description Update labels, default points, detail schema or active flag of an achievement type (Admin only)
+// @Tags Achievement Types
+// @Accept json
+// @Produce json
+// @Param code path string true "Achievement type code"
+// @Param body body models.AchievementTypeRequest true "Achievement type data"
+// @Success 200 {object} models.AchievementType
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /achievement-types/{code} [put]
+// @Security Bearer
+func (s *achievementTypeServiceImpl) UpdateAchievementType(c *fiber.Ctx) error {
+	var req models.AchievementTypeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "invalid request body")
+	}
+
+	achievementType, err := s.typeRepo.FindByCode(c.Params("code"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "achievement type not found")
+	}
+
+	if req.Labels != nil {
+		achievementType.Labels = req.Labels
+	}
+	if req.Description != "" {
+		achievementType.Description = req.Description
+	}
+	if req.DefaultPoints != nil {
+		achievementType.DefaultPoints = *req.DefaultPoints
+	}
+	if req.Fields != nil {
+		if err := validateFieldSchema(req.Fields); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		}
+		achievementType.Fields = req.Fields
+	}
+	if req.AllowedAttachmentTypes != nil {
+		if err := validateAttachmentTypes(req.AllowedAttachmentTypes); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		}
+		achievementType.AllowedAttachmentTypes = req.AllowedAttachmentTypes
+	}
+	if req.IsActive != nil {
+		achievementType.IsActive = *req.IsActive
+	}
+	achievementType.UpdatedAt = time.Now()
+
+	if err := s.typeRepo.Update(achievementType); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to update achievement type")
+	}
+
+	return utils.SuccessResponse(c, "achievement type updated successfully", achievementType)
+}
+
+// FunctionName godoc
+// @Summary Deactivate achievement type
+// @Description Deactivate an achievement type. Existing achievements keep their type; new ones cannot use it (Admin only)
+// @Tags Achievement Types
+// @Produce json
+// @Param code path string true "Achievement type code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /achievement-types/{code} [delete]
+// @Security Bearer
+func (s *achievementTypeServiceImpl) DeleteAchievementType(c *fiber.Ctx) error {
+	achievementType, err := s.typeRepo.FindByCode(c.Params("code"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "achievement type not found")
+	}
+
+	achievementType.IsActive = false
+	achievementType.UpdatedAt = time.Now()
+	if err := s.typeRepo.Update(achievementType); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to deactivate achievement type")
+	}
+
+	return utils.DeletedResponse(c, "achievement type deactivated successfully")
+}
+
+// validateFieldSchema checks that an admin-supplied detail schema is well formed
+func validateFieldSchema(fields []models.AchievementField) error {
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		if f.Name == "" {
+			return errors.New("field name is required")
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("duplicate field name: %s", f.Name)
+		}
+		seen[f.Name] = true
+
+		switch f.Type {
+		case models.FieldTypeString, models.FieldTypeNumber, models.FieldTypeInteger,
+			models.FieldTypeBoolean, models.FieldTypeDate, models.FieldTypeArray:
+		default:
+			return fmt.Errorf("field %s has unsupported type: %s", f.Name, f.Type)
+		}
+
+		if len(f.AllowedValues) > 0 && f.Type != models.FieldTypeString && f.Type != models.FieldTypeArray {
+			return fmt.Errorf("allowed_values is only supported for string and array fields (%s)", f.Name)
+		}
+	}
+	return nil
+}
+
+// validateAttachmentTypes checks that an attachment allowlist only names supported content types
+func validateAttachmentTypes(types []string) error {
+	for _, t := range types {
+		if !isSupportedAttachmentType(t) {
+			return fmt.Errorf("unsupported attachment type %q: use application/pdf, image/jpeg, image/png or image/webp", t)
+		}
+	}
+	return nil
+}
+
+// validateAchievementDetails validates achievement details against the type's schema
+// Keys that are not part of the schema are kept as free-form extra details
+func validateAchievementDetails(achievementType *models.AchievementType, details map[string]interface{}) error {
+	for _, field := range achievementType.Fields {
+		value, exists := details[field.Name]
+		if !exists || value == nil || value == "" {
+			if field.Required {
+				return fmt.Errorf("details.%s is required for %s achievements", field.Name, achievementType.Code)
+			}
+			continue
+		}
+
+		if err := validateFieldValue(field, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateFieldValue checks a single detail value against its field definition
+func validateFieldValue(field models.AchievementField, value interface{}) error {
+	switch field.Type {
+	case models.FieldTypeString:
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("details.%s must be a string", field.Name)
+		}
+		if !isAllowedValue(field.AllowedValues, str) {
+			return fmt.Errorf("details.%s must be one of %v", field.Name, field.AllowedValues)
+		}
+	case models.FieldTypeNumber:
+		if _, ok := toFloat(value); !ok {
+			return fmt.Errorf("details.%s must be a number", field.Name)
+		}
+	case models.FieldTypeInteger:
+		f, ok := toFloat(value)
+		if !ok || f != float64(int64(f)) {
+			return fmt.Errorf("details.%s must be an integer", field.Name)
+		}
+	case models.FieldTypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("details.%s must be a boolean", field.Name)
+		}
+	case models.FieldTypeDate:
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("details.%s must be a date (YYYY-MM-DD)", field.Name)
+		}
+		if _, err := time.Parse("2006-01-02", str); err != nil {
+			return fmt.Errorf("details.%s must be a date (YYYY-MM-DD)", field.Name)
+		}
+	case models.FieldTypeArray:
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("details.%s must be an array", field.Name)
+		}
+		for _, item := range items {
+			str, isString := item.(string)
+			if len(field.AllowedValues) > 0 && (!isString || !isAllowedValue(field.AllowedValues, str)) {
+				return fmt.Errorf("details.%s items must be one of %v", field.Name, field.AllowedValues)
+			}
+		}
+	}
+	return nil
+}
+
+func isAllowedValue(allowed []string, value string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, v := range allowed {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"testing"
+
+	"UAS/app/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func competitionType() *models.AchievementType {
+	return &models.AchievementType{
+		Code: "competition",
+		Fields: []models.AchievementField{
+			{Name: "competition_name", Type: models.FieldTypeString, Required: true},
+			{Name: "competition_level", Type: models.FieldTypeString, Required: true, AllowedValues: []string{"national", "international"}},
+			{Name: "rank", Type: models.FieldTypeInteger},
+			{Name: "event_date", Type: models.FieldTypeDate},
+			{Name: "authors", Type: models.FieldTypeArray},
+		},
+	}
+}
+
+// TestValidateAchievementDetails tests details validation against a type schema
+func TestValidateAchievementDetails(t *testing.T) {
+	testCases := []struct {
+		name        string
+		details     map[string]interface{}
+		expectValid bool
+	}{
+		{
+			name: "Valid details",
+			details: map[string]interface{}{
+				"competition_name":  "Gemastik",
+				"competition_level": "national",
+				"rank":              float64(1),
+				"event_date":        "2024-10-01",
+				"authors":           []interface{}{"A", "B"},
+				"extra_note":        "free-form keys are allowed",
+			},
+			expectValid: true,
+		},
+		{
+			name:        "Missing required field",
+			details:     map[string]interface{}{"competition_level": "national"},
+			expectValid: false,
+		},
+		{
+			name:        "Value not allowed",
+			details:     map[string]interface{}{"competition_name": "Gemastik", "competition_level": "galactic"},
+			expectValid: false,
+		},
+		{
+			name:        "Non integer rank",
+			details:     map[string]interface{}{"competition_name": "Gemastik", "competition_level": "national", "rank": 1.5},
+			expectValid: false,
+		},
+		{
+			name:        "Invalid date",
+			details:     map[string]interface{}{"competition_name": "Gemastik", "competition_level": "national", "event_date": "01/10/2024"},
+			expectValid: false,
+		},
+		{
+			name:        "Nil details with required fields",
+			details:     nil,
+			expectValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateAchievementDetails(competitionType(), tc.details)
+			assert.Equal(t, tc.expectValid, err == nil)
+		})
+	}
+}
+
+// TestValidateFieldSchema tests admin-supplied schema validation
+func TestValidateFieldSchema(t *testing.T) {
+	testCases := []struct {
+		name        string
+		fields      []models.AchievementField
+		expectValid bool
+	}{
+		{
+			name:        "Valid schema",
+			fields:      competitionType().Fields,
+			expectValid: true,
+		},
+		{
+			name:        "Unsupported type",
+			fields:      []models.AchievementField{{Name: "x", Type: "object"}},
+			expectValid: false,
+		},
+		{
+			name:        "Duplicate name",
+			fields:      []models.AchievementField{{Name: "x", Type: "string"}, {Name: "x", Type: "number"}},
+			expectValid: false,
+		},
+		{
+			name:        "Allowed values on number",
+			fields:      []models.AchievementField{{Name: "x", Type: "number", AllowedValues: []string{"1"}}},
+			expectValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateFieldSchema(tc.fields)
+			assert.Equal(t, tc.expectValid, err == nil)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+	"UAS/preview"
+	"UAS/queue"
+	"UAS/storage"
+)
+
+// attachmentPreviewJob is the queue job type that generates the preview of a scanned attachment
+const attachmentPreviewJob = "attachment-preview"
+
+// previewPrefix is the storage key prefix of generated previews
+const previewPrefix = "previews/"
+
+// attachmentPreviewer generates thumbnails of image attachments and first-page previews of PDFs
+type attachmentPreviewer struct {
+	pgRepo    *repository.AchievementRepository
+	mongoRepo *repository.MongoAchievementRepository
+	outbox    *repository.OutboxRepository
+	blobs     storage.BlobStore
+	generator *preview.Generator
+}
+
+func newAttachmentPreviewer() *attachmentPreviewer {
+	return &attachmentPreviewer{
+		pgRepo:    repository.NewAchievementRepository(),
+		mongoRepo: repository.NewMongoAchievementRepository(),
+		outbox:    repository.NewOutboxRepository(),
+		blobs:     storage.Default,
+		generator: preview.Default,
+	}
+}
+
+// RegisterPreviewJobs registers the attachment preview job handler. Previews are only made of files
+// the malware scan reported clean; a file without a preview is still shown as a download
+func RegisterPreviewJobs(q *queue.Queue) {
+	p := newAttachmentPreviewer()
+	q.Register(attachmentPreviewJob, p.handle, queue.HandlerOptions{Concurrency: 2, MaxAttempts: 5, Timeout: 2 * time.Minute})
+}
+
+// enqueueAttachmentPreview queues the preview of an attachment
+func enqueueAttachmentPreview(achievementID, attachmentID string) error {
+	_, err := queue.Enqueue(attachmentPreviewJob, attachmentJobPayload{AchievementID: achievementID, AttachmentID: attachmentID})
+	return err
+}
+
+func (p *attachmentPreviewer) handle(ctx context.Context, job *models.QueueJob) error {
+	var payload attachmentJobPayload
+	if err := queue.Decode(job, &payload); err != nil {
+		return err
+	}
+
+	achievement, err := p.pgRepo.FindByID(payload.AchievementID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil // deleted in the meantime
+	}
+	if err != nil {
+		return err
+	}
+	doc, err := p.mongoRepo.FindByID(ctx, achievement.MongoAchievementID)
+	if err != nil {
+		return err
+	}
+	attachment := findAttachment(doc, payload.AttachmentID)
+	if attachment == nil {
+		return attachmentMissing(p.outbox, payload.AchievementID, payload.AttachmentID)
+	}
+	if attachment.Preview != nil || attachment.ScanStatus == models.ScanInfected {
+		return nil
+	}
+	if attachment.ScanStatus == models.ScanPending {
+		// The job is queued just before the scan verdict is stored
+		return fmt.Errorf("attachment %s is not scanned yet", payload.AttachmentID)
+	}
+
+	r, _, err := p.blobs.Open(ctx, attachment.Key())
+	if err != nil {
+		return err
+	}
+	img, err := p.generator.Generate(ctx, attachment.FileType, r)
+	r.Close()
+	if errors.Is(err, preview.ErrUnsupported) || errors.Is(err, preview.ErrTooLarge) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	key := previewKey(achievement.ID, payload.AttachmentID)
+	if err := p.blobs.Put(ctx, key, bytes.NewReader(img.Data), int64(len(img.Data)), img.ContentType); err != nil {
+		return err
+	}
+	err = p.mongoRepo.SetAttachmentPreview(ctx, achievement.MongoAchievementID, payload.AttachmentID, models.AttachmentPreview{
+		StorageKey:  key,
+		ContentType: img.ContentType,
+		Width:       img.Width,
+		Height:      img.Height,
+		GeneratedAt: time.Now(),
+	})
+	if errors.Is(err, repository.ErrAttachmentNotFound) {
+		// Removed or replaced while the preview was rendered
+		p.blobs.Delete(ctx, key)
+		return attachmentMissing(p.outbox, payload.AchievementID, payload.AttachmentID)
+	}
+	return err
+}
+
+// previewKey returns the storage key of an attachment's preview
+func previewKey(achievementID, attachmentID string) string {
+	return previewPrefix + "achievements/" + achievementID + "/" + attachmentID + ".jpg"
+}
+
+// attachmentBlobKeys lists the stored files only an attachment uses: its preview and, for
+// attachments stored before deduplication, the file itself. Shared files are released instead
+func attachmentBlobKeys(attachment *models.Attachment) []string {
+	var keys []string
+	if !isBlobKey(attachment.Key()) {
+		keys = append(keys, attachment.Key())
+	}
+	if attachment.Preview != nil {
+		keys = append(keys, attachment.Preview.StorageKey)
+	}
+	return keys
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"UAS/app/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAttachmentViewPreview tests that a generated preview is returned with its own signed URL
+func TestAttachmentViewPreview(t *testing.T) {
+	t.Setenv("ATTACHMENT_URL_SECRET", "secret")
+	now := time.Unix(1700000000, 0)
+	attachment := &models.Attachment{
+		ID:         "f1",
+		FileName:   "sertifikat.pdf",
+		ScanStatus: models.ScanClean,
+		Preview:    &models.AttachmentPreview{StorageKey: previewKey("a1", "f1"), ContentType: "image/jpeg", Width: 724, Height: 1024},
+	}
+
+	view := attachmentView("a1", attachment, now)
+	previewView := view["preview"].(fiber.Map)
+	assert.Equal(t, 724, previewView["width"])
+	assert.Equal(t, 1024, previewView["height"])
+
+	u, err := url.Parse(previewView["url"].(string))
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/v1/files/achievements/a1/f1/preview", u.Path)
+	expires, _ := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	assert.NoError(t, verifyAttachmentSignature("secret", "a1", "f1"+previewPath, expires, u.Query().Get("signature"), now))
+	// A preview signature does not open the file itself
+	assert.Error(t, verifyAttachmentSignature("secret", "a1", "f1", expires, u.Query().Get("signature"), now))
+
+	// No preview yet, or the file is held by the malware scan
+	assert.NotContains(t, attachmentView("a1", &models.Attachment{ID: "f1", ScanStatus: models.ScanClean}, now), "preview")
+	attachment.ScanStatus = models.ScanPending
+	assert.NotContains(t, attachmentView("a1", attachment, now), "preview")
+}
+
+// TestAttachmentBlobKeys tests that removing an attachment also removes its preview
+func TestAttachmentBlobKeys(t *testing.T) {
+	attachment := &models.Attachment{ID: "f1", StorageKey: "achievements/a1/f1.png"}
+	assert.Equal(t, []string{"achievements/a1/f1.png"}, attachmentBlobKeys(attachment))
+
+	attachment.Preview = &models.AttachmentPreview{StorageKey: previewKey("a1", "f1")}
+	assert.Equal(t, []string{"achievements/a1/f1.png", "previews/achievements/a1/f1.jpg"}, attachmentBlobKeys(attachment))
+}
+
+// TestPreviewFileName tests the download name of previews
+func TestPreviewFileName(t *testing.T) {
+	assert.Equal(t, "sertifikat-preview.jpg", previewFileName("sertifikat.pdf"))
+	assert.Equal(t, "foto lomba-preview.jpg", previewFileName("foto lomba.png"))
+	assert.Equal(t, "noext-preview.jpg", previewFileName("noext"))
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+	"UAS/queue"
+	"UAS/scanner"
+	"UAS/storage"
+)
+
+// attachmentScanJob is the queue job type that scans an uploaded attachment
+const attachmentScanJob = "attachment-scan"
+
+// quarantinePrefix is the storage key prefix infected files are moved under
+const quarantinePrefix = "quarantine/"
+
+// attachmentJobPayload identifies the attachment a scan or preview job works on
+type attachmentJobPayload struct {
+	AchievementID string `json:"achievement_id"`
+	AttachmentID  string `json:"attachment_id"`
+}
+
+// attachmentScanner runs the malware scan of uploaded attachments and quarantines infected files
+type attachmentScanner struct {
+	pgRepo    *repository.AchievementRepository
+	mongoRepo *repository.MongoAchievementRepository
+	outbox    *repository.OutboxRepository
+	blobRepo  *repository.BlobRepository
+	blobs     storage.BlobStore
+	scanner   scanner.Scanner
+	notifier  *notifier
+}
+
+func newAttachmentScanner() *attachmentScanner {
+	return &attachmentScanner{
+		pgRepo:    repository.NewAchievementRepository(),
+		mongoRepo: repository.NewMongoAchievementRepository(),
+		outbox:    repository.NewOutboxRepository(),
+		blobRepo:  repository.NewBlobRepository(),
+		blobs:     storage.Default,
+		scanner:   scanner.Default,
+		notifier:  newNotifier(),
+	}
+}
+
+// RegisterScanJobs registers the attachment scan job handler. A scan that fails (e.g. clamd is
+// down) is retried; until it succeeds the attachment stays pending and cannot be downloaded
+func RegisterScanJobs(q *queue.Queue) {
+	s := newAttachmentScanner()
+	q.Register(attachmentScanJob, s.handle, queue.HandlerOptions{Concurrency: 2, MaxAttempts: 10})
+}
+
+// enqueueAttachmentScan queues the scan of a new attachment inside the upload transaction
+func enqueueAttachmentScan(tx *gorm.DB, achievementID, attachmentID string) error {
+	_, err := queue.Enqueue(attachmentScanJob, attachmentJobPayload{AchievementID: achievementID, AttachmentID: attachmentID}, queue.EnqueueOptions{Tx: tx})
+	return err
+}
+
+func (s *attachmentScanner) handle(ctx context.Context, job *models.QueueJob) error {
+	var payload attachmentJobPayload
+	if err := queue.Decode(job, &payload); err != nil {
+		return err
+	}
+
+	achievement, err := s.pgRepo.FindByID(payload.AchievementID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil // deleted in the meantime
+	}
+	if err != nil {
+		return err
+	}
+	doc, err := s.mongoRepo.FindByID(ctx, achievement.MongoAchievementID)
+	if err != nil {
+		return err
+	}
+	attachment := findAttachment(doc, payload.AttachmentID)
+	if attachment == nil {
+		return attachmentMissing(s.outbox, payload.AchievementID, payload.AttachmentID)
+	}
+	if attachment.ScanStatus != models.ScanPending {
+		return nil
+	}
+
+	result, err := s.scan(ctx, attachment)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	attachment.ScannedAt = &now
+	if !result.Infected {
+		attachment.ScanStatus = models.ScanClean
+		// Queued before the verdict is stored, so a failure here retries the scan instead of
+		// leaving the attachment without a preview
+		if err := enqueueAttachmentPreview(payload.AchievementID, payload.AttachmentID); err != nil {
+			return err
+		}
+		return s.mongoRepo.UpdateAttachmentScan(ctx, achievement.MongoAchievementID, payload.AttachmentID, *attachment)
+	}
+
+	if isBlobKey(attachment.Key()) {
+		// Every attachment sharing the content is infected too; their scans reuse the verdict
+		if err := s.blobRepo.MarkQuarantined(attachment.SHA256, quarantineKey(attachment.Key()), result.Signature); err != nil {
+			return err
+		}
+	}
+	if err := s.quarantine(ctx, attachment); err != nil {
+		return err
+	}
+	attachment.ScanStatus = models.ScanInfected
+	attachment.ScanSignature = result.Signature
+	if err := s.mongoRepo.UpdateAttachmentScan(ctx, achievement.MongoAchievementID, payload.AttachmentID, *attachment); err != nil {
+		return err
+	}
+
+	log.Printf("Attachment %s of achievement %s is infected (%s), quarantined as %s",
+		payload.AttachmentID, payload.AchievementID, result.Signature, attachment.StorageKey)
+	s.notifier.notify(models.NotificationAttachmentQuarantined, []string{achievement.StudentID},
+		"Attachment quarantined",
+		fmt.Sprintf("The file %q of your achievement %q contains malware (%s) and was quarantined. Check your device, then upload a clean copy.", attachment.FileName, doc.Title, result.Signature),
+		map[string]interface{}{"achievement_id": achievement.ID, "attachment_id": payload.AttachmentID})
+	return nil
+}
+
+// scan scans the file of an attachment. Shared content already found to be malware is not scanned
+// again; its file was moved to quarantine
+func (s *attachmentScanner) scan(ctx context.Context, attachment *models.Attachment) (*scanner.Result, error) {
+	if isBlobKey(attachment.Key()) {
+		if blob, err := s.blobRepo.FindBySHA256(attachment.SHA256); err == nil && blob.Quarantined {
+			return &scanner.Result{Infected: true, Signature: blob.Signature}, nil
+		}
+	}
+	r, _, err := s.blobs.Open(ctx, attachment.Key())
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	result, err := s.scanner.Scan(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// attachmentMissing handles an attachment a job cannot find in the document. While the achievement
+// has outbox entries to apply it may not have been added yet and the job is retried; otherwise it
+// was removed or replaced and there is nothing left to do
+func attachmentMissing(outbox *repository.OutboxRepository, achievementID, attachmentID string) error {
+	pending, err := outbox.HasPending(achievementID)
+	if err != nil {
+		return err
+	}
+	if pending {
+		return fmt.Errorf("attachment %s not in achievement %s yet", attachmentID, achievementID)
+	}
+	return nil
+}
+
+// quarantine moves an infected file under the quarantine prefix, where no download path reaches it,
+// and points the attachment at the new key
+func (s *attachmentScanner) quarantine(ctx context.Context, attachment *models.Attachment) error {
+	key := attachment.Key()
+	target := quarantineKey(key)
+	if key == target {
+		return nil
+	}
+
+	r, info, err := s.blobs.Open(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		// A previous attempt moved the file but did not record it
+		if _, serr := s.blobs.Stat(ctx, target); serr == nil {
+			attachment.StorageKey = target
+			return nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+	err = s.blobs.Put(ctx, target, r, info.Size, "application/octet-stream")
+	r.Close()
+	if err != nil {
+		return err
+	}
+	if err := s.blobs.Delete(ctx, key); err != nil {
+		return err
+	}
+	attachment.StorageKey = target
+	return nil
+}
+
+// quarantineKey returns the storage key an infected file is moved to
+func quarantineKey(key string) string {
+	if strings.HasPrefix(key, quarantinePrefix) {
+		return key
+	}
+	return quarantinePrefix + key
+}
+
+// attachmentUnavailable explains why an attachment cannot be downloaded, nil when it can.
+// Files are held until the malware scan reports them clean; attachments uploaded before scanning
+// have no status and stay available
+func attachmentUnavailable(attachment *models.Attachment) *reviewError {
+	switch attachment.ScanStatus {
+	case models.ScanPending:
+		return newReviewError(fiber.StatusConflict, "attachment is waiting for its malware scan, try again later")
+	case models.ScanInfected:
+		return newReviewError(fiber.StatusGone, "attachment contained malware and was quarantined")
+	}
+	return nil
+}
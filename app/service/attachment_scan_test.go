@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"UAS/app/models"
+	"UAS/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAttachmentUnavailable tests that only scanned-clean and legacy attachments can be downloaded
+func TestAttachmentUnavailable(t *testing.T) {
+	assert.Nil(t, attachmentUnavailable(&models.Attachment{ScanStatus: models.ScanClean}))
+	assert.Nil(t, attachmentUnavailable(&models.Attachment{FileURL: "/uploads/achievements/old.pdf"}))
+	assert.Equal(t, fiber.StatusConflict, attachmentUnavailable(&models.Attachment{ScanStatus: models.ScanPending}).code)
+	assert.Equal(t, fiber.StatusGone, attachmentUnavailable(&models.Attachment{ScanStatus: models.ScanInfected}).code)
+}
+
+// TestAttachmentViewScanStatus tests that pending and infected attachments get no download URL
+func TestAttachmentViewScanStatus(t *testing.T) {
+	now := time.Now()
+	pending := attachmentView("a1", &models.Attachment{ID: "f1", ScanStatus: models.ScanPending}, now)
+	assert.NotContains(t, pending, "url")
+	assert.Equal(t, models.ScanPending, pending["scan_status"])
+
+	infected := attachmentView("a1", &models.Attachment{ID: "f1", ScanStatus: models.ScanInfected, ScanSignature: "Eicar"}, now)
+	assert.NotContains(t, infected, "url")
+	assert.Equal(t, "Eicar", infected["scan_signature"])
+
+	clean := attachmentView("a1", &models.Attachment{ID: "f1", ScanStatus: models.ScanClean}, now)
+	assert.Contains(t, clean["url"], "/api/v1/files/achievements/a1/f1?")
+}
+
+// TestQuarantine tests that an infected file is moved under the quarantine prefix, also when retried
+func TestQuarantine(t *testing.T) {
+	ctx := context.Background()
+	blobs := &storage.LocalStore{Root: t.TempDir()}
+	s := &attachmentScanner{blobs: blobs}
+	assert.NoError(t, blobs.Put(ctx, "achievements/a1/f1.pdf", strings.NewReader("virus"), 5, "application/pdf"))
+
+	attachment := &models.Attachment{ID: "f1", StorageKey: "achievements/a1/f1.pdf"}
+	assert.NoError(t, s.quarantine(ctx, attachment))
+	assert.Equal(t, "quarantine/achievements/a1/f1.pdf", attachment.StorageKey)
+	_, err := blobs.Stat(ctx, "achievements/a1/f1.pdf")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	info, err := blobs.Stat(ctx, "quarantine/achievements/a1/f1.pdf")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), info.Size)
+
+	// A retry after the move was not recorded finds the quarantined copy
+	retry := &models.Attachment{ID: "f1", StorageKey: "achievements/a1/f1.pdf"}
+	assert.NoError(t, s.quarantine(ctx, retry))
+	assert.Equal(t, "quarantine/achievements/a1/f1.pdf", retry.StorageKey)
+
+	assert.NoError(t, s.quarantine(ctx, attachment))
+	assert.Equal(t, "quarantine/achievements/a1/f1.pdf", attachment.StorageKey)
+}
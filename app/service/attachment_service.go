@@ -0,0 +1,668 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+	"UAS/storage"
+	"UAS/utils"
+)
+
+// defaultAttachmentURLTTL is how long a signed attachment URL stays valid unless ATTACHMENT_URL_TTL is set
+const defaultAttachmentURLTTL = 15 * time.Minute
+
+// previewPath is appended to an attachment's URL to address its preview
+const previewPath = "/preview"
+
+// AttachmentService defines how achievement attachments are listed, downloaded and managed
+type AttachmentService interface {
+	ListAttachments(c *fiber.Ctx) error
+	DownloadAttachment(c *fiber.Ctx) error
+	DownloadSignedAttachment(c *fiber.Ctx) error
+	DownloadPreview(c *fiber.Ctx) error
+	DownloadSignedPreview(c *fiber.Ctx) error
+	DeleteAttachment(c *fiber.Ctx) error
+	ReplaceAttachment(c *fiber.Ctx) error
+	UpdateAttachment(c *fiber.Ctx) error
+	ReorderAttachments(c *fiber.Ctx) error
+}
+
+type attachmentServiceImpl struct {
+	pgRepo       *repository.AchievementRepository
+	mongoRepo    *repository.MongoAchievementRepository
+	studentRepo  *repository.StudentRepository
+	lecturerRepo *repository.LecturerRepository
+	participants *participantManager
+	outbox       *achievementOutbox
+	uploader     *attachmentUploader
+	blobs        storage.BlobStore
+}
+
+func NewAttachmentService() AttachmentService {
+	return &attachmentServiceImpl{
+		pgRepo:       repository.NewAchievementRepository(),
+		mongoRepo:    repository.NewMongoAchievementRepository(),
+		studentRepo:  repository.NewStudentRepository(),
+		lecturerRepo: repository.NewLecturerRepository(),
+		participants: newParticipantManager(),
+		outbox:       newAchievementOutbox(),
+		uploader:     newAttachmentUploader(),
+		blobs:        storage.Default,
+	}
+}
+
+// FunctionName godoc
+// @Summary List achievement attachments
+// @Description List the attachments of an achievement with signed, expiring download URLs that can be embedded without the Authorization header. Same access rules as the achievement detail
+// @Tags Achievements
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /achievements/{id}/attachments [get]
+// @Security Bearer
+func (s *attachmentServiceImpl) ListAttachments(c *fiber.Ctx) error {
+	achievement, doc, aerr := s.loadViewable(c)
+	if aerr != nil {
+		return utils.ErrorResponse(c, aerr.code, aerr.message)
+	}
+
+	now := time.Now()
+	attachments := make([]fiber.Map, 0, len(doc.Attachments))
+	for i := range doc.Attachments {
+		attachments = append(attachments, attachmentView(achievement.ID, &doc.Attachments[i], now))
+	}
+	return utils.SuccessResponse(c, "attachments retrieved", attachments)
+}
+
+// FunctionName godoc
+// @Summary Download achievement attachment
+// @Description Download an attachment file. Same access rules as the achievement detail
+// @Tags Achievements
+// @Produce octet-stream
+// @Param id path string true "Achievement ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Param download query bool false "Send as a download instead of inline"
+// @Success 200 {file} file
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /achievements/{id}/attachments/{attachmentId} [get]
+// @Security Bearer
+func (s *attachmentServiceImpl) DownloadAttachment(c *fiber.Ctx) error {
+	_, doc, aerr := s.loadViewable(c)
+	if aerr != nil {
+		return utils.ErrorResponse(c, aerr.code, aerr.message)
+	}
+
+	attachment := findAttachment(doc, attachmentParam(c))
+	if attachment == nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "attachment not found")
+	}
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return s.sendAttachment(c, attachment)
+}
+
+// FunctionName godoc
+// @Summary Download attachment by signed URL
+// @Description Download an attachment through a signed URL returned by the attachment list. Needs no token; the URL stops working when it expires or the attachment is removed
+// @Tags Achievements
+// @Produce octet-stream
+// @Param id path string true "Achievement ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Param expires query int true "Expiry as a Unix timestamp"
+// @Param signature query string true "URL signature"
+// @Success 200 {file} file
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /files/achievements/{id}/{attachmentId} [get]
+func (s *attachmentServiceImpl) DownloadSignedAttachment(c *fiber.Ctx) error {
+	attachment, aerr := s.loadSigned(c, "")
+	if aerr != nil {
+		return utils.ErrorResponse(c, aerr.code, aerr.message)
+	}
+	return s.sendAttachment(c, attachment)
+}
+
+// FunctionName godoc
+// @Summary Download attachment preview
+// @Description Download the JPEG preview of an attachment: a thumbnail of an image or the first page of a PDF. Previews are generated in the background after the malware scan; 404 until one exists. Same access rules as the achievement detail
+// @Tags Achievements
+// @Produce jpeg
+// @Param id path string true "Achievement ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /achievements/{id}/attachments/{attachmentId}/preview [get]
+// @Security Bearer
+func (s *attachmentServiceImpl) DownloadPreview(c *fiber.Ctx) error {
+	_, doc, aerr := s.loadViewable(c)
+	if aerr != nil {
+		return utils.ErrorResponse(c, aerr.code, aerr.message)
+	}
+
+	attachment := findAttachment(doc, attachmentParam(c))
+	if attachment == nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "attachment not found")
+	}
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return s.sendPreview(c, attachment)
+}
+
+// FunctionName godoc
+// @Summary Download attachment preview by signed URL
+// @Description Download an attachment preview through the signed preview URL returned with the attachment. Needs no token
+// @Tags Achievements
+// @Produce jpeg
+// @Param id path string true "Achievement ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Param expires query int true "Expiry as a Unix timestamp"
+// @Param signature query string true "URL signature"
+// @Success 200 {file} file
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /files/achievements/{id}/{attachmentId}/preview [get]
+func (s *attachmentServiceImpl) DownloadSignedPreview(c *fiber.Ctx) error {
+	attachment, aerr := s.loadSigned(c, previewPath)
+	if aerr != nil {
+		return utils.ErrorResponse(c, aerr.code, aerr.message)
+	}
+	return s.sendPreview(c, attachment)
+}
+
+// FunctionName godoc
+// @Summary Delete achievement attachment
+// @Description Remove an attachment from a draft achievement and delete its file
+// @Tags Achievements
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /achievements/{id}/attachments/{attachmentId} [delete]
+// @Security Bearer
+func (s *attachmentServiceImpl) DeleteAttachment(c *fiber.Ctx) error {
+	achievement, doc, aerr := s.loadChangeable(c)
+	if aerr != nil {
+		return utils.ErrorResponse(c, aerr.code, aerr.message)
+	}
+	attachment := findAttachment(doc, attachmentParam(c))
+	if attachment == nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "attachment not found")
+	}
+
+	// The file is deleted once the document no longer references it
+	entry := newOutboxEntry(achievement, models.OutboxOpRemoveAttachment, models.OutboxChange{Previous: attachment})
+	for _, key := range attachmentBlobKeys(attachment) {
+		entry.Events = append(entry.Events, outboxBlobDelete(key))
+	}
+	record := s.outbox.record(entry)
+	achievement.UpdatedAt = time.Now()
+	err := s.pgRepo.Update(achievement.ID, achievement, func(tx *gorm.DB) error {
+		if err := s.uploader.store.release(tx, attachment); err != nil {
+			return err
+		}
+		return record(tx)
+	})
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to delete attachment")
+	}
+	s.outbox.flush(entry)
+
+	return utils.SuccessResponse(c, "attachment deleted", nil)
+}
+
+// FunctionName godoc
+// @Summary Replace achievement attachment
+// @Description Replace the file of an attachment on a draft achievement. The new file takes the place of the old one under a new ID and is scanned again; caption and kind are kept unless sent
+// @Tags Achievements
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Param file formData file true "New file"
+// @Param caption formData string false "Caption, at most 200 characters"
+// @Param kind formData string false "certificate, photo, assignment_letter or other"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 415 {object} map[string]interface{}
+// @Router /achievements/{id}/attachments/{attachmentId} [put]
+// @Security Bearer
+func (s *attachmentServiceImpl) ReplaceAttachment(c *fiber.Ctx) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "file is required")
+	}
+
+	achievement, doc, aerr := s.loadChangeable(c)
+	if aerr != nil {
+		return utils.ErrorResponse(c, aerr.code, aerr.message)
+	}
+	previous := findAttachment(doc, attachmentParam(c))
+	if previous == nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "attachment not found")
+	}
+
+	caption, kind := previous.Caption, previous.Kind
+	if form, err := c.MultipartForm(); err == nil {
+		if values, ok := form.Value["caption"]; ok && len(values) > 0 {
+			caption = values[0]
+		}
+		if values, ok := form.Value["kind"]; ok && len(values) > 0 {
+			kind = values[0]
+		}
+	}
+	caption, kind, err = normalizeAttachmentMeta(caption, kind)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	attachment, uerr := s.uploader.prepareFile(c.UserContext(), achievement, file)
+	if uerr != nil {
+		return utils.ErrorResponse(c, uerr.code, uerr.message)
+	}
+	attachment.Caption = caption
+	attachment.Kind = kind
+
+	if err := s.uploader.attach(c.UserContext(), achievement, attachment, previous, nil); err != nil {
+		aerr := attachError(err)
+		return utils.ErrorResponse(c, aerr.code, aerr.message)
+	}
+
+	return utils.SuccessResponse(c, "attachment replaced", attachmentView(achievement.ID, attachment.Attachment, time.Now()))
+}
+
+// FunctionName godoc
+// @Summary Update achievement attachment
+// @Description Change the caption or kind of an attachment on a draft achievement; omitted fields are kept
+// @Tags Achievements
+// @Accept json
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Param body body models.UpdateAttachmentRequest true "Caption and kind"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /achievements/{id}/attachments/{attachmentId} [patch]
+// @Security Bearer
+func (s *attachmentServiceImpl) UpdateAttachment(c *fiber.Ctx) error {
+	var req models.UpdateAttachmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "invalid request body")
+	}
+	if req.Caption == nil && req.Kind == nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "caption or kind is required")
+	}
+
+	achievement, doc, aerr := s.loadChangeable(c)
+	if aerr != nil {
+		return utils.ErrorResponse(c, aerr.code, aerr.message)
+	}
+	current := findAttachment(doc, attachmentParam(c))
+	if current == nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "attachment not found")
+	}
+
+	attachment := *current
+	if req.Caption != nil {
+		attachment.Caption = *req.Caption
+	}
+	if req.Kind != nil {
+		attachment.Kind = *req.Kind
+	}
+	var err error
+	attachment.Caption, attachment.Kind, err = normalizeAttachmentMeta(attachment.Caption, attachment.Kind)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	entry := newOutboxEntry(achievement, models.OutboxOpUpdateAttachment, models.OutboxChange{Attachment: &attachment})
+	achievement.UpdatedAt = time.Now()
+	if err := s.pgRepo.Update(achievement.ID, achievement, s.outbox.record(entry)); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to update attachment")
+	}
+	s.outbox.flush(entry)
+
+	return utils.SuccessResponse(c, "attachment updated", attachmentView(achievement.ID, &attachment, time.Now()))
+}
+
+// FunctionName godoc
+// @Summary Reorder achievement attachments
+// @Description Set the order of the attachments of a draft achievement. attachment_ids must list every attachment exactly once
+// @Tags Achievements
+// @Accept json
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Param body body models.ReorderAttachmentsRequest true "Attachment IDs in the new order"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /achievements/{id}/attachments/order [put]
+// @Security Bearer
+func (s *attachmentServiceImpl) ReorderAttachments(c *fiber.Ctx) error {
+	var req models.ReorderAttachmentsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "invalid request body")
+	}
+
+	achievement, doc, aerr := s.loadChangeable(c)
+	if aerr != nil {
+		return utils.ErrorResponse(c, aerr.code, aerr.message)
+	}
+	if err := validateAttachmentOrder(doc.Attachments, req.AttachmentIDs); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	// The document matches attachments stored without an ID by their file URL
+	ordered := models.OrderAttachments(doc.Attachments, req.AttachmentIDs)
+	order := make([]string, 0, len(ordered))
+	for _, attachment := range ordered {
+		if attachment.ID != "" {
+			order = append(order, attachment.ID)
+		} else {
+			order = append(order, attachment.FileURL)
+		}
+	}
+
+	entry := newOutboxEntry(achievement, models.OutboxOpReorderAttachments, models.OutboxChange{AttachmentOrder: order})
+	achievement.UpdatedAt = time.Now()
+	if err := s.pgRepo.Update(achievement.ID, achievement, s.outbox.record(entry)); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to reorder attachments")
+	}
+	s.outbox.flush(entry)
+
+	now := time.Now()
+	attachments := make([]fiber.Map, 0, len(ordered))
+	for i := range ordered {
+		attachments = append(attachments, attachmentView(achievement.ID, &ordered[i], now))
+	}
+	return utils.SuccessResponse(c, "attachments reordered", attachments)
+}
+
+// loadViewable loads the achievement in the id parameter and its document, checking the caller may view it
+func (s *attachmentServiceImpl) loadViewable(c *fiber.Ctx) (*models.AchievementReference, *models.MongoAchievement, *reviewError) {
+	achievement, err := s.pgRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return nil, nil, newReviewError(fiber.StatusNotFound, "achievement not found")
+	}
+	if verr := authorizeView(s.studentRepo, s.lecturerRepo, s.participants, achievement, c.Locals("userID").(string), c.Locals("role").(string)); verr != nil {
+		return nil, nil, verr
+	}
+	doc, err := s.mongoRepo.FindByID(c.UserContext(), achievement.MongoAchievementID)
+	if err != nil {
+		return nil, nil, newReviewError(fiber.StatusNotFound, "achievement not found")
+	}
+	return achievement, doc, nil
+}
+
+// loadChangeable loads the achievement in the id parameter and its document, checking the caller may
+// change its attachments
+func (s *attachmentServiceImpl) loadChangeable(c *fiber.Ctx) (*models.AchievementReference, *models.MongoAchievement, *reviewError) {
+	achievement, err := s.pgRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return nil, nil, newReviewError(fiber.StatusNotFound, "achievement not found")
+	}
+	if aerr := authorizeAttachmentChange(achievement, c.Locals("userID").(string), c.Locals("role").(string)); aerr != nil {
+		return nil, nil, aerr
+	}
+	doc, err := s.mongoRepo.FindByID(c.UserContext(), achievement.MongoAchievementID)
+	if err != nil {
+		return nil, nil, newReviewError(fiber.StatusNotFound, "achievement not found")
+	}
+	return achievement, doc, nil
+}
+
+// loadSigned checks the signature of a signed attachment URL for the file or variant (e.g. the
+// preview) it addresses and loads the attachment
+func (s *attachmentServiceImpl) loadSigned(c *fiber.Ctx, variant string) (*models.Attachment, *reviewError) {
+	achievementID, attachmentID := c.Params("id"), attachmentParam(c)
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		return nil, newReviewError(fiber.StatusForbidden, "invalid signature")
+	}
+	if err := verifyAttachmentSignature(attachmentURLSecret(), achievementID, attachmentID+variant, expires, c.Query("signature"), time.Now()); err != nil {
+		return nil, newReviewError(fiber.StatusForbidden, err.Error())
+	}
+
+	achievement, err := s.pgRepo.FindByID(achievementID)
+	if err != nil {
+		return nil, newReviewError(fiber.StatusNotFound, "attachment not found")
+	}
+	doc, err := s.mongoRepo.FindByID(c.UserContext(), achievement.MongoAchievementID)
+	if err != nil {
+		return nil, newReviewError(fiber.StatusNotFound, "attachment not found")
+	}
+	attachment := findAttachment(doc, attachmentID)
+	if attachment == nil {
+		return nil, newReviewError(fiber.StatusNotFound, "attachment not found")
+	}
+
+	// Browsers may cache the file until the URL expires, but shared caches must not
+	maxAge := expires - time.Now().Unix()
+	c.Set(fiber.HeaderCacheControl, "private, max-age="+strconv.FormatInt(maxAge, 10))
+	return attachment, nil
+}
+
+// sendAttachment streams an attachment from the blob store, unless it is held by the malware scan
+func (s *attachmentServiceImpl) sendAttachment(c *fiber.Ctx, attachment *models.Attachment) error {
+	if uerr := attachmentUnavailable(attachment); uerr != nil {
+		return utils.ErrorResponse(c, uerr.code, uerr.message)
+	}
+	return s.sendBlob(c, attachment.Key(), attachment.FileType, attachment.FileName)
+}
+
+// sendPreview streams the preview of an attachment
+func (s *attachmentServiceImpl) sendPreview(c *fiber.Ctx, attachment *models.Attachment) error {
+	if uerr := attachmentUnavailable(attachment); uerr != nil {
+		return utils.ErrorResponse(c, uerr.code, uerr.message)
+	}
+	if attachment.Preview == nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "attachment has no preview")
+	}
+	return s.sendBlob(c, attachment.Preview.StorageKey, attachment.Preview.ContentType, previewFileName(attachment.FileName))
+}
+
+// sendBlob streams a stored file, inline only when its type is safe to show in the browser
+func (s *attachmentServiceImpl) sendBlob(c *fiber.Ctx, key, contentType, fileName string) error {
+	r, info, err := s.blobs.Open(c.UserContext(), key)
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "attachment file not found")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to read attachment")
+	}
+
+	if contentType == "" {
+		contentType = info.ContentType
+	}
+	inline := c.Query("download") != "true" && isInlineType(contentType)
+	if !isInlineType(contentType) {
+		contentType = "application/octet-stream"
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, contentDisposition(fileName, inline))
+	// Uploaded files must never run as a page of this origin
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderContentSecurityPolicy, "default-src 'none'; sandbox")
+	return c.SendStream(r, int(info.Size))
+}
+
+// attachmentParam returns the unescaped attachmentId parameter; legacy IDs are file names that may need escaping
+func attachmentParam(c *fiber.Ctx) string {
+	id := c.Params("attachmentId")
+	if unescaped, err := url.PathUnescape(id); err == nil {
+		return unescaped
+	}
+	return id
+}
+
+// findAttachment returns the attachment with the given ID, nil when there is none
+func findAttachment(doc *models.MongoAchievement, attachmentID string) *models.Attachment {
+	for i := range doc.Attachments {
+		if doc.Attachments[i].AttachmentID() == attachmentID {
+			return &doc.Attachments[i]
+		}
+	}
+	return nil
+}
+
+// validateAttachmentOrder checks that ids lists every attachment exactly once
+func validateAttachmentOrder(attachments []models.Attachment, ids []string) error {
+	if len(ids) != len(attachments) {
+		return errors.New("attachment_ids must list every attachment exactly once")
+	}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return errors.New("attachment_ids must list every attachment exactly once")
+		}
+		seen[id] = true
+	}
+	for i := range attachments {
+		if !seen[attachments[i].AttachmentID()] {
+			return errors.New("attachment_ids must list every attachment exactly once")
+		}
+	}
+	return nil
+}
+
+// attachmentView is the API representation of an attachment, with a signed download URL once the
+// file may be downloaded
+func attachmentView(achievementID string, attachment *models.Attachment, now time.Time) fiber.Map {
+	view := fiber.Map{
+		"id":             attachment.AttachmentID(),
+		"achievement_id": achievementID,
+		"file_name":      attachment.FileName,
+		"file_type":      attachment.FileType,
+		"file_size":      attachment.FileSize,
+		"caption":        attachment.Caption,
+		"kind":           attachment.Kind,
+		"uploaded_at":    attachment.UploadedAt,
+		"scan_status":    attachment.ScanStatus,
+	}
+	if attachment.ScanStatus == models.ScanInfected {
+		view["scan_signature"] = attachment.ScanSignature
+	}
+	if attachmentUnavailable(attachment) == nil {
+		view["url"], view["url_expires_at"] = signedAttachmentURL(achievementID, attachment.AttachmentID(), now)
+		if attachment.Preview != nil {
+			previewURL, _ := signedPreviewURL(achievementID, attachment.AttachmentID(), now)
+			view["preview"] = fiber.Map{
+				"url":          previewURL,
+				"content_type": attachment.Preview.ContentType,
+				"width":        attachment.Preview.Width,
+				"height":       attachment.Preview.Height,
+			}
+		}
+	}
+	return view
+}
+
+// previewFileName is the download name of an attachment's preview
+func previewFileName(fileName string) string {
+	return strings.TrimSuffix(fileName, path.Ext(fileName)) + "-preview.jpg"
+}
+
+// isInlineType reports whether a content type is safe to show in the browser
+func isInlineType(contentType string) bool {
+	switch contentType {
+	case "application/pdf", "image/jpeg", "image/png", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
+// contentDisposition builds the Content-Disposition header, encoding non-ASCII file names
+func contentDisposition(fileName string, inline bool) string {
+	disposition := "attachment"
+	if inline {
+		disposition = "inline"
+	}
+	if header := mime.FormatMediaType(disposition, map[string]string{"filename": fileName}); header != "" {
+		return header
+	}
+	return disposition
+}
+
+// attachmentURLSecret is the key signed attachment URLs are signed with; ATTACHMENT_URL_SECRET,
+// falling back to JWT_SECRET and then to the same default as the JWT code, so URLs are never
+// signed with an empty key
+func attachmentURLSecret() string {
+	if secret := os.Getenv("ATTACHMENT_URL_SECRET"); secret != "" {
+		return secret
+	}
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		return secret
+	}
+	return "your-secret-key-change-in-production"
+}
+
+// attachmentURLTTL returns ATTACHMENT_URL_TTL (e.g. "30m"), the lifetime of signed attachment URLs
+func attachmentURLTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("ATTACHMENT_URL_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return defaultAttachmentURLTTL
+}
+
+// signedAttachmentURL returns a URL that downloads an attachment without authentication until it expires
+func signedAttachmentURL(achievementID, attachmentID string, now time.Time) (string, time.Time) {
+	return signedFileURL(achievementID, attachmentID, "", now)
+}
+
+// signedPreviewURL returns a URL that downloads an attachment's preview without authentication until it expires
+func signedPreviewURL(achievementID, attachmentID string, now time.Time) (string, time.Time) {
+	return signedFileURL(achievementID, attachmentID, previewPath, now)
+}
+
+// signedFileURL signs the URL of an attachment or of a variant of it. Attachment IDs never contain
+// a slash, so the signature of a variant cannot be used for the file itself or the other way round
+func signedFileURL(achievementID, attachmentID, variant string, now time.Time) (string, time.Time) {
+	expiresAt := now.Add(attachmentURLTTL()).Truncate(time.Second)
+	expires := expiresAt.Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", attachmentSignature(attachmentURLSecret(), achievementID, attachmentID+variant, expires))
+	return "/api/v1/files/achievements/" + url.PathEscape(achievementID) + "/" + url.PathEscape(attachmentID) + variant + "?" + query.Encode(), expiresAt
+}
+
+// attachmentSignature is the hex HMAC-SHA256 of the attachment and its expiry
+func attachmentSignature(secret, achievementID, attachmentID string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(achievementID + "/" + attachmentID + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyAttachmentSignature checks a signed attachment URL
+func verifyAttachmentSignature(secret, achievementID, attachmentID string, expires int64, signature string, now time.Time) error {
+	expected := attachmentSignature(secret, achievementID, attachmentID, expires)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errors.New("invalid signature")
+	}
+	if now.Unix() > expires {
+		return errors.New("link has expired")
+	}
+	return nil
+}
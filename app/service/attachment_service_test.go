@@ -0,0 +1,83 @@
+package service
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"UAS/app/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAttachmentSignature tests signing and verifying attachment URLs
+func TestAttachmentSignature(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	expires := now.Add(time.Minute).Unix()
+	sig := attachmentSignature("secret", "a1", "f1", expires)
+
+	assert.NoError(t, verifyAttachmentSignature("secret", "a1", "f1", expires, sig, now))
+	assert.EqualError(t, verifyAttachmentSignature("secret", "a1", "f1", expires, sig, now.Add(2*time.Minute)), "link has expired")
+	assert.EqualError(t, verifyAttachmentSignature("secret", "a1", "f2", expires, sig, now), "invalid signature")
+	assert.EqualError(t, verifyAttachmentSignature("secret", "a1", "f1", expires+3600, sig, now), "invalid signature")
+	assert.EqualError(t, verifyAttachmentSignature("other", "a1", "f1", expires, sig, now), "invalid signature")
+}
+
+// TestSignedAttachmentURL tests that generated URLs verify and honour ATTACHMENT_URL_TTL
+func TestSignedAttachmentURL(t *testing.T) {
+	t.Setenv("ATTACHMENT_URL_SECRET", "secret")
+	t.Setenv("ATTACHMENT_URL_TTL", "30m")
+	now := time.Unix(1700000000, 0)
+
+	link, expiresAt := signedAttachmentURL("a1", "f 1.pdf", now)
+	assert.Equal(t, now.Add(30*time.Minute), expiresAt)
+	assert.True(t, strings.HasPrefix(link, "/api/v1/files/achievements/a1/f%201.pdf?"))
+
+	u, err := url.Parse(link)
+	assert.NoError(t, err)
+	expires, _ := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	assert.NoError(t, verifyAttachmentSignature("secret", "a1", "f 1.pdf", expires, u.Query().Get("signature"), now))
+}
+
+// TestAttachmentURLSecretNeverEmpty tests that URLs are not signed with an empty key when no
+// secret is configured
+func TestAttachmentURLSecretNeverEmpty(t *testing.T) {
+	t.Setenv("ATTACHMENT_URL_SECRET", "")
+	t.Setenv("JWT_SECRET", "")
+	now := time.Unix(1700000000, 0)
+
+	assert.NotEmpty(t, attachmentURLSecret())
+	link, _ := signedAttachmentURL("a1", "f1", now)
+	u, err := url.Parse(link)
+	assert.NoError(t, err)
+	expires, _ := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	assert.EqualError(t, verifyAttachmentSignature("", "a1", "f1", expires, u.Query().Get("signature"), now), "invalid signature")
+
+	t.Setenv("JWT_SECRET", "jwt")
+	assert.Equal(t, "jwt", attachmentURLSecret())
+}
+
+// TestFindAttachment tests attachment lookup by ID, including legacy records without one
+func TestFindAttachment(t *testing.T) {
+	doc := &models.MongoAchievement{Attachments: []models.Attachment{
+		{ID: "f1", StorageKey: "achievements/a1/f1.pdf"},
+		{FileURL: "/uploads/achievements/old_cert.pdf"},
+	}}
+
+	assert.Equal(t, "achievements/a1/f1.pdf", findAttachment(doc, "f1").Key())
+	legacy := findAttachment(doc, "old_cert.pdf")
+	assert.NotNil(t, legacy)
+	assert.Equal(t, "achievements/old_cert.pdf", legacy.Key())
+	assert.Nil(t, findAttachment(doc, "missing"))
+}
+
+// TestContentDisposition tests inline and download headers, including non-ASCII names
+func TestContentDisposition(t *testing.T) {
+	assert.Equal(t, `inline; filename=cert.pdf`, contentDisposition("cert.pdf", true))
+	assert.Equal(t, `attachment; filename="my cert.pdf"`, contentDisposition("my cert.pdf", false))
+	assert.Equal(t, `attachment; filename*=utf-8''sertifikat%C3%A9.pdf`, contentDisposition("sertifikaté.pdf", false))
+	assert.True(t, isInlineType("application/pdf"))
+	assert.False(t, isInlineType("text/html"))
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+)
+
+const (
+	// maxAttachmentSize is the upload limit of a single attachment
+	maxAttachmentSize = 10 * 1024 * 1024
+	// maxAttachmentCaptionLength is the longest accepted caption, in characters
+	maxAttachmentCaptionLength = 200
+)
+
+// attachmentUploader validates uploaded attachment files, stores them in the blob store and records
+// them on the achievement. Single-request uploads, resumable uploads and replacing an attachment share it
+type attachmentUploader struct {
+	pgRepo    *repository.AchievementRepository
+	mongoRepo *repository.MongoAchievementRepository
+	typeRepo  *repository.AchievementTypeRepository
+	outbox    *achievementOutbox
+	store     *blobStore
+}
+
+func newAttachmentUploader() *attachmentUploader {
+	return &attachmentUploader{
+		pgRepo:    repository.NewAchievementRepository(),
+		mongoRepo: repository.NewMongoAchievementRepository(),
+		typeRepo:  repository.NewAchievementTypeRepository(),
+		outbox:    newAchievementOutbox(),
+		store:     newBlobStore(),
+	}
+}
+
+// preparedAttachment is an upload that passed validation. Its content is only written to the blob
+// store when attach finds no stored file with the same hash
+type preparedAttachment struct {
+	*models.Attachment
+	open func() (io.ReadCloser, error)
+}
+
+// prepareFile validates a single-request upload under a new attachment ID
+func (u *attachmentUploader) prepareFile(ctx context.Context, achievement *models.AchievementReference, file *multipart.FileHeader) (*preparedAttachment, *reviewError) {
+	if file.Size > maxAttachmentSize {
+		return nil, newReviewError(fiber.StatusBadRequest, "file size exceeds 10MB limit, use the resumable upload endpoint for larger files")
+	}
+	open := func() (io.ReadCloser, error) { return file.Open() }
+	return u.prepare(ctx, achievement, uuid.New().String(), file.Filename, open, file.Size)
+}
+
+// prepare validates a file of size bytes read from open against the allowlist of the achievement's
+// type and the size limit of its type, and hashes it for the attachment with the given ID. Videos
+// are validated while they are read and read again when they are stored; other files are read into
+// memory, where images lose their metadata
+func (u *attachmentUploader) prepare(ctx context.Context, achievement *models.AchievementReference, attachmentID, fileName string, open func() (io.ReadCloser, error), size int64) (*preparedAttachment, *reviewError) {
+	allowedTypes := u.allowedTypes(ctx, achievement)
+	r, err := open()
+	if err != nil {
+		return nil, newReviewError(fiber.StatusInternalServerError, "failed to read uploaded file")
+	}
+	defer r.Close()
+
+	// The type comes from the content, not the client
+	br := bufio.NewReaderSize(r, 512)
+	head, _ := br.Peek(512)
+	contentType := sniffAttachmentType(head)
+	if contentType == "" {
+		return nil, newReviewError(fiber.StatusUnsupportedMediaType, errAttachmentType.Error()+": only PDF, JPEG, PNG, WebP, MP4 and MOV files are accepted")
+	}
+	if size > attachmentSizeLimit(contentType) {
+		return nil, newReviewError(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("%s files are limited to %dMB", contentType, attachmentSizeLimit(contentType)>>20))
+	}
+
+	if isVideoAttachment(contentType) {
+		if !attachmentTypeAllowed(allowedTypes, contentType) {
+			return nil, newReviewError(fiber.StatusUnsupportedMediaType, fmt.Sprintf("%s: %s files are not accepted for this achievement type", errAttachmentType, contentType))
+		}
+		hash := sha256.New()
+		video := newVideoValidator(br, size)
+		if _, err := io.Copy(hash, video); err != nil {
+			if video.err != nil {
+				return nil, newReviewError(fiber.StatusBadRequest, video.err.Error())
+			}
+			return nil, newReviewError(fiber.StatusInternalServerError, "failed to read uploaded file")
+		}
+		name := sanitizeAttachmentName(fileName, contentType)
+		return &preparedAttachment{newStoredAttachment(attachmentID, name, contentType, size, hex.EncodeToString(hash.Sum(nil))), open}, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(br, size))
+	if err != nil {
+		return nil, newReviewError(fiber.StatusInternalServerError, "failed to read uploaded file")
+	}
+
+	// Images lose their EXIF/GPS metadata
+	upload, err := inspectAttachment(fileName, data, allowedTypes)
+	if errors.Is(err, errAttachmentType) {
+		return nil, newReviewError(fiber.StatusUnsupportedMediaType, err.Error())
+	}
+	if err != nil {
+		return nil, newReviewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// The hash of the cleaned file addresses it in the blob store and lets duplicate detection
+	// recognise the same certificate uploaded twice
+	attachment := newStoredAttachment(attachmentID, upload.FileName, upload.ContentType, int64(len(upload.Data)), hashContent(upload.Data))
+	return &preparedAttachment{attachment, func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(upload.Data)), nil
+	}}, nil
+}
+
+// allowedTypes returns the attachment types the achievement's type accepts; empty accepts every supported type
+func (u *attachmentUploader) allowedTypes(ctx context.Context, achievement *models.AchievementReference) []string {
+	if doc, err := u.mongoRepo.FindByID(ctx, achievement.MongoAchievementID); err == nil {
+		if achievementType, err := u.typeRepo.FindByCode(doc.AchievementType); err == nil {
+			return achievementType.AllowedAttachmentTypes
+		}
+	}
+	return nil
+}
+
+// attach records a prepared attachment on the achievement, or in place of previous when given,
+// stores its content unless an identical file is stored already and queues its malware scan, in
+// one transaction together with extra when given. Exceeding the owner's storage quota returns an
+// error wrapping errStorageQuota
+func (u *attachmentUploader) attach(ctx context.Context, achievement *models.AchievementReference, prepared *preparedAttachment, previous *models.Attachment, extra func(tx *gorm.DB) error) error {
+	attachment := prepared.Attachment
+	achievement.UpdatedAt = time.Now()
+	entry := newOutboxEntry(achievement, models.OutboxOpAddAttachment, models.OutboxChange{Attachment: attachment})
+	if previous != nil {
+		// The old file goes once the document no longer references it
+		entry = newOutboxEntry(achievement, models.OutboxOpReplaceAttachment, models.OutboxChange{Attachment: attachment, Previous: previous})
+		for _, key := range attachmentBlobKeys(previous) {
+			entry.Events = append(entry.Events, outboxBlobDelete(key))
+		}
+	}
+	record := u.outbox.record(entry)
+	err := u.pgRepo.Update(achievement.ID, achievement, func(tx *gorm.DB) error {
+		if extra != nil {
+			if err := extra(tx); err != nil {
+				return err
+			}
+		}
+		if previous != nil {
+			if err := u.store.release(tx, previous); err != nil {
+				return err
+			}
+		}
+		// Sets the storage key, so it runs before the entry is recorded
+		if err := u.store.acquire(ctx, tx, achievement, attachment, prepared.open); err != nil {
+			return err
+		}
+		if err := record(tx); err != nil {
+			return err
+		}
+		// The file stays unavailable until the malware scan reports it clean
+		return enqueueAttachmentScan(tx, achievement.ID, attachment.ID)
+	})
+	if err != nil {
+		return err
+	}
+	u.outbox.flush(entry)
+	return nil
+}
+
+// attachError maps a failure to record an attachment to a response
+func attachError(err error) *reviewError {
+	if errors.Is(err, errStorageQuota) {
+		return newReviewError(fiber.StatusRequestEntityTooLarge, err.Error())
+	}
+	return newReviewError(fiber.StatusInternalServerError, "failed to save attachment record")
+}
+
+func newStoredAttachment(id, fileName, contentType string, size int64, hash string) *models.Attachment {
+	return &models.Attachment{
+		ID:         id,
+		FileName:   fileName,
+		StorageKey: blobKey(hash),
+		FileType:   contentType,
+		FileSize:   size,
+		SHA256:     hash,
+		UploadedAt: time.Now(),
+		ScanStatus: models.ScanPending,
+	}
+}
+
+// authorizeAttachmentChange checks that the attachments of an achievement may still be changed by the
+// caller: only while it is a draft, and students only on their own achievements
+func authorizeAttachmentChange(achievement *models.AchievementReference, userID, role string) *reviewError {
+	if achievement.Status != "draft" {
+		return newReviewError(fiber.StatusForbidden, "attachments can only be changed on draft achievements. Current status: "+achievement.Status)
+	}
+	if role == "Mahasiswa" && achievement.StudentID != userID {
+		return newReviewError(fiber.StatusForbidden, "you can only change attachments of your own achievements")
+	}
+	return nil
+}
+
+// normalizeAttachmentMeta trims a caption and checks it and the kind; both may be empty
+func normalizeAttachmentMeta(caption, kind string) (string, string, error) {
+	caption = strings.TrimSpace(caption)
+	if utf8.RuneCountInString(caption) > maxAttachmentCaptionLength {
+		return "", "", errors.New("caption must be at most 200 characters")
+	}
+	kind = strings.TrimSpace(kind)
+	if kind != "" && !containsString(models.AttachmentKinds, kind) {
+		return "", "", errors.New("kind must be one of " + strings.Join(models.AttachmentKinds, ", "))
+	}
+	return caption, kind, nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"UAS/app/models"
+	"UAS/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNormalizeAttachmentMeta tests caption trimming and the accepted kinds
+func TestNormalizeAttachmentMeta(t *testing.T) {
+	caption, kind, err := normalizeAttachmentMeta("  Juara 1 final  ", "certificate")
+	assert.NoError(t, err)
+	assert.Equal(t, "Juara 1 final", caption)
+	assert.Equal(t, models.AttachmentCertificate, kind)
+
+	_, _, err = normalizeAttachmentMeta("", "")
+	assert.NoError(t, err)
+
+	_, _, err = normalizeAttachmentMeta(strings.Repeat("é", 200), "")
+	assert.NoError(t, err)
+	_, _, err = normalizeAttachmentMeta(strings.Repeat("é", 201), "")
+	assert.Error(t, err)
+
+	_, _, err = normalizeAttachmentMeta("", "diploma")
+	assert.EqualError(t, err, "kind must be one of certificate, photo, assignment_letter, other")
+}
+
+// TestAuthorizeAttachmentChange tests that only draft attachments of the caller's own achievements change
+func TestAuthorizeAttachmentChange(t *testing.T) {
+	draft := &models.AchievementReference{StudentID: "u1", Status: "draft"}
+	assert.Nil(t, authorizeAttachmentChange(draft, "u1", "Mahasiswa"))
+	assert.Nil(t, authorizeAttachmentChange(draft, "admin", "Admin"))
+	assert.Equal(t, fiber.StatusForbidden, authorizeAttachmentChange(draft, "u2", "Mahasiswa").code)
+
+	submitted := &models.AchievementReference{StudentID: "u1", Status: "submitted"}
+	assert.Equal(t, fiber.StatusForbidden, authorizeAttachmentChange(submitted, "u1", "Mahasiswa").code)
+}
+
+// TestOrderAttachments tests reordering, including attachments left out of the order
+func TestOrderAttachments(t *testing.T) {
+	attachments := []models.Attachment{{ID: "a"}, {ID: "b"}, {FileURL: "/uploads/achievements/old.pdf"}, {ID: "d"}}
+
+	ordered := models.OrderAttachments(attachments, []string{"d", "old.pdf", "a", "b"})
+	assert.Equal(t, []string{"d", "old.pdf", "a", "b"}, attachmentIDs(ordered))
+
+	ordered = models.OrderAttachments(attachments, []string{"d", "missing", "b", "d"})
+	assert.Equal(t, []string{"d", "b", "a", "old.pdf"}, attachmentIDs(ordered))
+}
+
+// TestValidateAttachmentOrder tests that a reorder must list every attachment once
+func TestValidateAttachmentOrder(t *testing.T) {
+	attachments := []models.Attachment{{ID: "a"}, {ID: "b"}, {FileURL: "/uploads/achievements/old.pdf"}}
+
+	assert.NoError(t, validateAttachmentOrder(attachments, []string{"old.pdf", "b", "a"}))
+	assert.Error(t, validateAttachmentOrder(attachments, []string{"b", "a"}))
+	assert.Error(t, validateAttachmentOrder(attachments, []string{"b", "a", "a"}))
+	assert.Error(t, validateAttachmentOrder(attachments, []string{"b", "a", "c"}))
+	assert.NoError(t, validateAttachmentOrder(nil, nil))
+}
+
+// TestDispatchBlobDelete tests that an applied entry deletes the files it no longer references
+func TestDispatchBlobDelete(t *testing.T) {
+	ctx := context.Background()
+	blobs := &storage.LocalStore{Root: t.TempDir()}
+	assert.NoError(t, blobs.Put(ctx, "achievements/a1/f1.pdf", strings.NewReader("old"), 3, "application/pdf"))
+
+	o := &achievementOutbox{blobs: blobs}
+	entry := &models.OutboxEntry{AchievementID: "a1", Events: []models.OutboxEvent{outboxBlobDelete("achievements/a1/f1.pdf")}}
+	o.dispatch(entry)
+	_, err := blobs.Stat(ctx, "achievements/a1/f1.pdf")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	// Applying the event again finds nothing to delete
+	o.dispatch(entry)
+}
+
+func attachmentIDs(attachments []models.Attachment) []string {
+	ids := make([]string, 0, len(attachments))
+	for i := range attachments {
+		ids = append(ids, attachments[i].AttachmentID())
+	}
+	return ids
+}
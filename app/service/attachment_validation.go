@@ -0,0 +1,502 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/image/webp"
+
+	"UAS/preview"
+)
+
+// Attachment content types accepted for upload, detected from the file content
+const (
+	attachmentPDF  = "application/pdf"
+	attachmentJPEG = "image/jpeg"
+	attachmentPNG  = "image/png"
+	attachmentWebP = "image/webp"
+	attachmentMP4  = "video/mp4"
+	attachmentMOV  = "video/quicktime"
+)
+
+// attachmentExtensions lists the file extensions accepted for each type; the first is used when a name has none
+var attachmentExtensions = map[string][]string{
+	attachmentPDF:  {".pdf"},
+	attachmentJPEG: {".jpg", ".jpeg"},
+	attachmentPNG:  {".png"},
+	attachmentWebP: {".webp"},
+	attachmentMP4:  {".mp4", ".m4v"},
+	attachmentMOV:  {".mov"},
+}
+
+// attachmentSizeLimits caps the size of each type. Single-request uploads are further limited to
+// maxAttachmentSize; larger files go through the resumable upload endpoint
+var attachmentSizeLimits = map[string]int64{
+	attachmentPDF:  50 << 20,
+	attachmentJPEG: 20 << 20,
+	attachmentPNG:  20 << 20,
+	attachmentWebP: 20 << 20,
+	attachmentMP4:  500 << 20,
+	attachmentMOV:  500 << 20,
+}
+
+// maxAttachmentUploadSize is the largest size limit of any type
+const maxAttachmentUploadSize = 500 << 20
+
+// mp4Brands are the ftyp major brands accepted as MP4 video. Other ISO media files such as HEIC
+// photos, AVIF images and M4A audio share the container but are not accepted
+var mp4Brands = []string{"isom", "iso2", "iso4", "iso5", "iso6", "mp41", "mp42", "avc1", "M4V ", "MSNV"}
+
+const (
+	// maxAttachmentNameLength caps the stored file name, extension excluded
+	maxAttachmentNameLength = 100
+	// maxImagePixels rejects images whose declared size would take too much memory to process; the
+	// preview worker refuses to decode anything larger
+	maxImagePixels = preview.MaxImagePixels
+)
+
+// errAttachmentType marks uploads rejected because of their type rather than their content
+var errAttachmentType = errors.New("unsupported file type")
+
+var pdfHeader = regexp.MustCompile(`^%PDF-[12]\.[0-9]`)
+
+// inspectedAttachment is an upload that passed validation, with metadata removed
+type inspectedAttachment struct {
+	ContentType string
+	FileName    string
+	Data        []byte
+}
+
+// errInvalidVideo marks video files whose container structure is broken
+var errInvalidVideo = errors.New("malformed video")
+
+// isSupportedAttachmentType reports whether uploads of a content type can be accepted at all
+func isSupportedAttachmentType(contentType string) bool {
+	_, ok := attachmentExtensions[contentType]
+	return ok
+}
+
+// defaultAttachmentTypes are accepted by achievement types without allowed_attachment_types.
+// Video is opt-in: a type accepts MP4 or MOV only when its list names them
+var defaultAttachmentTypes = []string{attachmentPDF, attachmentJPEG, attachmentPNG, attachmentWebP}
+
+// attachmentTypeAllowed reports whether an achievement type accepts a content type; an empty list accepts defaultAttachmentTypes
+func attachmentTypeAllowed(allowed []string, contentType string) bool {
+	if len(allowed) == 0 {
+		allowed = defaultAttachmentTypes
+	}
+	for _, t := range allowed {
+		if t == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// inspectAttachment detects the real type of an upload from its content, checks it against the
+// allowlist, validates its structure and strips image metadata. The client Content-Type and file
+// name extension are ignored
+func inspectAttachment(fileName string, data []byte, allowed []string) (*inspectedAttachment, error) {
+	contentType := sniffAttachmentType(data)
+	if contentType == "" {
+		return nil, fmt.Errorf("%w: only PDF, JPEG, PNG, WebP, MP4 and MOV files are accepted", errAttachmentType)
+	}
+	if !attachmentTypeAllowed(allowed, contentType) {
+		return nil, fmt.Errorf("%w: %s files are not accepted for this achievement type", errAttachmentType, contentType)
+	}
+
+	var clean []byte
+	var err error
+	switch contentType {
+	case attachmentPDF:
+		clean, err = data, validatePDF(data)
+	case attachmentJPEG:
+		if clean, err = stripJPEGMetadata(data); err == nil {
+			err = checkImageConfig(jpeg.DecodeConfig, clean)
+		}
+	case attachmentPNG:
+		if clean, err = stripPNGMetadata(data); err == nil {
+			err = checkImageConfig(png.DecodeConfig, clean)
+		}
+	case attachmentWebP:
+		if clean, err = stripWebPMetadata(data); err == nil {
+			err = checkImageConfig(webp.DecodeConfig, clean)
+		}
+	case attachmentMP4, attachmentMOV:
+		_, err = io.Copy(io.Discard, newVideoValidator(bytes.NewReader(data), int64(len(data))))
+		clean = data
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &inspectedAttachment{
+		ContentType: contentType,
+		FileName:    sanitizeAttachmentName(fileName, contentType),
+		Data:        clean,
+	}, nil
+}
+
+// sniffAttachmentType detects a supported type from the magic bytes, empty when unknown
+func sniffAttachmentType(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		return attachmentPDF
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return attachmentJPEG
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return attachmentPNG
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return attachmentWebP
+	case len(data) >= 12 && string(data[4:8]) == "ftyp":
+		if brand := string(data[8:12]); brand == "qt  " {
+			return attachmentMOV
+		} else if containsString(mp4Brands, brand) {
+			return attachmentMP4
+		}
+	}
+	return ""
+}
+
+// isVideoAttachment reports whether a type is a video; videos are validated and stored while they
+// are read instead of in memory
+func isVideoAttachment(contentType string) bool {
+	return contentType == attachmentMP4 || contentType == attachmentMOV
+}
+
+// attachmentSizeLimit returns the largest accepted size of a type
+func attachmentSizeLimit(contentType string) int64 {
+	if limit, ok := attachmentSizeLimits[contentType]; ok {
+		return limit
+	}
+	return maxAttachmentSize
+}
+
+// declaredAttachmentType is the type a client announces for a file: its MIME type when supported,
+// otherwise the type of its extension. The content decides the real type once the file is complete
+func declaredAttachmentType(fileName, fileType string) string {
+	if isSupportedAttachmentType(fileType) {
+		return fileType
+	}
+	ext := strings.ToLower(path.Ext(fileName))
+	for contentType, extensions := range attachmentExtensions {
+		if containsString(extensions, ext) {
+			return contentType
+		}
+	}
+	return ""
+}
+
+// videoValidator checks the top-level box structure of an MP4/QuickTime file as it is read: the
+// file starts with ftyp, every box fits in the file, the boxes end exactly at the end of the file
+// and a moov box is present. A broken file makes Read fail, which aborts storing it. The content
+// of the boxes, including metadata, is left as it is
+type videoValidator struct {
+	r      io.Reader
+	size   int64  // declared file size
+	pos    int64  // bytes read so far
+	start  int64  // offset of the box header being read
+	end    int64  // end of the current box
+	header []byte // partially read box header
+	boxes  int
+	moov   bool
+	err    error
+}
+
+func newVideoValidator(r io.Reader, size int64) *videoValidator {
+	return &videoValidator{r: r, size: size, header: make([]byte, 0, 16)}
+}
+
+func (v *videoValidator) Read(p []byte) (int, error) {
+	if v.err != nil {
+		return 0, v.err
+	}
+	n, err := v.r.Read(p)
+	if serr := v.scan(p[:n]); serr != nil {
+		v.err = fmt.Errorf("%w: %s", errInvalidVideo, serr.Error())
+		return n, v.err
+	}
+	if errors.Is(err, io.EOF) {
+		if ferr := v.finish(); ferr != nil {
+			v.err = fmt.Errorf("%w: %s", errInvalidVideo, ferr.Error())
+			return n, v.err
+		}
+	}
+	return n, err
+}
+
+func (v *videoValidator) scan(b []byte) error {
+	if v.pos+int64(len(b)) > v.size {
+		return errors.New("the file is longer than declared")
+	}
+	for len(b) > 0 {
+		if v.pos < v.end {
+			skip := int(min(int64(len(b)), v.end-v.pos))
+			v.pos += int64(skip)
+			b = b[skip:]
+			continue
+		}
+
+		if len(v.header) == 0 {
+			v.start = v.pos
+		}
+		need := 8
+		if len(v.header) >= 8 && binary.BigEndian.Uint32(v.header) == 1 {
+			need = 16 // 64-bit box size follows the type
+		}
+		take := min(need-len(v.header), len(b))
+		v.header = append(v.header, b[:take]...)
+		v.pos += int64(take)
+		b = b[take:]
+		if len(v.header) < need || (need == 8 && binary.BigEndian.Uint32(v.header) == 1) {
+			continue
+		}
+
+		boxSize := int64(binary.BigEndian.Uint32(v.header))
+		if boxSize == 1 {
+			large := binary.BigEndian.Uint64(v.header[8:16])
+			if large > uint64(v.size) {
+				return errors.New("a box extends past the end of the file")
+			}
+			boxSize = int64(large)
+		} else if boxSize == 0 {
+			boxSize = v.size - v.start // the last box may extend to the end of the file
+		}
+		boxType := string(v.header[4:8])
+		if v.boxes == 0 && boxType != "ftyp" {
+			return errors.New("the file does not start with a file type box")
+		}
+		if boxSize < int64(need) || v.start+boxSize > v.size {
+			return errors.New("a box extends past the end of the file")
+		}
+		if boxType == "moov" {
+			v.moov = true
+		}
+		v.boxes++
+		v.end = v.start + boxSize
+		v.header = v.header[:0]
+	}
+	return nil
+}
+
+func (v *videoValidator) finish() error {
+	if v.pos != v.size || v.pos < v.end || len(v.header) > 0 {
+		return errors.New("the file is truncated")
+	}
+	if !v.moov {
+		return errors.New("the file has no movie header")
+	}
+	return nil
+}
+
+// validatePDF rejects truncated, malformed and encrypted PDFs. Encrypted files cannot be checked
+// by reviewers or previewed, so they are refused rather than stored
+func validatePDF(data []byte) error {
+	if !pdfHeader.Match(data) {
+		return errors.New("malformed PDF: invalid header")
+	}
+	tail := data
+	if len(tail) > 1024 {
+		tail = tail[len(tail)-1024:]
+	}
+	if !bytes.Contains(tail, []byte("%%EOF")) || !bytes.Contains(data, []byte("startxref")) {
+		return errors.New("malformed PDF: the file is truncated or damaged")
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return errors.New("encrypted or password-protected PDFs are not accepted")
+	}
+	return nil
+}
+
+// checkImageConfig validates an image header and rejects oversized dimensions
+func checkImageConfig(decode func(r io.Reader) (image.Config, error), data []byte) error {
+	cfg, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return errors.New("malformed image: " + err.Error())
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return errors.New("image dimensions are too large")
+	}
+	return nil
+}
+
+// stripJPEGMetadata removes EXIF (including GPS), XMP, IPTC and comment segments.
+// JFIF, ICC colour profiles and Adobe segments are kept because they affect how the image renders
+func stripJPEGMetadata(data []byte) ([]byte, error) {
+	malformed := errors.New("malformed JPEG")
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+
+	for i := 2; ; {
+		if i+4 > len(data) || data[i] != 0xFF {
+			return nil, malformed
+		}
+		marker := data[i+1]
+		if marker == 0xFF { // fill byte
+			i++
+			continue
+		}
+		if marker == 0xD8 || marker == 0xD9 || (marker >= 0xD0 && marker <= 0xD7) {
+			return nil, malformed
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return nil, malformed
+		}
+
+		// Start of scan: the compressed image data follows up to the end marker
+		if marker == 0xDA {
+			if !bytes.Contains(data[i+2+length:], []byte{0xFF, 0xD9}) {
+				return nil, malformed
+			}
+			out.Write(data[i:])
+			return out.Bytes(), nil
+		}
+
+		switch {
+		case marker == 0xFE: // comment
+		case marker >= 0xE0 && marker <= 0xEF && marker != 0xE0 && marker != 0xE2 && marker != 0xEE:
+			// APP1 EXIF/XMP, APP13 IPTC and other application metadata
+		default:
+			out.Write(data[i : i+2+length])
+		}
+		i += 2 + length
+	}
+}
+
+// stripPNGMetadata removes text, EXIF and timestamp chunks, checking every chunk CRC
+func stripPNGMetadata(data []byte) ([]byte, error) {
+	malformed := errors.New("malformed PNG")
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:8])
+
+	for i := 8; i+12 <= len(data); {
+		n := int(binary.BigEndian.Uint32(data[i:]))
+		if n < 0 || n > len(data)-i-12 {
+			return nil, malformed
+		}
+		chunkType := string(data[i+4 : i+8])
+		if crc32.ChecksumIEEE(data[i+4:i+8+n]) != binary.BigEndian.Uint32(data[i+8+n:]) {
+			return nil, malformed
+		}
+		switch chunkType {
+		case "tEXt", "zTXt", "iTXt", "eXIf", "tIME":
+		default:
+			out.Write(data[i : i+12+n])
+		}
+		i += 12 + n
+		if chunkType == "IEND" {
+			return out.Bytes(), nil
+		}
+	}
+	return nil, malformed
+}
+
+// stripWebPMetadata removes the EXIF and XMP chunks of a WebP file and clears their VP8X flags
+func stripWebPMetadata(data []byte) ([]byte, error) {
+	malformed := errors.New("malformed WebP")
+	size := int(binary.LittleEndian.Uint32(data[4:8]))
+	if size < 4 || size > len(data)-8 {
+		return nil, malformed
+	}
+	body := data[12 : 8+size]
+
+	var chunks bytes.Buffer
+	hasImage := false
+	for i := 0; i < len(body); {
+		if i+8 > len(body) {
+			return nil, malformed
+		}
+		fourCC := string(body[i : i+4])
+		n := int(binary.LittleEndian.Uint32(body[i+4:]))
+		if n < 0 || n > len(body)-i-8 {
+			return nil, malformed
+		}
+		end := i + 8 + n + n&1 // chunks are padded to an even size
+		if end > len(body) {
+			end = len(body)
+		}
+
+		switch fourCC {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte(nil), body[i:end]...)
+			if n > 0 {
+				chunk[8] &^= 0x0C // EXIF and XMP flags
+			}
+			chunks.Write(chunk)
+		default:
+			if fourCC == "VP8 " || fourCC == "VP8L" || fourCC == "ANMF" {
+				hasImage = true
+			}
+			chunks.Write(body[i:end])
+		}
+		i = end
+	}
+	if !hasImage {
+		return nil, malformed
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, chunks.Len()+12))
+	out.WriteString("RIFF")
+	binary.Write(out, binary.LittleEndian, uint32(chunks.Len()+4))
+	out.WriteString("WEBP")
+	out.Write(chunks.Bytes())
+	return out.Bytes(), nil
+}
+
+// sanitizeAttachmentName turns a client file name into a safe display name: directories are
+// dropped, unusual characters replaced, the length capped and the extension made to match the type
+func sanitizeAttachmentName(fileName, contentType string) string {
+	// Some clients send the full local path
+	fileName = fileName[strings.LastIndexAny(fileName, `/\`)+1:]
+
+	var b strings.Builder
+	for _, r := range fileName {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), strings.ContainsRune("._-()", r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune(' ')
+		case unicode.IsControl(r):
+		default:
+			b.WriteRune('_')
+		}
+	}
+	name := strings.Join(strings.Fields(b.String()), " ")
+
+	ext := strings.ToLower(path.Ext(name))
+	base := strings.Trim(strings.TrimSuffix(name, path.Ext(name)), " .")
+	exts := attachmentExtensions[contentType]
+	if !containsString(exts, ext) {
+		ext = exts[0]
+	}
+
+	if runes := []rune(base); len(runes) > maxAttachmentNameLength {
+		base = strings.TrimSpace(string(runes[:maxAttachmentNameLength]))
+	}
+	if base == "" {
+		base = "attachment"
+	}
+	return base + ext
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPDF = "%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\ntrailer << /Root 1 0 R >>\nstartxref\n9\n%%EOF\n"
+
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(1, 1, color.RGBA{R: 255, A: 255})
+	return img
+}
+
+// testJPEGWithEXIF returns a JPEG carrying an EXIF segment with a GPS marker and a comment
+func testJPEGWithEXIF(t *testing.T) []byte {
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, testImage(), nil))
+	data := buf.Bytes()
+
+	exif := append([]byte("Exif\x00\x00"), []byte("GPSLatitude -6.2")...)
+	app1 := append([]byte{0xFF, 0xE1, 0, byte(len(exif) + 2)}, exif...)
+	comment := append([]byte{0xFF, 0xFE, 0, 9}, []byte("secret!")...)
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+	out = append(out, comment...)
+	return append(out, data[2:]...)
+}
+
+func pngChunk(chunkType string, data []byte) []byte {
+	chunk := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(chunk, uint32(len(data)))
+	copy(chunk[4:], chunkType)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+// testPNGWithText returns a PNG carrying a tEXt chunk after the header
+func testPNGWithText(t *testing.T) []byte {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, testImage()))
+	data := buf.Bytes()
+	ihdrEnd := 8 + 12 + 13
+	out := append([]byte{}, data[:ihdrEnd]...)
+	out = append(out, pngChunk("tEXt", []byte("Comment\x00GPS -6.2"))...)
+	return append(out, data[ihdrEnd:]...)
+}
+
+func webpChunk(fourCC string, data []byte) []byte {
+	chunk := append([]byte(fourCC), binary.LittleEndian.AppendUint32(nil, uint32(len(data)))...)
+	chunk = append(chunk, data...)
+	if len(data)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// testWebPWithEXIF returns an extended WebP container with EXIF and XMP chunks
+func testWebPWithEXIF() []byte {
+	var body []byte
+	body = append(body, webpChunk("VP8X", []byte{0x0C, 0, 0, 0, 3, 0, 0, 3, 0, 0})...)
+	body = append(body, webpChunk("VP8L", []byte{0x2F, 3, 0xC0, 0, 0})...)
+	body = append(body, webpChunk("EXIF", []byte("GPS -6.2"))...)
+	body = append(body, webpChunk("XMP ", []byte("<x/>"))...)
+	out := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)+4))...)
+	out = append(out, "WEBP"...)
+	return append(out, body...)
+}
+
+// TestSniffAttachmentType tests detection from magic bytes
+func TestSniffAttachmentType(t *testing.T) {
+	assert.Equal(t, attachmentPDF, sniffAttachmentType([]byte(testPDF)))
+	assert.Equal(t, attachmentJPEG, sniffAttachmentType(testJPEGWithEXIF(t)))
+	assert.Equal(t, attachmentPNG, sniffAttachmentType(testPNGWithText(t)))
+	assert.Equal(t, attachmentWebP, sniffAttachmentType(testWebPWithEXIF()))
+	assert.Equal(t, "", sniffAttachmentType([]byte("<html><script>alert(1)</script>")))
+	assert.Equal(t, "", sniffAttachmentType([]byte("MZ\x90\x00")))
+}
+
+// TestInspectAttachmentAllowlist tests the per achievement type allowlist and unknown content
+func TestInspectAttachmentAllowlist(t *testing.T) {
+	_, err := inspectAttachment("cert.pdf", []byte("<html></html>"), nil)
+	assert.ErrorIs(t, err, errAttachmentType)
+
+	_, err = inspectAttachment("photo.png", testPNGWithText(t), []string{attachmentPDF})
+	assert.ErrorIs(t, err, errAttachmentType)
+
+	upload, err := inspectAttachment("cert.png", []byte(testPDF), []string{attachmentPDF})
+	assert.NoError(t, err)
+	assert.Equal(t, attachmentPDF, upload.ContentType)
+	assert.Equal(t, "cert.pdf", upload.FileName)
+}
+
+// TestValidatePDF tests rejection of malformed, truncated and encrypted PDFs
+func TestValidatePDF(t *testing.T) {
+	assert.NoError(t, validatePDF([]byte(testPDF)))
+	assert.Error(t, validatePDF([]byte("%PDF-x")))
+	assert.Error(t, validatePDF([]byte(testPDF[:40])))
+	encrypted := "%PDF-1.7\ntrailer << /Root 1 0 R /Encrypt 5 0 R >>\nstartxref\n9\n%%EOF"
+	assert.EqualError(t, validatePDF([]byte(encrypted)), "encrypted or password-protected PDFs are not accepted")
+}
+
+// TestStripJPEGMetadata tests that EXIF and comments are removed and the image still decodes
+func TestStripJPEGMetadata(t *testing.T) {
+	upload, err := inspectAttachment("photo.jpg", testJPEGWithEXIF(t), nil)
+	assert.NoError(t, err)
+	assert.False(t, bytes.Contains(upload.Data, []byte("Exif")))
+	assert.False(t, bytes.Contains(upload.Data, []byte("GPSLatitude")))
+	assert.False(t, bytes.Contains(upload.Data, []byte("secret!")))
+	_, err = jpeg.Decode(bytes.NewReader(upload.Data))
+	assert.NoError(t, err)
+
+	truncated := testJPEGWithEXIF(t)
+	_, err = stripJPEGMetadata(truncated[:len(truncated)-300])
+	assert.Error(t, err)
+}
+
+// TestStripPNGMetadata tests that text chunks are removed and corrupt chunks rejected
+func TestStripPNGMetadata(t *testing.T) {
+	data := testPNGWithText(t)
+	upload, err := inspectAttachment("scan.PNG", data, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "scan.png", upload.FileName)
+	assert.False(t, bytes.Contains(upload.Data, []byte("tEXt")))
+	_, err = png.Decode(bytes.NewReader(upload.Data))
+	assert.NoError(t, err)
+
+	corrupt := append([]byte{}, data...)
+	corrupt[40] ^= 0xFF
+	_, err = stripPNGMetadata(corrupt)
+	assert.Error(t, err)
+}
+
+// TestStripWebPMetadata tests that EXIF and XMP chunks and their flags are removed
+func TestStripWebPMetadata(t *testing.T) {
+	clean, err := stripWebPMetadata(testWebPWithEXIF())
+	assert.NoError(t, err)
+	assert.False(t, bytes.Contains(clean, []byte("EXIF")))
+	assert.False(t, bytes.Contains(clean, []byte("XMP ")))
+	assert.Equal(t, byte(0), clean[20]&0x0C)
+	assert.Equal(t, uint32(len(clean)-8), binary.LittleEndian.Uint32(clean[4:8]))
+
+	_, err = stripWebPMetadata([]byte("RIFF\xff\x00\x00\x00WEBPVP8L"))
+	assert.Error(t, err)
+}
+
+// TestInspectWebPDimensions tests that WebP images are held to the same pixel limit as JPEG and PNG
+func TestInspectWebPDimensions(t *testing.T) {
+	upload, err := inspectAttachment("photo.webp", testWebPWithEXIF(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, attachmentWebP, upload.ContentType)
+
+	// Canvas declared as 20000x20000 in the VP8X chunk
+	huge := testWebPWithEXIF()
+	copy(huge[24:30], []byte{0x1F, 0x4E, 0, 0x1F, 0x4E, 0})
+	_, err = inspectAttachment("huge.webp", huge, nil)
+	assert.EqualError(t, err, "image dimensions are too large")
+}
+
+// TestSanitizeAttachmentName tests path stripping, character replacement and extension fixing
+func TestSanitizeAttachmentName(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contentType string
+		expected    string
+	}{
+		{"../../etc/passwd", attachmentPDF, "passwd.pdf"},
+		{`C:\Users\budi\Sertifikat Lomba.PDF`, attachmentPDF, "Sertifikat Lomba.pdf"},
+		{"foto<script>.jpeg", attachmentJPEG, "foto_script_.jpeg"},
+		{"invoice.pdf.exe", attachmentPDF, "invoice.pdf.pdf"},
+		{"...", attachmentPNG, "attachment.png"},
+		{"a\x00b\tc.webp", attachmentWebP, "ab c.webp"},
+		{"piagam  juara\n1.jpg", attachmentJPEG, "piagam juara 1.jpg"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, sanitizeAttachmentName(tc.name, tc.contentType), tc.name)
+	}
+
+	long := sanitizeAttachmentName(string(bytes.Repeat([]byte("a"), 300))+".pdf", attachmentPDF)
+	assert.Equal(t, maxAttachmentNameLength+4, len(long))
+}
+
+func mp4Box(boxType string, data []byte) []byte {
+	box := binary.BigEndian.AppendUint32(nil, uint32(8+len(data)))
+	return append(append(box, boxType...), data...)
+}
+
+func testMP4(brand string) []byte {
+	ftyp := mp4Box("ftyp", []byte(brand+"\x00\x00\x02\x00isommp41"))
+	return append(append(ftyp, mp4Box("moov", make([]byte, 32))...), mp4Box("mdat", make([]byte, 1000))...)
+}
+
+func validateVideo(data []byte, size int64) error {
+	_, err := io.Copy(io.Discard, newVideoValidator(bytes.NewReader(data), size))
+	return err
+}
+
+// TestSniffVideoAttachment tests detection of MP4 and QuickTime files by their ftyp brand
+func TestSniffVideoAttachment(t *testing.T) {
+	assert.Equal(t, attachmentMP4, sniffAttachmentType(testMP4("isom")))
+	assert.Equal(t, attachmentMP4, sniffAttachmentType(testMP4("mp42")))
+	assert.Equal(t, attachmentMOV, sniffAttachmentType(testMP4("qt  ")))
+	// HEIC photos and M4A audio share the container
+	assert.Equal(t, "", sniffAttachmentType(testMP4("heic")))
+	assert.Equal(t, "", sniffAttachmentType(testMP4("M4A ")))
+
+	// Video is only accepted by types that opt in
+	_, err := inspectAttachment("lomba.mp4", testMP4("isom"), nil)
+	assert.ErrorIs(t, err, errAttachmentType)
+	upload, err := inspectAttachment("lomba.mp4", testMP4("isom"), []string{attachmentPDF, attachmentMP4})
+	assert.NoError(t, err)
+	assert.Equal(t, attachmentMP4, upload.ContentType)
+}
+
+// TestVideoValidator tests the box structure checks done while a video is streamed
+func TestVideoValidator(t *testing.T) {
+	video := testMP4("isom")
+	assert.NoError(t, validateVideo(video, int64(len(video))))
+
+	// Read in small pieces, headers split across reads
+	_, err := io.Copy(io.Discard, newVideoValidator(iotest.OneByteReader(bytes.NewReader(video)), int64(len(video))))
+	assert.NoError(t, err)
+
+	// 64-bit box size and a last box extending to the end of the file
+	large := append(binary.BigEndian.AppendUint32(nil, 1), "mdat"...)
+	large = append(binary.BigEndian.AppendUint64(large, 16+100), make([]byte, 100)...)
+	toEnd := append(binary.BigEndian.AppendUint32(nil, 0), "mdat"...)
+	toEnd = append(toEnd, make([]byte, 50)...)
+	for _, tail := range [][]byte{large, toEnd} {
+		file := append(append(mp4Box("ftyp", []byte("isom\x00\x00\x00\x00")), mp4Box("moov", nil)...), tail...)
+		assert.NoError(t, validateVideo(file, int64(len(file))))
+	}
+
+	err = validateVideo(video[:len(video)-10], int64(len(video)))
+	assert.ErrorIs(t, err, errInvalidVideo)
+	assert.ErrorContains(t, err, "truncated")
+	assert.ErrorContains(t, validateVideo(video, int64(len(video))-10), "longer than declared")
+
+	noMoov := append(mp4Box("ftyp", []byte("isom\x00\x00\x00\x00")), mp4Box("mdat", make([]byte, 10))...)
+	assert.ErrorContains(t, validateVideo(noMoov, int64(len(noMoov))), "no movie header")
+
+	noFtyp := append(mp4Box("moov", nil), mp4Box("mdat", nil)...)
+	assert.ErrorContains(t, validateVideo(noFtyp, int64(len(noFtyp))), "file type box")
+
+	// A box claiming more than the file holds
+	overlong := append(mp4Box("ftyp", []byte("isom\x00\x00\x00\x00")), binary.BigEndian.AppendUint32(nil, 1<<30)...)
+	overlong = append(overlong, "mdat"...)
+	assert.ErrorContains(t, validateVideo(overlong, int64(len(overlong))), "past the end")
+}
+
+// TestDeclaredAttachmentType tests the type taken from a client's MIME type or file extension
+func TestDeclaredAttachmentType(t *testing.T) {
+	assert.Equal(t, attachmentMP4, declaredAttachmentType("lomba.mp4", ""))
+	assert.Equal(t, attachmentMOV, declaredAttachmentType("LOMBA.MOV", "application/octet-stream"))
+	assert.Equal(t, attachmentPDF, declaredAttachmentType("sertifikat", "application/pdf"))
+	assert.Equal(t, attachmentJPEG, declaredAttachmentType("foto.jpeg", ""))
+	assert.Equal(t, "", declaredAttachmentType("setup.exe", "application/x-msdownload"))
+}
+
+// TestAttachmentSizeLimit tests the per-type size limits
+func TestAttachmentSizeLimit(t *testing.T) {
+	assert.Equal(t, int64(50<<20), attachmentSizeLimit(attachmentPDF))
+	assert.Equal(t, int64(20<<20), attachmentSizeLimit(attachmentPNG))
+	assert.Equal(t, int64(500<<20), attachmentSizeLimit(attachmentMP4))
+	assert.Equal(t, int64(maxAttachmentSize), attachmentSizeLimit("application/zip"))
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+	"UAS/queue"
+	"UAS/scheduler"
+)
+
+// certificationReminderJob is the queue job type that reminds a student of an expiring certification
+const certificationReminderJob = "certification-expiry-reminder"
+
+// errInvalidValidUntil is returned when valid_until is not a recognizable date
+var errInvalidValidUntil = errors.New("valid_until must be a date such as 2027-06-30, 30/06/2027 or 30 Juni 2027")
+
+// noExpiryValues are valid_until values meaning the certification does not expire
+var noExpiryValues = map[string]bool{
+	"": true, "-": true, "n/a": true, "na": true, "none": true,
+	"lifetime": true, "permanent": true, "no expiry": true, "no expiration": true,
+	"seumur hidup": true, "selamanya": true, "permanen": true, "tidak ada": true, "tidak berlaku": true,
+}
+
+// indonesianMonths maps Indonesian month names to English ones understood by time.Parse
+var indonesianMonths = strings.NewReplacer(
+	"januari", "january", "februari", "february", "maret", "march", "mei", "may",
+	"juni", "june", "juli", "july", "agustus", "august", "oktober", "october",
+	"desember", "december", "agu", "aug", "agt", "aug", "okt", "oct", "des", "dec",
+)
+
+// dayLayouts are full date formats, day before month for numeric dates as used in Indonesia
+var dayLayouts = []string{
+	"2006-01-02", "2006/01/02", "02/01/2006", "2/1/2006", "02-01-2006", "2-1-2006", "02.01.2006",
+	"2 January 2006", "02 January 2006", "January 2, 2006", "January 2 2006",
+	"2 Jan 2006", "02 Jan 2006", "Jan 2, 2006", "Jan 2 2006",
+	time.RFC3339,
+}
+
+// monthLayouts are month precision formats; the certification is valid until the end of that month
+var monthLayouts = []string{"2006-01", "01/2006", "1/2006", "01-2006", "January 2006", "Jan 2006"}
+
+// parseValidUntil parses a free-form certification validity. hasExpiry is false for
+// values such as "lifetime" or an empty string
+func parseValidUntil(value string) (validUntil time.Time, hasExpiry bool, err error) {
+	v := strings.ToLower(strings.TrimSpace(value))
+	v = strings.TrimPrefix(v, "valid until ")
+	v = strings.TrimPrefix(v, "berlaku sampai ")
+	v = strings.TrimPrefix(v, "berlaku hingga ")
+	if noExpiryValues[v] {
+		return time.Time{}, false, nil
+	}
+
+	v = indonesianMonths.Replace(v)
+	// time.Parse month names are case sensitive; capitalize words
+	words := strings.Fields(v)
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	v = strings.Join(words, " ")
+
+	for _, layout := range dayLayouts {
+		if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+			return endOfDay(t), true, nil
+		}
+	}
+	for _, layout := range monthLayouts {
+		if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+			return endOfDay(t.AddDate(0, 1, -1)), true, nil
+		}
+	}
+	if len(v) == 4 {
+		if year, err := strconv.Atoi(v); err == nil && year > 1900 {
+			return endOfDay(time.Date(year, 12, 31, 0, 0, 0, 0, time.Local)), true, nil
+		}
+	}
+	return time.Time{}, false, errInvalidValidUntil
+}
+
+func endOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, t.Location())
+}
+
+// validUntilFromDetails parses the valid_until detail of an achievement, nil when it does not expire
+func validUntilFromDetails(details map[string]interface{}) (*time.Time, error) {
+	raw, ok := details["valid_until"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	value, ok := raw.(string)
+	if !ok {
+		return nil, errInvalidValidUntil
+	}
+	t, hasExpiry, err := parseValidUntil(value)
+	if err != nil || !hasExpiry {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// excludeExpiredPointsDefault reports whether expired certifications are left out of point totals
+// when a report request does not say otherwise (EXCLUDE_EXPIRED_CERTIFICATION_POINTS)
+func excludeExpiredPointsDefault() bool {
+	v, _ := strconv.ParseBool(os.Getenv("EXCLUDE_EXPIRED_CERTIFICATION_POINTS"))
+	return v
+}
+
+// LoadCertificationConfig applies CERTIFICATION_EXPIRY_WARNING_DAYS, the number of days before
+// expiry a certification is flagged as expiring soon and its owner is reminded
+func LoadCertificationConfig() {
+	if days, err := strconv.Atoi(os.Getenv("CERTIFICATION_EXPIRY_WARNING_DAYS")); err == nil && days > 0 {
+		models.CertificationWarningDays = days
+	}
+}
+
+// certificationReminder is the payload of a certification expiry reminder job
+type certificationReminder struct {
+	AchievementID string    `json:"achievement_id"`
+	StudentID     string    `json:"student_id"`
+	Title         string    `json:"title"`
+	ValidUntil    time.Time `json:"valid_until"`
+}
+
+// RegisterCertificationJobs registers the daily certification expiry check and its reminder handler
+func RegisterCertificationJobs(s *scheduler.Scheduler, q *queue.Queue) {
+	q.Register(certificationReminderJob, sendCertificationReminder, queue.HandlerOptions{})
+
+	s.Register(scheduler.Job{
+		Name:        "certification-expiry",
+		Schedule:    "0 7 * * *",
+		Description: "Parse certification validity dates and remind students of certifications about to expire",
+		Run: func(ctx context.Context) (string, error) {
+			return checkCertificationExpiry(ctx, q)
+		},
+	})
+}
+
+// checkCertificationExpiry backfills valid_until for achievements not parsed yet and
+// enqueues one reminder per verified certification entering its warning window
+func checkCertificationExpiry(ctx context.Context, q *queue.Queue) (string, error) {
+	pgRepo := repository.NewAchievementRepository()
+	mongoRepo := repository.NewMongoAchievementRepository()
+
+	unchecked, err := pgRepo.FindValidityUnchecked(500)
+	if err != nil {
+		return "", err
+	}
+	mongoIDs := make([]string, len(unchecked))
+	for i, a := range unchecked {
+		mongoIDs[i] = a.MongoAchievementID
+	}
+	details, err := mongoRepo.FindByIDs(ctx, mongoIDs)
+	if err != nil {
+		return "", err
+	}
+	backfilled := 0
+	for _, a := range unchecked {
+		// Unparseable legacy values are recorded as not expiring rather than retried every day
+		validUntil, _ := validUntilFromDetails(details[a.MongoAchievementID].Details)
+		if err := pgRepo.UpdateValidity(a.ID, validUntil); err == nil {
+			backfilled++
+		}
+	}
+
+	due, err := pgRepo.FindExpiringUnreminded(time.Now().AddDate(0, 0, models.CertificationWarningDays))
+	if err != nil {
+		return "", err
+	}
+	dueMongoIDs := make([]string, len(due))
+	for i, a := range due {
+		dueMongoIDs[i] = a.MongoAchievementID
+	}
+	dueDetails, _ := mongoRepo.FindByIDs(ctx, dueMongoIDs)
+
+	reminded := 0
+	for _, a := range due {
+		_, err := q.Enqueue(certificationReminderJob, certificationReminder{
+			AchievementID: a.ID,
+			StudentID:     a.StudentID,
+			Title:         dueDetails[a.MongoAchievementID].Title,
+			ValidUntil:    *a.ValidUntil,
+		})
+		if err != nil {
+			log.Println("Failed to enqueue certification reminder for "+a.ID+":", err)
+			continue
+		}
+		if err := pgRepo.MarkExpiryReminded(a.ID); err == nil {
+			reminded++
+		}
+	}
+
+	return fmt.Sprintf("%d validity dates parsed, %d reminders queued", backfilled, reminded), nil
+}
+
+// sendCertificationReminder tells a student that a certification is about to expire
+func sendCertificationReminder(ctx context.Context, job *models.QueueJob) error {
+	var reminder certificationReminder
+	if err := queue.Decode(job, &reminder); err != nil {
+		return err
+	}
+	newNotifier().notify(models.NotificationCertificationExpiring, []string{reminder.StudentID},
+		"Certification expiring soon",
+		fmt.Sprintf("Your certification %q expires on %s.", reminder.Title, reminder.ValidUntil.Format("2006-01-02")),
+		map[string]interface{}{"achievement_id": reminder.AchievementID, "valid_until": reminder.ValidUntil})
+	return nil
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"UAS/app/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseValidUntil tests parsing of free-form certification validity dates
+func TestParseValidUntil(t *testing.T) {
+	testCases := []struct {
+		name          string
+		value         string
+		expectedDate  string
+		expectExpiry  bool
+		expectedError bool
+	}{
+		{name: "ISO date", value: "2027-06-30", expectedDate: "2027-06-30", expectExpiry: true},
+		{name: "Day first numeric date", value: "05/06/2027", expectedDate: "2027-06-05", expectExpiry: true},
+		{name: "Dashed numeric date", value: "5-6-2027", expectedDate: "2027-06-05", expectExpiry: true},
+		{name: "Indonesian month name", value: "30 Juni 2027", expectedDate: "2027-06-30", expectExpiry: true},
+		{name: "Indonesian abbreviation", value: "1 Okt 2027", expectedDate: "2027-10-01", expectExpiry: true},
+		{name: "English month name", value: "December 31, 2027", expectedDate: "2027-12-31", expectExpiry: true},
+		{name: "Prefixed value", value: "Berlaku sampai 17 Agustus 2027", expectedDate: "2027-08-17", expectExpiry: true},
+		{name: "Month precision", value: "02/2028", expectedDate: "2028-02-29", expectExpiry: true},
+		{name: "Year precision", value: "2029", expectedDate: "2029-12-31", expectExpiry: true},
+		{name: "Lifetime", value: "Seumur Hidup", expectExpiry: false},
+		{name: "Empty", value: "", expectExpiry: false},
+		{name: "Unrecognized", value: "next year", expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			validUntil, hasExpiry, err := parseValidUntil(tc.value)
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectExpiry, hasExpiry)
+			if tc.expectExpiry {
+				assert.Equal(t, tc.expectedDate, validUntil.Format("2006-01-02"))
+				assert.Equal(t, 23, validUntil.Hour())
+			}
+		})
+	}
+}
+
+// TestCertificationValidity tests the validity status relative to the warning window
+func TestCertificationValidity(t *testing.T) {
+	now := time.Date(2027, 1, 1, 12, 0, 0, 0, time.UTC)
+	date := func(days int) *time.Time {
+		d := now.AddDate(0, 0, days)
+		return &d
+	}
+
+	testCases := []struct {
+		name       string
+		validUntil *time.Time
+		expected   string
+	}{
+		{name: "No expiry", validUntil: nil, expected: ""},
+		{name: "Far in the future", validUntil: date(90), expected: models.CertificationValid},
+		{name: "Inside warning window", validUntil: date(10), expected: models.CertificationExpiringSoon},
+		{name: "Already expired", validUntil: date(-1), expected: models.CertificationExpired},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, models.CertificationValidity(tc.validUntil, now, 30))
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+)
+
+// duplicateThreshold is the minimum similarity for an achievement to be flagged as a potential duplicate
+const duplicateThreshold = 0.6
+
+// duplicateCandidateLimit caps how many candidate documents are scored per submission
+const duplicateCandidateLimit = 50
+
+// Similarity weights. Identical certificate numbers or attachment files are conclusive on their own,
+// the remaining signals only add up to a flag when several of them match
+const (
+	weightCertificationNumber = 1.0
+	weightAttachmentHash      = 1.0
+	weightTitle               = 0.5
+	weightSimilarTitle        = 0.4
+	weightEventDate           = 0.25
+	weightOrganizer           = 0.25
+)
+
+// similarTitleRatio is the minimum word overlap for two different titles to count as similar
+const similarTitleRatio = 0.8
+
+// duplicateDetector looks for previously submitted achievements that resemble a new submission
+type duplicateDetector struct {
+	pgRepo    *repository.AchievementRepository
+	mongoRepo *repository.MongoAchievementRepository
+	flagRepo  *repository.DuplicateFlagRepository
+}
+
+func newDuplicateDetector() *duplicateDetector {
+	return &duplicateDetector{
+		pgRepo:    repository.NewAchievementRepository(),
+		mongoRepo: repository.NewMongoAchievementRepository(),
+		flagRepo:  repository.NewDuplicateFlagRepository(),
+	}
+}
+
+// detect scores candidate achievements against a submission and replaces its open flags
+// Only submitted and verified achievements are considered; drafts and rejected ones are still in flux
+func (d *duplicateDetector) detect(ctx context.Context, achievement *models.AchievementReference, mongoAch *models.MongoAchievement) ([]models.AchievementDuplicateFlag, error) {
+	if mongoAch.NormalizedTitle == "" {
+		mongoAch.NormalizedTitle = normalizeTitle(mongoAch.Title)
+	}
+
+	candidates, err := d.mongoRepo.FindDuplicateCandidates(ctx, mongoAch, duplicateCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	scored := make(map[string]duplicateMatch)
+	mongoIDs := make([]string, 0, len(candidates))
+	for i := range candidates {
+		score, reasons := compareAchievements(mongoAch, &candidates[i])
+		if score < duplicateThreshold {
+			continue
+		}
+		id := candidates[i].ID.Hex()
+		scored[id] = duplicateMatch{score: score, reasons: reasons}
+		mongoIDs = append(mongoIDs, id)
+	}
+
+	refs, err := d.pgRepo.FindByMongoIDs(mongoIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Matches a reviewer already ruled out are not raised again
+	dismissed, _ := d.flagRepo.FindDismissedPairs(achievement.ID)
+	skip := make(map[string]bool, len(dismissed))
+	for _, id := range dismissed {
+		skip[id] = true
+	}
+
+	flags := []models.AchievementDuplicateFlag{}
+	for _, ref := range refs {
+		if ref.ID == achievement.ID || skip[ref.ID] {
+			continue
+		}
+		if ref.Status != "submitted" && ref.Status != "verified" {
+			continue
+		}
+		match := scored[ref.MongoAchievementID]
+		flags = append(flags, models.AchievementDuplicateFlag{
+			ID:                   uuid.New().String(),
+			AchievementID:        achievement.ID,
+			MatchedAchievementID: ref.ID,
+			Score:                match.score,
+			Reasons:              match.reasons,
+			Status:               models.DuplicateFlagOpen,
+			CreatedAt:            time.Now(),
+		})
+	}
+
+	if err := d.flagRepo.ReplaceOpen(achievement.ID, flags); err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+type duplicateMatch struct {
+	score   float64
+	reasons []string
+}
+
+// compareAchievements returns a similarity score between 0 and 1 and the signals that matched
+func compareAchievements(a, b *models.MongoAchievement) (float64, []string) {
+	score := 0.0
+	reasons := []string{}
+
+	if certA := detailKey(a.Details, "certification_number"); certA != "" && certA == detailKey(b.Details, "certification_number") {
+		score += weightCertificationNumber
+		reasons = append(reasons, "certification_number")
+	}
+
+	if sharesAttachment(a.Attachments, b.Attachments) {
+		score += weightAttachmentHash
+		reasons = append(reasons, "attachment_hash")
+	}
+
+	titleA, titleB := normalizeTitle(a.Title), normalizeTitle(b.Title)
+	if titleA != "" && titleA == titleB {
+		score += weightTitle
+		reasons = append(reasons, "title")
+	} else if titleSimilarity(titleA, titleB) >= similarTitleRatio {
+		score += weightSimilarTitle
+		reasons = append(reasons, "similar_title")
+	}
+
+	if dateA := detailKey(a.Details, "event_date"); dateA != "" && dateA == detailKey(b.Details, "event_date") {
+		score += weightEventDate
+		reasons = append(reasons, "event_date")
+	}
+
+	if orgA := organizerOf(a.Details); orgA != "" && orgA == organizerOf(b.Details) {
+		score += weightOrganizer
+		reasons = append(reasons, "organizer")
+	}
+
+	if score > 1 {
+		score = 1
+	}
+	return score, reasons
+}
+
+// normalizeTitle lowercases a title and reduces punctuation and repeated whitespace to single spaces
+func normalizeTitle(title string) string {
+	clean := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, title)
+	return strings.Join(strings.Fields(clean), " ")
+}
+
+// titleSimilarity returns the Jaccard similarity of the words of two normalized titles
+func titleSimilarity(a, b string) float64 {
+	wordsA, wordsB := strings.Fields(a), strings.Fields(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+
+	set := make(map[string]bool, len(wordsA))
+	for _, w := range wordsA {
+		set[w] = true
+	}
+	union := len(set)
+	shared := 0
+	seen := make(map[string]bool, len(wordsB))
+	for _, w := range wordsB {
+		if seen[w] {
+			continue
+		}
+		seen[w] = true
+		if set[w] {
+			shared++
+		} else {
+			union++
+		}
+	}
+	return float64(shared) / float64(union)
+}
+
+// organizerOf returns the normalized organizer of an event, falling back to the certificate issuer
+func organizerOf(details map[string]interface{}) string {
+	if v, ok := details["organizer"].(string); ok && strings.TrimSpace(v) != "" {
+		return normalizeTitle(v)
+	}
+	if v, ok := details["issued_by"].(string); ok {
+		return normalizeTitle(v)
+	}
+	return ""
+}
+
+func sharesAttachment(a, b []models.Attachment) bool {
+	hashes := make(map[string]bool, len(a))
+	for _, att := range a {
+		if att.SHA256 != "" {
+			hashes[att.SHA256] = true
+		}
+	}
+	for _, att := range b {
+		if hashes[att.SHA256] {
+			return true
+		}
+	}
+	return false
+}
+
+// hashContent computes the SHA-256 of uploaded file content
+func hashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"testing"
+
+	"UAS/app/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNormalizeTitle tests title normalization used for duplicate detection
+func TestNormalizeTitle(t *testing.T) {
+	assert.Equal(t, "juara 1 lomba web design", normalizeTitle("  Juara-1: Lomba   WEB Design!! "))
+	assert.Equal(t, "", normalizeTitle(" -- "))
+}
+
+// TestCompareAchievements tests the duplicate similarity scoring
+func TestCompareAchievements(t *testing.T) {
+	competition := &models.MongoAchievement{
+		Title: "Juara 1 Lomba Web Design",
+		Details: map[string]interface{}{
+			"event_date": "2024-05-01",
+			"organizer":  "Universitas Airlangga",
+		},
+		Attachments: []models.Attachment{{SHA256: "deadbeef"}, {}},
+	}
+	certificate := &models.MongoAchievement{
+		Title:   "TOEFL Certificate",
+		Details: map[string]interface{}{"certification_number": "abc-123 "},
+	}
+
+	testCases := []struct {
+		name            string
+		source          *models.MongoAchievement
+		other           *models.MongoAchievement
+		expectedReasons []string
+		flagged         bool
+	}{
+		{
+			name:   "Same certificate number",
+			source: certificate,
+			other: &models.MongoAchievement{
+				Title:   "Sertifikat TOEFL",
+				Details: map[string]interface{}{"certification_number": "ABC-123"},
+			},
+			expectedReasons: []string{"certification_number"},
+			flagged:         true,
+		},
+		{
+			name:   "Teammate submitting the same event",
+			source: competition,
+			other: &models.MongoAchievement{
+				Title:   "juara 1 lomba web design",
+				Details: map[string]interface{}{"event_date": "2024-05-01", "organizer": "universitas airlangga"},
+			},
+			expectedReasons: []string{"title", "event_date", "organizer"},
+			flagged:         true,
+		},
+		{
+			name:   "Similar title on a different date",
+			source: competition,
+			other: &models.MongoAchievement{
+				Title:   "Juara 1 Lomba Web Design Nasional",
+				Details: map[string]interface{}{"event_date": "2023-05-01"},
+			},
+			expectedReasons: []string{"similar_title"},
+			flagged:         false,
+		},
+		{
+			name:   "Same attachment file",
+			source: competition,
+			other: &models.MongoAchievement{
+				Title:       "Other title",
+				Attachments: []models.Attachment{{}, {SHA256: "deadbeef"}},
+			},
+			expectedReasons: []string{"attachment_hash"},
+			flagged:         true,
+		},
+		{
+			name:            "Unrelated achievement",
+			source:          competition,
+			other:           &models.MongoAchievement{Title: "Ketua BEM", Attachments: []models.Attachment{{}}},
+			expectedReasons: []string{},
+			flagged:         false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			score, reasons := compareAchievements(tc.source, tc.other)
+			assert.Equal(t, tc.expectedReasons, reasons)
+			assert.Equal(t, tc.flagged, score >= duplicateThreshold)
+			assert.LessOrEqual(t, score, 1.0)
+		})
+	}
+}
+
+// TestTitleSimilarity tests word overlap between titles
+func TestTitleSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, titleSimilarity("a b c", "c b a"))
+	assert.Equal(t, 0.0, titleSimilarity("", "a"))
+	assert.InDelta(t, 1.0/3, titleSimilarity("a b", "a c"), 0.001)
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"UAS/app/repository"
+	"UAS/scheduler"
+	"UAS/utils"
+)
+
+// JobService defines background job administration operations
+type JobService interface {
+	ListJobs(c *fiber.Ctx) error
+	ListJobRuns(c *fiber.Ctx) error
+	TriggerJob(c *fiber.Ctx) error
+	ListQueueJobs(c *fiber.Ctx) error
+	RetryQueueJob(c *fiber.Ctx) error
+}
+
+type jobServiceImpl struct {
+	scheduler *scheduler.Scheduler
+	runRepo   *repository.JobRunRepository
+	queueRepo *repository.QueueJobRepository
+}
+
+func NewJobService() JobService {
+	return &jobServiceImpl{
+		scheduler: scheduler.Default,
+		runRepo:   repository.NewJobRunRepository(),
+		queueRepo: repository.NewQueueJobRepository(),
+	}
+}
+
+// queueRetention is how long succeeded queue jobs are kept before cleanup
+const queueRetention = 7 * 24 * time.Hour
+
+// RegisterQueueJobs registers the cleanup of finished queue jobs
+func RegisterQueueJobs(s *scheduler.Scheduler) {
+	repo := repository.NewQueueJobRepository()
+	s.Register(scheduler.Job{
+		Name:        "queue-cleanup",
+		Schedule:    "0 3 * * *",
+		Description: "Delete succeeded background queue jobs older than 7 days",
+		Run: func(ctx context.Context) (string, error) {
+			deleted, err := repo.DeleteSucceededBefore(time.Now().Add(-queueRetention))
+			return fmt.Sprintf("%d jobs deleted", deleted), err
+		},
+	})
+}
+
+// FunctionName godoc
+// @Summary List background jobs
+// @Description Get registered recurring jobs with their schedule, next run and last run result (Admin only)
+// @Tags Jobs
+// @Produce json
+// @Success 200 {array} scheduler.JobInfo
+// @Router /jobs [get]
+// @Security Bearer
+func (s *jobServiceImpl) ListJobs(c *fiber.Ctx) error {
+	return utils.SuccessResponse(c, "jobs retrieved successfully", s.scheduler.Jobs())
+}
+
+// FunctionName godoc
+// @Summary List job runs
+// @Description Get the run history of a job, newest first (Admin only)
+// @Tags Jobs
+// @Produce json
+// @Param name path string true "Job name"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /jobs/{name}/runs [get]
+// @Security Bearer
+func (s *jobServiceImpl) ListJobRuns(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if !s.scheduler.HasJob(name) {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "job not found")
+	}
+
+	pagination := utils.GetPaginationParams(c)
+	runs, total, err := s.runRepo.FindByJobName(name, pagination.Offset, pagination.Limit)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve job runs")
+	}
+	return utils.PaginatedResponse(c, fiber.Map{"runs": runs}, total, pagination.Page, pagination.Limit)
+}
+
+// FunctionName godoc
+// @Summary Trigger job
+// @Description Run a job now in the background. The run shows up in the job's run history (Admin only)
+// @Tags Jobs
+// @Produce json
+// @Param name path string true "Job name"
+// @Success 202 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /jobs/{name}/trigger [post]
+// @Security Bearer
+func (s *jobServiceImpl) TriggerJob(c *fiber.Ctx) error {
+	name := c.Params("name")
+	err := s.scheduler.Trigger(name, c.Locals("userID").(string))
+	switch {
+	case errors.Is(err, scheduler.ErrJobNotFound):
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "job not found")
+	case errors.Is(err, scheduler.ErrJobRunning):
+		return utils.ErrorResponse(c, fiber.StatusConflict, "job is already running")
+	case err != nil:
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to trigger job")
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"status":  true,
+		"message": "job triggered",
+		"data":    fiber.Map{"name": name},
+	})
+}
+
+// FunctionName godoc
+// @Summary List queue jobs
+// @Description Get background queue jobs filtered by status (pending, running, succeeded, dead) and type, newest first (Admin only)
+// @Tags Jobs
+// @Produce json
+// @Param status query string false "Job status"
+// @Param type query string false "Job type"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} map[string]interface{}
+// @Router /jobs/queue [get]
+// @Security Bearer
+func (s *jobServiceImpl) ListQueueJobs(c *fiber.Ctx) error {
+	pagination := utils.GetPaginationParams(c)
+	jobs, total, err := s.queueRepo.FindAll(c.Query("status"), c.Query("type"), pagination.Offset, pagination.Limit)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve queue jobs")
+	}
+	return utils.PaginatedResponse(c, fiber.Map{"jobs": jobs}, total, pagination.Page, pagination.Limit)
+}
+
+// FunctionName godoc
+// @Summary Retry dead queue job
+// @Description Put a dead queue job back in the queue with a fresh set of attempts (Admin only)
+// @Tags Jobs
+// @Produce json
+// @Param id path string true "Queue job ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /jobs/queue/{id}/retry [post]
+// @Security Bearer
+func (s *jobServiceImpl) RetryQueueJob(c *fiber.Ctx) error {
+	job, err := s.queueRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "queue job not found")
+	}
+	if err := s.queueRepo.Retry(job.ID); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	return utils.SuccessResponse(c, "queue job scheduled for retry", fiber.Map{"id": job.ID})
+}
@@ -297,7 +297,10 @@ func (s *lecturerServiceImpl) GetGuidedStudentsAchievements(c *fiber.Ctx) error
 // @Description Get paginated list of all lecturers. Dosen/Dosen Wali only sees their own profile.
 // @Tags Lecturers
 // @Produce json
+// @Param cursor query string false "Cursor from the next/prev link of the previous page"
+// @Param limit query int false "Items per page" default(10)
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /lecturers [get]
 // @Security Bearer
@@ -319,26 +322,40 @@ func (s *lecturerServiceImpl) ListLecturers(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to get user role")
 	}
 
+	params, err := utils.GetCursorParams(c, models.SortOldestFirst)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
 	// Fetch lecturers based on role
 	var lecturers []models.Lecturer
+	var total int64
+	more := false
 	if role.Name == "Dosen" || role.Name == "Dosen Wali" {
 		// Dosen/Dosen Wali can only see their own profile
 		lecturer, err := s.lecturerRepo.FindByUserID(userID)
 		if err != nil {
 			return utils.ErrorResponse(c, fiber.StatusNotFound, "lecturer profile not found")
 		}
-		lecturers = []models.Lecturer{*lecturer}
+		if params.Cursor == nil {
+			lecturers, total = []models.Lecturer{*lecturer}, 1
+		}
 	} else {
 		// Admin can see all lecturers
-		lecturers, err = s.lecturerRepo.FindAll()
+		lecturers, total, more, err = s.lecturerRepo.FindPage(params.Cursor, params.Limit)
 		if err != nil {
 			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to list lecturers")
 		}
 	}
 
-	return utils.SuccessResponse(c, "lecturers retrieved successfully", fiber.Map{
-		"data": lecturers,
+	first, last := utils.PageBounds(lecturers, func(l models.Lecturer) *models.Cursor {
+		return &models.Cursor{Key: models.CursorTime(l.CreatedAt), ID: l.ID}
 	})
+	pagination := utils.CursorPagination(c, params, first, last, more)
+	pagination["total"] = total
+	return utils.CursorResponse(c, "lecturers retrieved successfully", fiber.Map{
+		"data": lecturers,
+	}, pagination)
 }
 
 // FunctionName godoc
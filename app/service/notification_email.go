@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+	"UAS/mail"
+	"UAS/queue"
+	"UAS/scheduler"
+)
+
+// sendEmailJob is the queue job type that renders and sends one notification email
+const sendEmailJob = "send-email"
+
+// digestItemLimit caps how many notifications the weekly digest lists
+const digestItemLimit = 20
+
+// emailJob is the payload of a send-email job. The recipient's address, name and
+// language are looked up when the job runs so they are always current
+type emailJob struct {
+	UserID        string                 `json:"user_id"`
+	Template      string                 `json:"template"`
+	Data          map[string]interface{} `json:"data,omitempty"`
+	Notifications []mail.DigestItem      `json:"notifications,omitempty"`
+	Unread        int64                  `json:"unread,omitempty"`
+}
+
+// queueEmails enqueues one email per recipient that has not turned the event type off for email
+func (n *notifier) queueEmails(eventType, template string, recipients []string, data map[string]interface{}) {
+	optedOut, err := n.repo.FindOptedOut(repository.ChannelEmail, eventType, recipients)
+	if err != nil {
+		log.Println("Failed to load email preferences for "+eventType+":", err)
+		return
+	}
+	for _, userID := range notificationRecipients(recipients, optedOut) {
+		if _, err := queue.Enqueue(sendEmailJob, emailJob{UserID: userID, Template: template, Data: data}); err != nil {
+			log.Println("Failed to queue "+eventType+" email for "+userID+":", err)
+		}
+	}
+}
+
+// RegisterMailJobs registers the email sender and the weekly digest
+func RegisterMailJobs(s *scheduler.Scheduler, q *queue.Queue, mailer mail.Mailer) {
+	userRepo := repository.NewUserRepository()
+	q.Register(sendEmailJob, func(ctx context.Context, job *models.QueueJob) error {
+		return sendEmail(ctx, mailer, userRepo, job)
+	}, queue.HandlerOptions{Concurrency: 2, Timeout: time.Minute})
+
+	s.Register(scheduler.Job{
+		Name:        "notification-digest",
+		Schedule:    "0 8 * * 1",
+		Description: "Email every user a summary of the notifications they received in the past week",
+		Run: func(ctx context.Context) (string, error) {
+			return queueWeeklyDigests(q)
+		},
+	})
+}
+
+// sendEmail renders the template of a job in the recipient's language and sends it
+func sendEmail(ctx context.Context, mailer mail.Mailer, userRepo *repository.UserRepository, job *models.QueueJob) error {
+	var payload emailJob
+	if err := queue.Decode(job, &payload); err != nil {
+		return err
+	}
+
+	user, err := userRepo.FindByID(payload.UserID)
+	if err != nil {
+		return err
+	}
+	// Deactivated users and users without an address get no email; retrying would not help
+	if !user.IsActive || user.Email == "" {
+		return nil
+	}
+
+	msg, err := mail.Render(payload.Template, mail.TemplateData{
+		Locale:        user.Locale,
+		Name:          user.FullName,
+		AppURL:        os.Getenv("APP_URL"),
+		Data:          payload.Data,
+		Notifications: payload.Notifications,
+		Unread:        payload.Unread,
+	})
+	if err != nil {
+		return err
+	}
+	msg.To = []string{user.Email}
+	return mailer.Send(ctx, msg)
+}
+
+// queueWeeklyDigests enqueues a digest for every user who received notifications in the past week
+func queueWeeklyDigests(q *queue.Queue) (string, error) {
+	repo := repository.NewNotificationRepository()
+	since := time.Now().AddDate(0, 0, -7)
+
+	userIDs, err := repo.FindUserIDsWithNotificationsSince(since)
+	if err != nil {
+		return "", err
+	}
+	optedOut, err := repo.FindOptedOut(repository.ChannelEmail, models.NotificationWeeklyDigest, userIDs)
+	if err != nil {
+		return "", err
+	}
+
+	queued := 0
+	for _, userID := range notificationRecipients(userIDs, optedOut) {
+		notifications, err := repo.FindSince(userID, since, digestItemLimit)
+		if err != nil || len(notifications) == 0 {
+			continue
+		}
+		unread, _ := repo.CountUnread(userID)
+
+		items := make([]mail.DigestItem, len(notifications))
+		for i, n := range notifications {
+			items[i] = mail.DigestItem{Title: n.Title, Message: n.Message, CreatedAt: n.CreatedAt}
+		}
+		job := emailJob{UserID: userID, Template: "weekly_digest", Notifications: items, Unread: unread}
+		if _, err := q.Enqueue(sendEmailJob, job); err != nil {
+			log.Println("Failed to queue weekly digest for "+userID+":", err)
+			continue
+		}
+		queued++
+	}
+	return fmt.Sprintf("%d weekly digests queued", queued), nil
+}
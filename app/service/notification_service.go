@@ -0,0 +1,366 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+	"UAS/mail"
+	"UAS/utils"
+)
+
+// NotificationService defines in-app notification operations for the logged-in user
+type NotificationService interface {
+	ListNotifications(c *fiber.Ctx) error
+	GetUnreadCount(c *fiber.Ctx) error
+	MarkAsRead(c *fiber.Ctx) error
+	MarkManyAsRead(c *fiber.Ctx) error
+	MarkAllAsRead(c *fiber.Ctx) error
+	GetPreferences(c *fiber.Ctx) error
+	UpdatePreferences(c *fiber.Ctx) error
+}
+
+type notificationServiceImpl struct {
+	repo     *repository.NotificationRepository
+	userRepo *repository.UserRepository
+}
+
+func NewNotificationService() NotificationService {
+	return &notificationServiceImpl{
+		repo:     repository.NewNotificationRepository(),
+		userRepo: repository.NewUserRepository(),
+	}
+}
+
+// notifier delivers events raised by the other services: in-app notifications are stored
+// directly, emails are queued as send-email jobs
+type notifier struct {
+	repo *repository.NotificationRepository
+}
+
+func newNotifier() *notifier {
+	return &notifier{repo: repository.NewNotificationRepository()}
+}
+
+// notify sends an event to every recipient that has not turned it off, on each channel of the event type
+// Failures are only logged: a notification never fails the action that raised it
+func (n *notifier) notify(eventType string, recipients []string, title, message string, data map[string]interface{}) {
+	info, ok := models.FindNotificationType(eventType)
+	if !ok {
+		log.Println("Unknown notification type:", eventType)
+		return
+	}
+	if info.InApp {
+		n.createInApp(eventType, recipients, title, message, data)
+	}
+	if info.EmailTemplate != "" {
+		n.queueEmails(eventType, info.EmailTemplate, recipients, data)
+	}
+}
+
+func (n *notifier) createInApp(eventType string, recipients []string, title, message string, data map[string]interface{}) {
+	optedOut, err := n.repo.FindOptedOut(repository.ChannelInApp, eventType, recipients)
+	if err != nil {
+		log.Println("Failed to load notification preferences for "+eventType+":", err)
+		return
+	}
+
+	now := time.Now()
+	var notifications []models.Notification
+	for _, userID := range notificationRecipients(recipients, optedOut) {
+		notifications = append(notifications, models.Notification{
+			ID:        uuid.New().String(),
+			UserID:    userID,
+			Type:      eventType,
+			Title:     title,
+			Message:   message,
+			Data:      data,
+			CreatedAt: now,
+		})
+	}
+	if err := n.repo.CreateMany(notifications); err != nil {
+		log.Println("Failed to create "+eventType+" notifications:", err)
+	}
+}
+
+// notificationRecipients drops empty and repeated user IDs and users who opted out
+func notificationRecipients(userIDs []string, optedOut map[string]bool) []string {
+	seen := make(map[string]bool)
+	var recipients []string
+	for _, id := range userIDs {
+		if id == "" || seen[id] || optedOut[id] {
+			continue
+		}
+		seen[id] = true
+		recipients = append(recipients, id)
+	}
+	return recipients
+}
+
+// preferenceList lists every event type with the user's choice per channel, enabled unless
+// stored otherwise. Channels an event type is not delivered on are left out
+func preferenceList(stored []models.NotificationPreference) []fiber.Map {
+	byType := make(map[string]models.NotificationPreference)
+	for _, p := range stored {
+		byType[p.Type] = p
+	}
+
+	list := make([]fiber.Map, 0, len(models.NotificationTypes))
+	for _, info := range models.NotificationTypes {
+		pref := effectivePreference(byType, "", info.Type)
+		item := fiber.Map{
+			"type":        info.Type,
+			"description": info.Description,
+		}
+		if info.InApp {
+			item["in_app"] = pref.InApp
+		}
+		if info.EmailTemplate != "" {
+			item["email"] = *pref.Email
+		}
+		list = append(list, item)
+	}
+	return list
+}
+
+// effectivePreference returns the stored preference of an event type, or the default with every channel enabled
+func effectivePreference(byType map[string]models.NotificationPreference, userID, eventType string) models.NotificationPreference {
+	pref, ok := byType[eventType]
+	if !ok {
+		pref = models.NotificationPreference{UserID: userID, Type: eventType, InApp: true}
+	}
+	if pref.Email == nil {
+		enabled := true
+		pref.Email = &enabled
+	}
+	return pref
+}
+
+// applyPreferenceItems merges requested changes into the stored preferences
+// Only channels the event type is delivered on can be changed
+func applyPreferenceItems(stored []models.NotificationPreference, userID string, items []models.NotificationPreferenceItem) ([]models.NotificationPreference, error) {
+	byType := make(map[string]models.NotificationPreference)
+	for _, p := range stored {
+		byType[p.Type] = p
+	}
+
+	now := time.Now()
+	prefs := make([]models.NotificationPreference, 0, len(items))
+	for _, item := range items {
+		info, ok := models.FindNotificationType(item.Type)
+		if !ok {
+			return nil, errors.New("unknown notification type: " + item.Type)
+		}
+		if item.InApp == nil && item.Email == nil {
+			return nil, errors.New("in_app or email is required for " + item.Type)
+		}
+		if item.InApp != nil && !info.InApp {
+			return nil, errors.New(item.Type + " is not shown in the notification center")
+		}
+		if item.Email != nil && info.EmailTemplate == "" {
+			return nil, errors.New(item.Type + " is not sent by email")
+		}
+
+		pref := effectivePreference(byType, userID, item.Type)
+		if item.InApp != nil {
+			pref.InApp = *item.InApp
+		}
+		if item.Email != nil {
+			pref.Email = item.Email
+		}
+		pref.UpdatedAt = now
+		byType[item.Type] = pref
+		prefs = append(prefs, pref)
+	}
+	return prefs, nil
+}
+
+// FunctionName godoc
+// @Summary List notifications
+// @Description Get the notifications of the logged-in user, newest first
+// @Tags Notifications
+// @Produce json
+// @Param unread query bool false "Only unread notifications"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} map[string]interface{}
+// @Router /notifications [get]
+// @Security Bearer
+func (s *notificationServiceImpl) ListNotifications(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	pagination := utils.GetPaginationParams(c)
+
+	notifications, total, err := s.repo.FindByUserID(userID, c.QueryBool("unread"), pagination.Offset, pagination.Limit)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve notifications")
+	}
+	unread, err := s.repo.CountUnread(userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to count unread notifications")
+	}
+
+	return utils.PaginatedResponse(c, fiber.Map{
+		"notifications": notifications,
+		"unread_count":  unread,
+	}, total, pagination.Page, pagination.Limit)
+}
+
+// FunctionName godoc
+// @Summary Count unread notifications
+// @Description Get the number of unread notifications of the logged-in user
+// @Tags Notifications
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /notifications/unread-count [get]
+// @Security Bearer
+func (s *notificationServiceImpl) GetUnreadCount(c *fiber.Ctx) error {
+	unread, err := s.repo.CountUnread(c.Locals("userID").(string))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to count unread notifications")
+	}
+	return utils.SuccessResponse(c, "unread notifications counted successfully", fiber.Map{"unread_count": unread})
+}
+
+// FunctionName godoc
+// @Summary Mark notification as read
+// @Description Mark one notification of the logged-in user as read
+// @Tags Notifications
+// @Produce json
+// @Param id path string true "Notification ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /notifications/{id}/read [post]
+// @Security Bearer
+func (s *notificationServiceImpl) MarkAsRead(c *fiber.Ctx) error {
+	updated, err := s.repo.MarkRead(c.Locals("userID").(string), []string{c.Params("id")})
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to mark notification as read")
+	}
+	if updated == 0 {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "unread notification not found")
+	}
+	return utils.SuccessResponse(c, "notification marked as read", nil)
+}
+
+// FunctionName godoc
+// @Summary Mark notifications as read
+// @Description Mark the given notifications of the logged-in user as read
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param body body models.MarkNotificationsReadRequest true "Notification IDs"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /notifications/read [post]
+// @Security Bearer
+func (s *notificationServiceImpl) MarkManyAsRead(c *fiber.Ctx) error {
+	var req models.MarkNotificationsReadRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "invalid request body")
+	}
+	if len(req.IDs) == 0 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "ids is required")
+	}
+
+	updated, err := s.repo.MarkRead(c.Locals("userID").(string), req.IDs)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to mark notifications as read")
+	}
+	return utils.SuccessResponse(c, "notifications marked as read", fiber.Map{"updated": updated})
+}
+
+// FunctionName godoc
+// @Summary Mark all notifications as read
+// @Description Mark every unread notification of the logged-in user as read
+// @Tags Notifications
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /notifications/read-all [post]
+// @Security Bearer
+func (s *notificationServiceImpl) MarkAllAsRead(c *fiber.Ctx) error {
+	updated, err := s.repo.MarkRead(c.Locals("userID").(string), nil)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to mark notifications as read")
+	}
+	return utils.SuccessResponse(c, "all notifications marked as read", fiber.Map{"updated": updated})
+}
+
+// FunctionName godoc
+// @Summary Get notification preferences
+// @Description Get which notification event types the logged-in user receives per channel, and the language of their emails
+// @Tags Notifications
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /notifications/preferences [get]
+// @Security Bearer
+func (s *notificationServiceImpl) GetPreferences(c *fiber.Ctx) error {
+	return s.preferencesResponse(c, "notification preferences retrieved successfully")
+}
+
+func (s *notificationServiceImpl) preferencesResponse(c *fiber.Ctx, message string) error {
+	userID := c.Locals("userID").(string)
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "user not found")
+	}
+	stored, err := s.repo.FindPreferences(userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve notification preferences")
+	}
+
+	locale := user.Locale
+	if !mail.IsLocale(locale) {
+		locale = mail.DefaultLocale
+	}
+	return utils.SuccessResponse(c, message, fiber.Map{
+		"locale":      locale,
+		"preferences": preferenceList(stored),
+	})
+}
+
+// FunctionName godoc
+// @Summary Update notification preferences
+// @Description Turn notification event types on or off per channel (in_app, email) for the logged-in user, and set the email language (id or en). Types and channels not listed keep their setting
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param body body models.UpdateNotificationPreferencesRequest true "Preferences per event type"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /notifications/preferences [put]
+// @Security Bearer
+func (s *notificationServiceImpl) UpdatePreferences(c *fiber.Ctx) error {
+	var req models.UpdateNotificationPreferencesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "invalid request body")
+	}
+	if req.Locale != "" && !mail.IsLocale(req.Locale) {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "locale must be id or en")
+	}
+
+	userID := c.Locals("userID").(string)
+	stored, err := s.repo.FindPreferences(userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve notification preferences")
+	}
+	prefs, err := applyPreferenceItems(stored, userID, req.Preferences)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := s.repo.SavePreferences(prefs); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to update notification preferences")
+	}
+	if req.Locale != "" {
+		if err := s.userRepo.UpdateLocale(userID, req.Locale); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to update email language")
+		}
+	}
+
+	return s.preferencesResponse(c, "notification preferences updated successfully")
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"testing"
+
+	"UAS/app/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNotificationRecipients tests recipient filtering of notification events
+func TestNotificationRecipients(t *testing.T) {
+	testCases := []struct {
+		name     string
+		userIDs  []string
+		optedOut map[string]bool
+		expected []string
+	}{
+		{
+			name:     "All recipients kept in order",
+			userIDs:  []string{"owner", "member"},
+			expected: []string{"owner", "member"},
+		},
+		{
+			name:     "Empty and repeated IDs dropped",
+			userIDs:  []string{"owner", "", "member", "owner"},
+			expected: []string{"owner", "member"},
+		},
+		{
+			name:     "Opted out users dropped",
+			userIDs:  []string{"owner", "member"},
+			optedOut: map[string]bool{"member": true},
+			expected: []string{"owner"},
+		},
+		{
+			name:     "Advisor missing",
+			userIDs:  []string{""},
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, notificationRecipients(tc.userIDs, tc.optedOut))
+		})
+	}
+}
+
+// TestPreferenceList tests that every event type is listed with only the channels it is delivered on
+func TestPreferenceList(t *testing.T) {
+	off := false
+	stored := []models.NotificationPreference{
+		{Type: models.NotificationAchievementRejected, InApp: false},
+		{Type: models.NotificationAchievementVerified, InApp: true, Email: &off},
+	}
+
+	list := preferenceList(stored)
+	assert.Len(t, list, len(models.NotificationTypes))
+
+	byType := make(map[string]map[string]interface{})
+	for _, item := range list {
+		byType[item["type"].(string)] = item
+	}
+	assert.Equal(t, false, byType[models.NotificationAchievementRejected]["in_app"])
+	assert.Equal(t, true, byType[models.NotificationAchievementRejected]["email"])
+	assert.Equal(t, true, byType[models.NotificationAchievementVerified]["in_app"])
+	assert.Equal(t, false, byType[models.NotificationAchievementVerified]["email"])
+	assert.Equal(t, true, byType[models.NotificationAdvisorChanged]["in_app"])
+	assert.NotContains(t, byType[models.NotificationAdvisorChanged], "email")
+	assert.NotContains(t, byType[models.NotificationWeeklyDigest], "in_app")
+	assert.Equal(t, true, byType[models.NotificationWeeklyDigest]["email"])
+}
+
+// TestApplyPreferenceItems tests merging preference changes into stored preferences
+func TestApplyPreferenceItems(t *testing.T) {
+	on, off := true, false
+	stored := []models.NotificationPreference{{UserID: "u1", Type: models.NotificationAchievementVerified, InApp: false}}
+
+	testCases := []struct {
+		name          string
+		item          models.NotificationPreferenceItem
+		expectedInApp bool
+		expectedEmail bool
+		expectedError bool
+	}{
+		{
+			name:          "Email change keeps stored in-app setting",
+			item:          models.NotificationPreferenceItem{Type: models.NotificationAchievementVerified, Email: &off},
+			expectedInApp: false,
+			expectedEmail: false,
+		},
+		{
+			name:          "New preference starts from defaults",
+			item:          models.NotificationPreferenceItem{Type: models.NotificationAchievementRejected, InApp: &off},
+			expectedInApp: false,
+			expectedEmail: true,
+		},
+		{
+			name:          "Email only type",
+			item:          models.NotificationPreferenceItem{Type: models.NotificationWeeklyDigest, Email: &off},
+			expectedInApp: true,
+			expectedEmail: false,
+		},
+		{
+			name:          "Channel not delivered",
+			item:          models.NotificationPreferenceItem{Type: models.NotificationAdvisorChanged, Email: &on},
+			expectedError: true,
+		},
+		{
+			name:          "Unknown type",
+			item:          models.NotificationPreferenceItem{Type: "comment_added", InApp: &on},
+			expectedError: true,
+		},
+		{
+			name:          "No channel given",
+			item:          models.NotificationPreferenceItem{Type: models.NotificationAchievementVerified},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prefs, err := applyPreferenceItems(stored, "u1", []models.NotificationPreferenceItem{tc.item})
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if assert.Len(t, prefs, 1) {
+				assert.Equal(t, "u1", prefs[0].UserID)
+				assert.Equal(t, tc.expectedInApp, prefs[0].InApp)
+				assert.Equal(t, tc.expectedEmail, *prefs[0].Email)
+			}
+		})
+	}
+}
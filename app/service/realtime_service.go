@@ -0,0 +1,276 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+	"UAS/realtime"
+	"UAS/scheduler"
+	"UAS/utils"
+)
+
+const (
+	// realtimeHeartbeat keeps idle streams open through proxies that drop silent connections
+	realtimeHeartbeat = 25 * time.Second
+	// realtimeRetry is the reconnection delay suggested to EventSource clients, in milliseconds
+	realtimeRetry = 3000
+	// realtimeRetention is how long events stay available for Last-Event-ID replay
+	realtimeRetention = 24 * time.Hour
+	// defaultRealtimeTokenTTL is how long a stream token stays valid unless REALTIME_TOKEN_TTL is set
+	defaultRealtimeTokenTTL = time.Minute
+)
+
+// RealtimeService defines the Server-Sent Events stream of achievement changes
+type RealtimeService interface {
+	CreateStreamToken(c *fiber.Ctx) error
+	StreamEvents(c *fiber.Ctx) error
+}
+
+type realtimeServiceImpl struct {
+	lecturerRepo *repository.LecturerRepository
+	broker       *realtime.Broker
+}
+
+func NewRealtimeService() RealtimeService {
+	return &realtimeServiceImpl{
+		lecturerRepo: repository.NewLecturerRepository(),
+		broker:       realtime.Default,
+	}
+}
+
+// FunctionName godoc
+// @Summary Create a stream token
+// @Description Issues a short-lived token that only opens the event stream, for EventSource clients that cannot set the Authorization header. Request a new one before every (re)connection
+// @Tags Realtime
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /realtime/tokens [post]
+// @Security Bearer
+func (s *realtimeServiceImpl) CreateStreamToken(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	role := c.Locals("role").(string)
+
+	token, expiresAt, err := utils.GenerateStreamToken(userID, role, c.Locals("permissions"), realtimeTokenTTL())
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to create stream token")
+	}
+
+	return utils.SuccessResponse(c, "stream token created", fiber.Map{
+		"token":      token,
+		"expires_at": expiresAt,
+	})
+}
+
+// FunctionName godoc
+// @Summary Stream achievement events
+// @Description Server-Sent Events stream of achievement changes visible to the caller: own and shared achievements for Mahasiswa, advisees' achievements for Dosen Wali, everything for Admin. Reconnecting clients send Last-Event-ID to receive the events they missed. Browsers may pass a stream token from POST /realtime/tokens as access_token because EventSource cannot set headers
+// @Tags Realtime
+// @Produce text/event-stream
+// @Param Last-Event-ID header string false "ID of the last event received"
+// @Param last_event_id query string false "Same as the Last-Event-ID header"
+// @Param access_token query string false "Stream token when the Authorization header cannot be set"
+// @Success 200 {string} string "text/event-stream"
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /realtime/events [get]
+// @Security Bearer
+func (s *realtimeServiceImpl) StreamEvents(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	role := c.Locals("role").(string)
+
+	lecturerID := ""
+	if role == "Dosen Wali" {
+		lecturer, err := s.lecturerRepo.FindByUserID(userID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, "lecturer not found")
+		}
+		lecturerID = lecturer.ID
+	}
+	filter := realtimeFilter(role, userID, lecturerID)
+	if filter == nil {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "role cannot subscribe to real-time events")
+	}
+
+	lastEventID := c.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var afterID int64
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "invalid Last-Event-ID")
+		}
+		afterID = id
+	}
+
+	// Subscribe before replaying so nothing published in between is lost; duplicates are skipped by ID
+	sub := s.broker.Subscribe(filter)
+	var missed []models.RealtimeEvent
+	complete := true
+	if lastEventID != "" {
+		var err error
+		missed, complete, err = s.broker.Replay(afterID, filter)
+		if err != nil {
+			sub.Close()
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to load missed events")
+		}
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		fmt.Fprintf(w, "retry: %d\n\n", realtimeRetry)
+		if !complete {
+			// Too many events were missed; the client should reload its data instead
+			fmt.Fprint(w, "event: resync\ndata: {}\n\n")
+		}
+		sent := afterID
+		for i := range missed {
+			if err := writeRealtimeEvent(w, &missed[i]); err != nil {
+				return
+			}
+			sent = missed[i].ID
+		}
+		if w.Flush() != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(realtimeHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case event, ok := <-sub.Events:
+				if !ok {
+					return
+				}
+				if event.ID <= sent {
+					continue
+				}
+				if err := writeRealtimeEvent(w, &event); err != nil {
+					return
+				}
+				sent = event.ID
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+			if w.Flush() != nil {
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// realtimeFilter returns the events a caller may see, following the same rules as the
+// achievement endpoints; nil when the role has no access to the stream
+func realtimeFilter(role, userID, lecturerID string) realtime.Filter {
+	switch role {
+	case "Admin":
+		return func(event *models.RealtimeEvent) bool { return true }
+	case "Mahasiswa":
+		return func(event *models.RealtimeEvent) bool {
+			if event.StudentID == userID {
+				return true
+			}
+			for _, id := range event.Participants {
+				if id == userID {
+					return true
+				}
+			}
+			return false
+		}
+	case "Dosen Wali":
+		if lecturerID == "" {
+			return nil
+		}
+		return func(event *models.RealtimeEvent) bool { return event.AdvisorID == lecturerID }
+	}
+	return nil
+}
+
+// writeRealtimeEvent writes one event in the Server-Sent Events wire format
+func writeRealtimeEvent(w io.Writer, event *models.RealtimeEvent) error {
+	data := map[string]interface{}{}
+	for k, v := range event.Data {
+		data[k] = v
+	}
+	data["achievement_id"] = event.AchievementID
+	data["created_at"] = event.CreatedAt
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, body)
+	return err
+}
+
+// realtimePublisher streams achievement changes to the owner, co-participants, the owner's advisor and admins
+type realtimePublisher struct {
+	studentRepo  *repository.StudentRepository
+	participants *participantManager
+}
+
+func newRealtimePublisher() *realtimePublisher {
+	return &realtimePublisher{
+		studentRepo:  repository.NewStudentRepository(),
+		participants: newParticipantManager(),
+	}
+}
+
+// publish stores a real-time event for an achievement
+// Failures are only logged: a real-time event never fails the action that raised it
+func (p *realtimePublisher) publish(eventType, achievementID, studentUserID string, data map[string]interface{}) {
+	event := &models.RealtimeEvent{
+		Type:          eventType,
+		AchievementID: achievementID,
+		StudentID:     studentUserID,
+		Participants:  p.participants.memberIDs(achievementID, studentUserID),
+		Data:          data,
+	}
+	if student, err := p.studentRepo.FindByUserID(studentUserID); err == nil {
+		event.AdvisorID = student.AdvisorID
+	}
+	if err := realtime.Publish(event); err != nil {
+		log.Println("Failed to publish real-time event "+eventType+":", err)
+	}
+}
+
+// RegisterRealtimeJobs registers the cleanup of events too old to be replayed
+func RegisterRealtimeJobs(s *scheduler.Scheduler) {
+	s.Register(scheduler.Job{
+		Name:        "realtime-cleanup",
+		Schedule:    "30 * * * *",
+		Description: "Delete real-time events older than 24 hours",
+		Run: func(ctx context.Context) (string, error) {
+			deleted, err := repository.NewRealtimeEventRepository().DeleteBefore(time.Now().Add(-realtimeRetention))
+			return fmt.Sprintf("%d real-time events deleted", deleted), err
+		},
+	})
+}
+
+// realtimeTokenTTL returns REALTIME_TOKEN_TTL (e.g. "2m"), the lifetime of stream tokens
+func realtimeTokenTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("REALTIME_TOKEN_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return defaultRealtimeTokenTTL
+}
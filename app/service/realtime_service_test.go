@@ -0,0 +1,63 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"UAS/app/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRealtimeFilter tests which events each role receives on the stream
+func TestRealtimeFilter(t *testing.T) {
+	own := &models.RealtimeEvent{StudentID: "u1", AdvisorID: "l1"}
+	shared := &models.RealtimeEvent{StudentID: "u2", AdvisorID: "l2", Participants: []string{"u2", "u1"}}
+	other := &models.RealtimeEvent{StudentID: "u3", AdvisorID: "l2"}
+
+	testCases := []struct {
+		name       string
+		role       string
+		userID     string
+		lecturerID string
+		expected   []bool // own, shared, other; nil when the role has no access
+	}{
+		{name: "Admin sees everything", role: "Admin", userID: "a1", expected: []bool{true, true, true}},
+		{name: "Student sees own and shared", role: "Mahasiswa", userID: "u1", expected: []bool{true, true, false}},
+		{name: "Advisor sees advisees", role: "Dosen Wali", userID: "d2", lecturerID: "l2", expected: []bool{false, true, true}},
+		{name: "Advisor without lecturer profile", role: "Dosen Wali", userID: "d3"},
+		{name: "Unknown role", role: "Guest", userID: "g1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter := realtimeFilter(tc.role, tc.userID, tc.lecturerID)
+			if tc.expected == nil {
+				assert.Nil(t, filter)
+				return
+			}
+			assert.Equal(t, tc.expected, []bool{filter(own), filter(shared), filter(other)})
+		})
+	}
+}
+
+// TestWriteRealtimeEvent tests the Server-Sent Events wire format
+func TestWriteRealtimeEvent(t *testing.T) {
+	var buf bytes.Buffer
+	event := &models.RealtimeEvent{
+		ID:            42,
+		Type:          models.RealtimeAchievementVerified,
+		AchievementID: "ach-1",
+		StudentID:     "u1",
+		Data:          map[string]interface{}{"status": "verified", "points": 20},
+		CreatedAt:     time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+
+	err := writeRealtimeEvent(&buf, event)
+	assert.NoError(t, err)
+	assert.Equal(t, "id: 42\nevent: achievement.verified\n"+
+		`data: {"achievement_id":"ach-1","created_at":"2024-05-01T10:00:00Z","points":20,"status":"verified"}`+"\n\n",
+		buf.String())
+	assert.Len(t, event.Data, 2, "the stored event data is not modified")
+}
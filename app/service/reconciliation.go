@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+	"UAS/scheduler"
+)
+
+// reconcileFinding is an issue plus the values its repair writes
+type reconcileFinding struct {
+	issue     models.ReconcileIssue
+	studentID string
+	points    int
+}
+
+// ReconcileAchievements compares every achievement reference with its MongoDB document and reports
+// the inconsistencies. PostgreSQL is the source of truth. With repair false nothing is changed, so
+// a dry run shows exactly what a repair run would do. Achievements with pending outbox entries are
+// skipped because the outbox is about to change them anyway
+func ReconcileAchievements(ctx context.Context, repair bool) (*models.ReconcileReport, error) {
+	pgRepo := repository.NewAchievementRepository()
+	mongoRepo := repository.NewMongoAchievementRepository()
+	outboxRepo := repository.NewOutboxRepository()
+
+	inFlight, err := pendingOutboxAchievements(outboxRepo)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := pgRepo.FindAllWithDeleted()
+	if err != nil {
+		return nil, err
+	}
+	docs, err := mongoRepo.FindAllWithDeleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	findings, skipped := findAchievementInconsistencies(refs, docs, inFlight)
+	failed, err := failedOutboxEntries(outboxRepo)
+	if err != nil {
+		return nil, err
+	}
+	findings = append(findings, failed...)
+	report := &models.ReconcileReport{
+		DryRun:            !repair,
+		ReferencesScanned: len(refs),
+		DocumentsScanned:  len(docs),
+		SkippedInFlight:   skipped,
+		Issues:            []models.ReconcileIssue{},
+	}
+
+	if repair && len(findings) > 0 {
+		// Changes made while scanning go through the outbox; leave those achievements alone
+		if inFlight, err = pendingOutboxAchievements(outboxRepo); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, f := range findings {
+		issue := f.issue
+		switch {
+		case issue.Repair == "":
+			report.NeedsManualAttention++
+		case repair && inFlight[issue.AchievementID]:
+			issue.Error = "skipped: the achievement changed during the run"
+			report.Failed++
+		case repair:
+			if err := applyReconcileRepair(ctx, pgRepo, mongoRepo, outboxRepo, f); err != nil {
+				issue.Error = err.Error()
+				report.Failed++
+			} else {
+				issue.Repaired = true
+				report.Repaired++
+			}
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+	return report, nil
+}
+
+func pendingOutboxAchievements(repo *repository.OutboxRepository) (map[string]bool, error) {
+	ids, err := repo.FindPendingAchievementIDs()
+	if err != nil {
+		return nil, err
+	}
+	pending := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		pending[id] = true
+	}
+	return pending, nil
+}
+
+// failedOutboxEntries lists the outbox entries that were given up on. Requeueing is offered unless
+// a newer change of the achievement was applied since, which the older entry would overwrite
+func failedOutboxEntries(repo *repository.OutboxRepository) ([]reconcileFinding, error) {
+	entries, err := repo.FindFailed()
+	if err != nil {
+		return nil, err
+	}
+	superseded := make(map[int64]bool)
+	for _, entry := range entries {
+		newer, err := repo.HasAppliedAfter(entry.AchievementID, entry.ID)
+		if err != nil {
+			return nil, err
+		}
+		superseded[entry.ID] = newer
+	}
+	return failedOutboxFindings(entries, superseded), nil
+}
+
+// failedOutboxFindings describes failed outbox entries; superseded marks entries with newer applied
+// changes, which need manual attention instead of a requeue
+func failedOutboxFindings(entries []models.OutboxEntry, superseded map[int64]bool) []reconcileFinding {
+	findings := make([]reconcileFinding, 0, len(entries))
+	for _, entry := range entries {
+		issue := models.ReconcileIssue{
+			Kind:          models.ReconcileFailedOutbox,
+			AchievementID: entry.AchievementID,
+			MongoID:       entry.MongoID,
+			OutboxEntryID: entry.ID,
+			Detail:        fmt.Sprintf("outbox entry %d (%s) failed after %d attempts: %s", entry.ID, orNone(entry.Operation), entry.Attempts, entry.LastError),
+			Repair:        "requeue the outbox entry",
+		}
+		if superseded[entry.ID] {
+			issue.Detail += "; newer changes were applied since, check the document before requeueing"
+			issue.Repair = ""
+		}
+		findings = append(findings, reconcileFinding{issue: issue})
+	}
+	return findings
+}
+
+// orNone names an empty outbox operation
+func orNone(operation string) string {
+	if operation == models.OutboxOpNone {
+		return "events only"
+	}
+	return operation
+}
+
+// findAchievementInconsistencies matches references to documents and lists what disagrees.
+// It returns the findings and how many references were skipped because they are in flight
+func findAchievementInconsistencies(refs []models.AchievementReference, docs []models.MongoAchievement, inFlight map[string]bool) ([]reconcileFinding, int) {
+	docByID := make(map[string]*models.MongoAchievement, len(docs))
+	for i := range docs {
+		docByID[docs[i].ID.Hex()] = &docs[i]
+	}
+
+	var findings []reconcileFinding
+	referenced := make(map[string]bool, len(refs))
+	skipped := 0
+	for _, ref := range refs {
+		referenced[ref.MongoAchievementID] = true
+		if inFlight[ref.ID] {
+			skipped++
+			continue
+		}
+
+		doc := docByID[ref.MongoAchievementID]
+		refDeleted := ref.DeletedAt != nil
+		issue := models.ReconcileIssue{AchievementID: ref.ID, MongoID: ref.MongoAchievementID}
+
+		switch {
+		case doc == nil:
+			if refDeleted {
+				continue
+			}
+			issue.Kind = models.ReconcileMissingDocument
+			issue.Detail = "reference (" + ref.Status + ") points to a MongoDB document that does not exist"
+			// Only drafts are removed automatically; a submitted or verified achievement needs a person
+			if ref.Status == "draft" {
+				issue.Repair = "soft delete the reference"
+			}
+			findings = append(findings, reconcileFinding{issue: issue})
+		case doc.DeletedAt != nil && !refDeleted:
+			issue.Kind = models.ReconcileDocumentDeleted
+			issue.Detail = "reference is active but its document is deleted"
+			issue.Repair = "restore the document"
+			findings = append(findings, reconcileFinding{issue: issue})
+		case doc.DeletedAt == nil && refDeleted:
+			issue.Kind = models.ReconcileReferenceDeleted
+			issue.Detail = "reference is deleted but its document is active"
+			issue.Repair = "soft delete the document"
+			findings = append(findings, reconcileFinding{issue: issue})
+		case doc.DeletedAt == nil:
+			if doc.StudentID != ref.StudentID {
+				issue.Kind = models.ReconcileStudentMismatch
+				issue.Detail = fmt.Sprintf("document student_id is %q, reference student_id is %q", doc.StudentID, ref.StudentID)
+				issue.Repair = "set the document student_id to " + ref.StudentID
+				findings = append(findings, reconcileFinding{issue: issue, studentID: ref.StudentID})
+			}
+			if ref.Status == "verified" && doc.Points != ref.Points {
+				issue.Kind = models.ReconcilePointsMismatch
+				issue.Detail = fmt.Sprintf("document has %d points, reference has %d", doc.Points, ref.Points)
+				issue.Repair = fmt.Sprintf("set the document points to %d", ref.Points)
+				findings = append(findings, reconcileFinding{issue: issue, points: ref.Points})
+			}
+		}
+	}
+
+	var orphans []reconcileFinding
+	for id, doc := range docByID {
+		if referenced[id] || doc.DeletedAt != nil {
+			continue
+		}
+		orphans = append(orphans, reconcileFinding{issue: models.ReconcileIssue{
+			Kind:    models.ReconcileOrphanDocument,
+			MongoID: id,
+			Detail:  fmt.Sprintf("document %q of student %q has no reference", doc.Title, doc.StudentID),
+			Repair:  "soft delete the document",
+		}})
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].issue.MongoID < orphans[j].issue.MongoID })
+
+	return append(findings, orphans...), skipped
+}
+
+// applyReconcileRepair makes the side that disagrees with PostgreSQL match it
+func applyReconcileRepair(ctx context.Context, pgRepo *repository.AchievementRepository, mongoRepo *repository.MongoAchievementRepository, outboxRepo *repository.OutboxRepository, f reconcileFinding) error {
+	switch f.issue.Kind {
+	case models.ReconcileFailedOutbox:
+		return requeueOutboxEntry(outboxRepo, f.issue.OutboxEntryID)
+	case models.ReconcileMissingDocument:
+		return pgRepo.Delete(f.issue.AchievementID, nil)
+	case models.ReconcileDocumentDeleted:
+		return mongoRepo.Restore(ctx, f.issue.MongoID)
+	case models.ReconcileReferenceDeleted, models.ReconcileOrphanDocument:
+		return mongoRepo.SoftDelete(ctx, f.issue.MongoID)
+	case models.ReconcileStudentMismatch:
+		return mongoRepo.UpdateStudentID(ctx, f.issue.MongoID, f.studentID)
+	case models.ReconcilePointsMismatch:
+		return mongoRepo.UpdatePoints(ctx, f.issue.MongoID, f.points)
+	}
+	return fmt.Errorf("no repair for %s", f.issue.Kind)
+}
+
+// RequeueOutboxEntry puts a failed outbox entry back in the queue, also when newer changes were
+// applied since; for entries a person has checked
+func RequeueOutboxEntry(id int64) error {
+	return requeueOutboxEntry(repository.NewOutboxRepository(), id)
+}
+
+// ReconcileSummary describes a report in one line
+func ReconcileSummary(report *models.ReconcileReport) string {
+	mode := "repair"
+	if report.DryRun {
+		mode = "dry run"
+	}
+	return fmt.Sprintf("%s: %d references and %d documents scanned, %d in flight skipped, %d issues, %d repaired, %d failed, %d need manual attention",
+		mode, report.ReferencesScanned, report.DocumentsScanned, report.SkippedInFlight,
+		len(report.Issues), report.Repaired, report.Failed, report.NeedsManualAttention)
+}
+
+// RegisterReconcileJobs registers the nightly consistency check. It only reports unless
+// RECONCILE_REPAIR=true; repairs can always be run by hand with cmd/reconcile
+func RegisterReconcileJobs(s *scheduler.Scheduler) {
+	s.Register(scheduler.Job{
+		Name:        "achievement-reconcile",
+		Schedule:    "0 2 * * *",
+		Description: "Compare achievement references in PostgreSQL with their MongoDB documents",
+		Run: func(ctx context.Context) (string, error) {
+			report, err := ReconcileAchievements(ctx, os.Getenv("RECONCILE_REPAIR") == "true")
+			if err != nil {
+				return "", err
+			}
+			for _, issue := range report.Issues {
+				log.Printf("Reconcile %s: achievement=%s document=%s: %s (repair: %s, repaired: %t %s)",
+					issue.Kind, issue.AchievementID, issue.MongoID, issue.Detail, issue.Repair, issue.Repaired, issue.Error)
+			}
+			return ReconcileSummary(report), nil
+		},
+	})
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"UAS/app/models"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestFindAchievementInconsistencies tests how references and documents are matched
+func TestFindAchievementInconsistencies(t *testing.T) {
+	deleted := time.Now()
+	ids := make([]primitive.ObjectID, 8)
+	for i := range ids {
+		ids[i] = primitive.NewObjectID()
+	}
+
+	refs := []models.AchievementReference{
+		{ID: "consistent", StudentID: "u1", MongoAchievementID: ids[0].Hex(), Status: "verified", Points: 20},
+		{ID: "missing-draft", StudentID: "u1", MongoAchievementID: primitive.NewObjectID().Hex(), Status: "draft"},
+		{ID: "missing-verified", StudentID: "u1", MongoAchievementID: primitive.NewObjectID().Hex(), Status: "verified"},
+		{ID: "doc-deleted", StudentID: "u1", MongoAchievementID: ids[1].Hex(), Status: "draft"},
+		{ID: "ref-deleted", StudentID: "u1", MongoAchievementID: ids[2].Hex(), Status: "draft", DeletedAt: &deleted},
+		{ID: "both-deleted", StudentID: "u1", MongoAchievementID: ids[3].Hex(), Status: "draft", DeletedAt: &deleted},
+		{ID: "wrong-owner", StudentID: "u1", MongoAchievementID: ids[4].Hex(), Status: "verified", Points: 15},
+		{ID: "in-flight", StudentID: "u1", MongoAchievementID: primitive.NewObjectID().Hex(), Status: "draft"},
+		{ID: "gone-and-deleted", StudentID: "u1", MongoAchievementID: primitive.NewObjectID().Hex(), DeletedAt: &deleted},
+	}
+	docs := []models.MongoAchievement{
+		{ID: ids[0], StudentID: "u1", Points: 20},
+		{ID: ids[1], StudentID: "u1", DeletedAt: &deleted},
+		{ID: ids[2], StudentID: "u1"},
+		{ID: ids[3], StudentID: "u1", DeletedAt: &deleted},
+		{ID: ids[4], StudentID: "admin", Points: 0},
+		{ID: ids[5], StudentID: "u2", Title: "Orphan"},
+		{ID: ids[6], StudentID: "u2", DeletedAt: &deleted}, // deleted orphans are harmless
+	}
+
+	findings, skipped := findAchievementInconsistencies(refs, docs, map[string]bool{"in-flight": true})
+
+	assert.Equal(t, 1, skipped)
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.issue.Kind+":"+f.issue.AchievementID)
+	}
+	assert.Equal(t, []string{
+		models.ReconcileMissingDocument + ":missing-draft",
+		models.ReconcileMissingDocument + ":missing-verified",
+		models.ReconcileDocumentDeleted + ":doc-deleted",
+		models.ReconcileReferenceDeleted + ":ref-deleted",
+		models.ReconcileStudentMismatch + ":wrong-owner",
+		models.ReconcilePointsMismatch + ":wrong-owner",
+		models.ReconcileOrphanDocument + ":",
+	}, got)
+
+	assert.NotEmpty(t, findings[0].issue.Repair, "missing drafts are repaired")
+	assert.Empty(t, findings[1].issue.Repair, "missing verified achievements need a person")
+	assert.Equal(t, "u1", findings[4].studentID)
+	assert.Equal(t, 15, findings[5].points)
+	assert.Equal(t, ids[5].Hex(), findings[6].issue.MongoID)
+}
+
+// TestFailedOutboxFindings tests how failed outbox entries are reported
+func TestFailedOutboxFindings(t *testing.T) {
+	entries := []models.OutboxEntry{
+		{ID: 7, AchievementID: "a1", MongoID: "m1", Operation: models.OutboxOpUpdate, Attempts: 20, LastError: "connection refused"},
+		{ID: 9, AchievementID: "a2", MongoID: "m2", Operation: models.OutboxOpNone, Attempts: 20, LastError: "timeout"},
+	}
+
+	findings := failedOutboxFindings(entries, map[int64]bool{9: true})
+	assert.Len(t, findings, 2)
+	assert.Equal(t, models.ReconcileFailedOutbox, findings[0].issue.Kind)
+	assert.Equal(t, int64(7), findings[0].issue.OutboxEntryID)
+	assert.Equal(t, "outbox entry 7 (update) failed after 20 attempts: connection refused", findings[0].issue.Detail)
+	assert.NotEmpty(t, findings[0].issue.Repair)
+	assert.Empty(t, findings[1].issue.Repair, "entries with newer applied changes need a person")
+	assert.Contains(t, findings[1].issue.Detail, "(events only)")
+}
+
+// TestReconcileSummary tests the one-line report summary
+func TestReconcileSummary(t *testing.T) {
+	report := &models.ReconcileReport{DryRun: true, ReferencesScanned: 10, DocumentsScanned: 11,
+		Issues: make([]models.ReconcileIssue, 2), NeedsManualAttention: 1}
+	assert.Equal(t, "dry run: 10 references and 11 documents scanned, 0 in flight skipped, 2 issues, 0 repaired, 0 failed, 1 need manual attention",
+		ReconcileSummary(report))
+}
@@ -0,0 +1,389 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+	"UAS/scheduler"
+	"UAS/utils"
+)
+
+// ReviewQueueService defines reviewer work queue operations
+type ReviewQueueService interface {
+	GetQueue(c *fiber.Ctx) error
+	ClaimAchievement(c *fiber.Ctx) error
+	ReleaseAchievement(c *fiber.Ctx) error
+	ListSLAs(c *fiber.Ctx) error
+	UpdateSLA(c *fiber.Ctx) error
+	ListEscalations(c *fiber.Ctx) error
+}
+
+type reviewQueueServiceImpl struct {
+	pgRepo       *repository.AchievementRepository
+	mongoRepo    *repository.MongoAchievementRepository
+	studentRepo  *repository.StudentRepository
+	lecturerRepo *repository.LecturerRepository
+	queueRepo    *repository.ReviewQueueRepository
+	userRepo     *repository.UserRepository
+	notifier     *notifier
+}
+
+func NewReviewQueueService() ReviewQueueService {
+	return newReviewQueueService()
+}
+
+func newReviewQueueService() *reviewQueueServiceImpl {
+	return &reviewQueueServiceImpl{
+		pgRepo:       repository.NewAchievementRepository(),
+		mongoRepo:    repository.NewMongoAchievementRepository(),
+		studentRepo:  repository.NewStudentRepository(),
+		lecturerRepo: repository.NewLecturerRepository(),
+		queueRepo:    repository.NewReviewQueueRepository(),
+		userRepo:     repository.NewUserRepository(),
+		notifier:     newNotifier(),
+	}
+}
+
+// reviewDeadline returns the current review stage of a submitted achievement and when it is due
+// Legacy submissions without submitted_at are measured from their last update
+func reviewDeadline(achievement *models.AchievementReference, slaHours map[string]int) (string, time.Time) {
+	stage := models.ReviewStageSubmitted
+	start := achievement.SubmittedAt
+	if start.IsZero() {
+		start = achievement.UpdatedAt
+	}
+	if achievement.ClaimedBy != "" && achievement.ClaimedAt != nil {
+		stage = models.ReviewStageClaimed
+		start = *achievement.ClaimedAt
+	}
+	return stage, start.Add(time.Duration(slaHours[stage]) * time.Hour)
+}
+
+// slaHours loads the deadline of every stage, falling back to zero (no deadline) for missing stages
+func (s *reviewQueueServiceImpl) slaHours() map[string]int {
+	hours := make(map[string]int)
+	slas, err := s.queueRepo.FindSLAs()
+	if err != nil {
+		return hours
+	}
+	for _, sla := range slas {
+		hours[sla.Stage] = sla.DeadlineHours
+	}
+	return hours
+}
+
+// FunctionName godoc
+// @Summary Get review queue
+// @Description Get submitted achievements waiting for review, oldest submission first. Dosen Wali see their advisees, Admin sees everything
+// @Tags Review Queue
+// @Produce json
+// @Param claimed query string false "mine, unclaimed or empty for all"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /review-queue [get]
+// @Security Bearer
+func (s *reviewQueueServiceImpl) GetQueue(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	role := c.Locals("role").(string)
+
+	var studentIDs []string
+	switch role {
+	case "Admin":
+		// Admin reviews every submission
+	case "Dosen Wali":
+		lecturer, err := s.lecturerRepo.FindByUserID(userID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, "lecturer profile not found")
+		}
+		students, err := s.studentRepo.FindByAdvisorID(lecturer.ID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve advisees")
+		}
+		studentIDs = make([]string, 0, len(students))
+		for _, student := range students {
+			studentIDs = append(studentIDs, student.UserID)
+		}
+	default:
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "only reviewers have a review queue")
+	}
+
+	claimed := c.Query("claimed", "")
+	if claimed != "" && claimed != "mine" && claimed != "unclaimed" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "claimed must be mine or unclaimed")
+	}
+
+	pagination := utils.GetPaginationParams(c)
+	achievements, total, err := s.pgRepo.FindReviewQueue(studentIDs, claimed, userID, pagination.Offset, pagination.Limit)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve review queue")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	mongoIDs := make([]string, len(achievements))
+	ids := make([]string, len(achievements))
+	for i, a := range achievements {
+		mongoIDs[i] = a.MongoAchievementID
+		ids[i] = a.ID
+	}
+	details, err := s.mongoRepo.FindByIDs(ctx, mongoIDs)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve achievement details")
+	}
+	escalated, _ := s.queueRepo.FindEscalatedAchievementIDs(ids)
+
+	hours := s.slaHours()
+	now := time.Now()
+	items := make([]fiber.Map, 0, len(achievements))
+	for i := range achievements {
+		a := &achievements[i]
+		stage, deadline := reviewDeadline(a, hours)
+		detail := details[a.MongoAchievementID]
+		items = append(items, fiber.Map{
+			"id":               a.ID,
+			"student_id":       a.StudentID,
+			"title":            detail.Title,
+			"achievement_type": detail.AchievementType,
+			"submitted_at":     a.SubmittedAt,
+			"suggested_points": a.SuggestedPoints,
+			"duplicate_flags":  a.DuplicateFlags,
+			"claimed_by":       a.ClaimedBy,
+			"claimed_at":       a.ClaimedAt,
+			"claimed_by_me":    a.ClaimedBy == userID,
+			"stage":            stage,
+			"deadline":         deadline,
+			"overdue":          hours[stage] > 0 && now.After(deadline),
+			"escalated":        escalated[a.ID],
+		})
+	}
+
+	return utils.PaginatedResponse(c, fiber.Map{"queue": items}, total, pagination.Page, pagination.Limit)
+}
+
+// FunctionName godoc
+// @Summary Claim achievement for review
+// @Description Claim a submitted achievement so other reviewers do not review it at the same time
+// @Tags Review Queue
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /review-queue/{id}/claim [post]
+// @Security Bearer
+func (s *reviewQueueServiceImpl) ClaimAchievement(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	role := c.Locals("role").(string)
+
+	achievement, err := s.pgRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "achievement not found")
+	}
+	if achievement.Status != "submitted" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "only submitted achievements can be claimed")
+	}
+	if rerr := authorizeReview(s.studentRepo, s.lecturerRepo, achievement, userID, role, "claim"); rerr != nil {
+		return utils.ErrorResponse(c, rerr.code, rerr.message)
+	}
+
+	claimed, err := s.pgRepo.Claim(achievement.ID, userID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to claim achievement")
+	}
+	if !claimed {
+		return utils.ErrorResponse(c, fiber.StatusConflict, "achievement is claimed by another reviewer")
+	}
+
+	return utils.SuccessResponse(c, "achievement claimed", fiber.Map{"id": achievement.ID, "claimed_by": userID})
+}
+
+// FunctionName godoc
+// @Summary Release claimed achievement
+// @Description Give a claimed achievement back to the queue. Only the claimant or an Admin can release it
+// @Tags Review Queue
+// @Produce json
+// @Param id path string true "Achievement ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /review-queue/{id}/release [post]
+// @Security Bearer
+func (s *reviewQueueServiceImpl) ReleaseAchievement(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	role := c.Locals("role").(string)
+
+	achievement, err := s.pgRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "achievement not found")
+	}
+	if achievement.ClaimedBy == "" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "achievement is not claimed")
+	}
+	if achievement.ClaimedBy != userID && role != "Admin" {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "only the claimant can release this achievement")
+	}
+
+	if err := s.pgRepo.Release(achievement.ID); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to release achievement")
+	}
+
+	return utils.SuccessResponse(c, "achievement released", fiber.Map{"id": achievement.ID})
+}
+
+// FunctionName godoc
+// @Summary List review SLAs
+// @Description Get the review deadline of every stage in hours
+// @Tags Review Queue
+// @Produce json
+// @Success 200 {array} models.ReviewSLA
+// @Router /review-queue/sla [get]
+// @Security Bearer
+func (s *reviewQueueServiceImpl) ListSLAs(c *fiber.Ctx) error {
+	slas, err := s.queueRepo.FindSLAs()
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve review SLAs")
+	}
+	return utils.SuccessResponse(c, "review SLAs retrieved successfully", slas)
+}
+
+// FunctionName godoc
+// @Summary Update review SLA
+// @Description Change the deadline of a review stage (submitted or claimed). Zero disables escalation for the stage (Admin only)
+// @Tags Review Queue
+// @Accept json
+// @Produce json
+// @Param stage path string true "Review stage"
+// @Param body body models.UpdateReviewSLARequest true "Deadline in hours"
+// @Success 200 {object} models.ReviewSLA
+// @Failure 400 {object} map[string]interface{}
+// @Router /review-queue/sla/{stage} [put]
+// @Security Bearer
+func (s *reviewQueueServiceImpl) UpdateSLA(c *fiber.Ctx) error {
+	stage := c.Params("stage")
+	if stage != models.ReviewStageSubmitted && stage != models.ReviewStageClaimed {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "unknown review stage: "+stage)
+	}
+
+	var req models.UpdateReviewSLARequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "invalid request body")
+	}
+	if req.DeadlineHours < 0 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "deadline_hours cannot be negative")
+	}
+
+	sla := &models.ReviewSLA{
+		Stage:         stage,
+		DeadlineHours: req.DeadlineHours,
+		UpdatedBy:     c.Locals("userID").(string),
+		UpdatedAt:     time.Now(),
+	}
+	if err := s.queueRepo.SaveSLA(sla); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to update review SLA")
+	}
+
+	return utils.SuccessResponse(c, "review SLA updated successfully", sla)
+}
+
+// FunctionName godoc
+// @Summary List review escalations
+// @Description Get achievements escalated for passing their review deadline, newest first (Admin only)
+// @Tags Review Queue
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} map[string]interface{}
+// @Router /review-queue/escalations [get]
+// @Security Bearer
+func (s *reviewQueueServiceImpl) ListEscalations(c *fiber.Ctx) error {
+	pagination := utils.GetPaginationParams(c)
+	escalations, total, err := s.queueRepo.FindEscalations(pagination.Offset, pagination.Limit)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve escalations")
+	}
+	return utils.PaginatedResponse(c, fiber.Map{"escalations": escalations}, total, pagination.Page, pagination.Limit)
+}
+
+// RegisterReviewQueueJobs registers the review escalation job
+func RegisterReviewQueueJobs(s *scheduler.Scheduler) {
+	s.Register(scheduler.Job{
+		Name:        "review-escalation",
+		Schedule:    "*/15 * * * *",
+		Description: "Escalate submitted achievements past their review SLA deadline to the admins",
+		Run: func(ctx context.Context) (string, error) {
+			escalated, err := EscalateOverdueReviews()
+			return fmt.Sprintf("%d reviews escalated", escalated), err
+		},
+	})
+}
+
+// EscalateOverdueReviews escalates submitted achievements past their review deadline to the admins
+// Each deadline is escalated once, so it is safe to run repeatedly
+func EscalateOverdueReviews() (int, error) {
+	return newReviewQueueService().escalateOverdue()
+}
+
+func (s *reviewQueueServiceImpl) escalateOverdue() (int, error) {
+	achievements, err := s.pgRepo.FindByStatus("submitted")
+	if err != nil {
+		return 0, err
+	}
+
+	hours := s.slaHours()
+	now := time.Now()
+	escalated := 0
+	admins, err := s.userRepo.FindActiveIDsByRoleName("Admin")
+	if err != nil {
+		log.Println("Failed to load admins for escalation notifications:", err)
+	}
+	for i := range achievements {
+		a := &achievements[i]
+		stage, deadline := reviewDeadline(a, hours)
+		if hours[stage] <= 0 || !now.After(deadline) {
+			continue
+		}
+
+		reviewerID := s.responsibleReviewer(a)
+		created, err := s.queueRepo.CreateEscalation(&models.ReviewEscalation{
+			ID:            uuid.New().String(),
+			AchievementID: a.ID,
+			Stage:         stage,
+			Deadline:      deadline,
+			ReviewerID:    reviewerID,
+			EscalatedTo:   "Admin",
+			CreatedAt:     now,
+		})
+		if err != nil {
+			log.Println("Failed to escalate achievement "+a.ID+":", err)
+			continue
+		}
+		if created {
+			escalated++
+			log.Printf("Review of achievement %s escalated to Admin: %s stage overdue since %s", a.ID, stage, deadline.Format(time.RFC3339))
+			s.notifier.notify(models.NotificationReviewEscalated, append([]string{reviewerID}, admins...),
+				"Review overdue",
+				"An achievement review has been in the "+stage+" stage past its deadline of "+deadline.Format("2006-01-02 15:04")+".",
+				map[string]interface{}{"achievement_id": a.ID, "stage": stage, "deadline": deadline})
+		}
+	}
+	return escalated, nil
+}
+
+// responsibleReviewer returns the claimant, or the user ID of the student's advisor when unclaimed
+func (s *reviewQueueServiceImpl) responsibleReviewer(achievement *models.AchievementReference) string {
+	if achievement.ClaimedBy != "" {
+		return achievement.ClaimedBy
+	}
+	return advisorUserID(s.studentRepo, s.lecturerRepo, achievement.StudentID)
+}
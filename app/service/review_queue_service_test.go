@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"UAS/app/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReviewDeadline tests SLA deadline calculation per review stage
+func TestReviewDeadline(t *testing.T) {
+	hours := map[string]int{models.ReviewStageSubmitted: 72, models.ReviewStageClaimed: 48}
+	submittedAt := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	claimedAt := submittedAt.Add(24 * time.Hour)
+
+	testCases := []struct {
+		name             string
+		achievement      models.AchievementReference
+		expectedStage    string
+		expectedDeadline time.Time
+	}{
+		{
+			name:             "Unclaimed submission",
+			achievement:      models.AchievementReference{SubmittedAt: submittedAt},
+			expectedStage:    models.ReviewStageSubmitted,
+			expectedDeadline: submittedAt.Add(72 * time.Hour),
+		},
+		{
+			name:             "Claimed submission",
+			achievement:      models.AchievementReference{SubmittedAt: submittedAt, ClaimedBy: "reviewer", ClaimedAt: &claimedAt},
+			expectedStage:    models.ReviewStageClaimed,
+			expectedDeadline: claimedAt.Add(48 * time.Hour),
+		},
+		{
+			name:             "Legacy submission without submitted_at",
+			achievement:      models.AchievementReference{UpdatedAt: submittedAt},
+			expectedStage:    models.ReviewStageSubmitted,
+			expectedDeadline: submittedAt.Add(72 * time.Hour),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stage, deadline := reviewDeadline(&tc.achievement, hours)
+			assert.Equal(t, tc.expectedStage, stage)
+			assert.Equal(t, tc.expectedDeadline, deadline)
+		})
+	}
+}
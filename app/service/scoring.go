@@ -0,0 +1,102 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"UAS/app/models"
+)
+
+// calculatePoints applies a rubric to an achievement and returns suggested points with a breakdown
+// teamSize overrides details.team_size when greater than zero
+//
+// points = (base * level multiplier + rank + medal + indexation + position bonuses) * team size multiplier
+func calculatePoints(rubric *models.ScoringRubric, achievementType *models.AchievementType, details map[string]interface{}, teamSize int) models.ScoreResult {
+	rules := rubric.Rules
+	result := models.ScoreResult{RubricVersion: rubric.Version}
+
+	base, ok := rules.TypeBasePoints[achievementType.Code]
+	if !ok {
+		base = achievementType.DefaultPoints
+	}
+	result.Breakdown = append(result.Breakdown, models.ScoreComponent{Rule: "type_base", Key: achievementType.Code, Points: base})
+
+	total := float64(base)
+
+	if level := detailKey(details, "competition_level"); level != "" {
+		if multiplier, ok := lookupFloat(rules.LevelMultipliers, level); ok {
+			total *= multiplier
+			result.Breakdown = append(result.Breakdown, models.ScoreComponent{Rule: "level_multiplier", Key: level, Factor: multiplier})
+		}
+	}
+
+	bonuses := []struct {
+		rule  string
+		table map[string]int
+		key   string
+	}{
+		{"rank_bonus", rules.RankBonus, detailKey(details, "rank")},
+		{"medal_bonus", rules.MedalBonus, detailKey(details, "medal_type")},
+		{"indexation_bonus", rules.IndexationBonus, detailKey(details, "indexation")},
+		{"position_bonus", rules.PositionBonus, detailKey(details, "position")},
+	}
+	for _, b := range bonuses {
+		if b.key == "" {
+			continue
+		}
+		if bonus, ok := lookupInt(b.table, b.key); ok {
+			total += float64(bonus)
+			result.Breakdown = append(result.Breakdown, models.ScoreComponent{Rule: b.rule, Key: b.key, Points: bonus})
+		}
+	}
+
+	if teamSize <= 0 {
+		if size, ok := toFloat(details["team_size"]); ok {
+			teamSize = int(size)
+		}
+	}
+	if teamSize > 1 && rules.TeamSizeDecay > 0 {
+		multiplier := math.Max(rules.MinTeamSizeMultiplier, 1-rules.TeamSizeDecay*float64(teamSize-1))
+		total *= multiplier
+		result.Breakdown = append(result.Breakdown, models.ScoreComponent{Rule: "team_size", Key: fmt.Sprintf("%d", teamSize), Factor: multiplier})
+	}
+
+	result.Points = int(math.Round(total))
+	return result
+}
+
+// detailKey returns a normalized lookup key for a details value
+func detailKey(details map[string]interface{}, name string) string {
+	switch v := details[name].(type) {
+	case string:
+		return strings.ToLower(strings.TrimSpace(v))
+	case float64:
+		return fmt.Sprintf("%d", int(v))
+	case int:
+		return fmt.Sprintf("%d", v)
+	case int32:
+		return fmt.Sprintf("%d", v)
+	case int64:
+		return fmt.Sprintf("%d", v)
+	}
+	return ""
+}
+
+func lookupInt(table map[string]int, key string) (int, bool) {
+	for k, v := range table {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+func lookupFloat(table map[string]float64, key string) (float64, bool) {
+	for k, v := range table {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+	"UAS/utils"
+)
+
+// ScoringService defines scoring rubric management operations
+type ScoringService interface {
+	ListRubrics(c *fiber.Ctx) error
+	GetActiveRubric(c *fiber.Ctx) error
+	CreateRubric(c *fiber.Ctx) error
+	RecomputeScores(c *fiber.Ctx) error
+}
+
+type scoringServiceImpl struct {
+	rubricRepo   *repository.ScoringRubricRepository
+	pgRepo       *repository.AchievementRepository
+	mongoRepo    *repository.MongoAchievementRepository
+	scorer       *pointsScorer
+	participants *participantManager
+	outbox       *achievementOutbox
+}
+
+func NewScoringService() ScoringService {
+	return &scoringServiceImpl{
+		rubricRepo:   repository.NewScoringRubricRepository(),
+		pgRepo:       repository.NewAchievementRepository(),
+		mongoRepo:    repository.NewMongoAchievementRepository(),
+		scorer:       newPointsScorer(),
+		participants: newParticipantManager(),
+		outbox:       newAchievementOutbox(),
+	}
+}
+
+// pointsScorer computes suggested points for achievements using the active rubric
+type pointsScorer struct {
+	rubricRepo *repository.ScoringRubricRepository
+	typeRepo   *repository.AchievementTypeRepository
+}
+
+func newPointsScorer() *pointsScorer {
+	return &pointsScorer{
+		rubricRepo: repository.NewScoringRubricRepository(),
+		typeRepo:   repository.NewAchievementTypeRepository(),
+	}
+}
+
+// score computes suggested points for an achievement document with the active rubric
+func (p *pointsScorer) score(mongoAch *models.MongoAchievement, teamSize int) (models.ScoreResult, error) {
+	rubric, err := p.rubricRepo.FindActive()
+	if err != nil {
+		return models.ScoreResult{}, errors.New("no active scoring rubric")
+	}
+	return p.scoreWith(rubric, mongoAch, teamSize)
+}
+
+// scoreWith computes suggested points for an achievement document with a specific rubric
+func (p *pointsScorer) scoreWith(rubric *models.ScoringRubric, mongoAch *models.MongoAchievement, teamSize int) (models.ScoreResult, error) {
+	achievementType, err := p.typeRepo.FindByCode(mongoAch.AchievementType)
+	if err != nil {
+		// Unknown legacy types still get scored from the rubric tables only
+		achievementType = &models.AchievementType{Code: mongoAch.AchievementType}
+	}
+	return calculatePoints(rubric, achievementType, mongoAch.Details, teamSize), nil
+}
+
+// FunctionName godoc
+// @Summary List scoring rubrics
+// @Description Get all scoring rubric versions, newest first (Admin only)
+// @Tags Scoring
+// @Produce json
+// @Success 200 {array} models.ScoringRubric
+// @Failure 500 {object} map[string]interface{}
+// @Router /scoring/rubrics [get]
+// @Security Bearer
+func (s *scoringServiceImpl) ListRubrics(c *fiber.Ctx) error {
+	rubrics, err := s.rubricRepo.FindAll()
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve rubrics")
+	}
+	return utils.SuccessResponse(c, "rubrics retrieved successfully", rubrics)
+}
+
+// FunctionName godoc
+// @Summary Get active scoring rubric
+// @Description Get the rubric currently used to compute suggested points
+// @Tags Scoring
+// @Produce json
+// @Success 200 {object} models.ScoringRubric
+// @Failure 404 {object} map[string]interface{}
+// @Router /scoring/rubrics/active [get]
+// @Security Bearer
+func (s *scoringServiceImpl) GetActiveRubric(c *fiber.Ctx) error {
+	rubric, err := s.rubricRepo.FindActive()
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "no active rubric")
+	}
+	return utils.SuccessResponse(c, "active rubric retrieved successfully", rubric)
+}
+
+// FunctionName godoc
+// @Summary Publish scoring rubric
+// @Description Publish a new rubric version and make it active. Existing scores are not changed until recompute is run (Admin only)
+// @Tags Scoring
+// @Accept json
+// @Produce json
+// @Param body body models.CreateRubricRequest true "Rubric rules"
+// @Success 201 {object} models.ScoringRubric
+// @Failure 400 {object} map[string]interface{}
+// @Router /scoring/rubrics [post]
+// @Security Bearer
+func (s *scoringServiceImpl) CreateRubric(c *fiber.Ctx) error {
+	var req models.CreateRubricRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "invalid request body")
+	}
+
+	if req.Rules.TeamSizeDecay < 0 || req.Rules.TeamSizeDecay > 1 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "team_size_decay must be between 0 and 1")
+	}
+	if req.Rules.MinTeamSizeMultiplier < 0 || req.Rules.MinTeamSizeMultiplier > 1 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "min_team_size_multiplier must be between 0 and 1")
+	}
+
+	rubric := &models.ScoringRubric{
+		ID:          uuid.New().String(),
+		Description: req.Description,
+		Rules:       req.Rules,
+		CreatedBy:   c.Locals("userID").(string),
+		CreatedAt:   time.Now(),
+	}
+	if err := s.rubricRepo.CreateActiveVersion(rubric); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to create rubric")
+	}
+
+	return utils.CreatedResponse(c, "rubric published successfully", rubric)
+}
+
+// FunctionName godoc
+// @Summary Recompute achievement scores
+// @Description Recompute suggested points of achievements scored with an older rubric version. Verified achievements without a manual override also get their awarded points updated (Admin only)
+// @Tags Scoring
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /scoring/recompute [post]
+// @Security Bearer
+func (s *scoringServiceImpl) RecomputeScores(c *fiber.Ctx) error {
+	rubric, err := s.rubricRepo.FindActive()
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "no active rubric")
+	}
+
+	achievements, err := s.pgRepo.FindScoredBeforeVersion(rubric.Version)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve achievements")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	updated, pointsChanged, failed := 0, 0, 0
+	for _, ach := range achievements {
+		mongoAch, err := s.mongoRepo.FindByID(ctx, ach.MongoAchievementID)
+		if err != nil {
+			failed++
+			continue
+		}
+
+		result, _ := s.scorer.scoreWith(rubric, mongoAch, s.participants.acceptedCount(ach.ID))
+
+		// Awarded points follow the rubric unless a verifier overrode them; changed points and
+		// their participant split are committed together with the score and reach MongoDB
+		// through the outbox
+		points := ach.Points
+		var entry *models.OutboxEntry
+		var outbox func(tx *gorm.DB) error
+		if ach.Status == "verified" && !ach.PointsOverridden && points != result.Points {
+			points = result.Points
+			split, err := s.participants.pointSplit(ach.ID, points)
+			if err != nil {
+				failed++
+				continue
+			}
+			entry = newOutboxEntry(&ach, models.OutboxOpPoints, models.OutboxChange{Points: points})
+			record := s.outbox.record(entry)
+			outbox = func(tx *gorm.DB) error {
+				if err := split(tx); err != nil {
+					return err
+				}
+				return record(tx)
+			}
+		}
+
+		if err := s.pgRepo.UpdateScore(ach.ID, points, result.Points, result.RubricVersion, ach.PointsOverridden, ach.OverrideReason, outbox); err != nil {
+			failed++
+			continue
+		}
+		if entry != nil {
+			s.outbox.flush(entry)
+			pointsChanged++
+		}
+		updated++
+	}
+
+	return utils.SuccessResponse(c, "scores recomputed", fiber.Map{
+		"rubric_version": rubric.Version,
+		"scanned":        len(achievements),
+		"updated":        updated,
+		"points_changed": pointsChanged,
+		"failed":         failed,
+	})
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"testing"
+
+	"UAS/app/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRubric() *models.ScoringRubric {
+	return &models.ScoringRubric{
+		Version: 2,
+		Rules: models.RubricRules{
+			TypeBasePoints:        map[string]int{"publication": 30},
+			LevelMultipliers:      map[string]float64{"national": 3, "international": 4},
+			RankBonus:             map[string]int{"1": 15, "2": 10},
+			MedalBonus:            map[string]int{"gold": 15},
+			IndexationBonus:       map[string]int{"scopus_q1": 50},
+			PositionBonus:         map[string]int{"ketua": 15},
+			TeamSizeDecay:         0.1,
+			MinTeamSizeMultiplier: 0.5,
+		},
+	}
+}
+
+// TestCalculatePoints tests the rubric scoring engine
+func TestCalculatePoints(t *testing.T) {
+	competition := &models.AchievementType{Code: "competition", DefaultPoints: 20}
+	publication := &models.AchievementType{Code: "publication", DefaultPoints: 25}
+	organization := &models.AchievementType{Code: "organization", DefaultPoints: 10}
+
+	testCases := []struct {
+		name           string
+		achievement    *models.AchievementType
+		details        map[string]interface{}
+		teamSize       int
+		expectedPoints int
+	}{
+		{
+			name:           "Base points from type default",
+			achievement:    competition,
+			details:        map[string]interface{}{},
+			expectedPoints: 20,
+		},
+		{
+			name:           "National winner with gold medal",
+			achievement:    competition,
+			details:        map[string]interface{}{"competition_level": "National", "rank": float64(1), "medal_type": "gold"},
+			expectedPoints: 20*3 + 15 + 15,
+		},
+		{
+			name:           "Team of three from details",
+			achievement:    competition,
+			details:        map[string]interface{}{"competition_level": "international", "team_size": float64(3)},
+			expectedPoints: 64, // 80 * 0.8
+		},
+		{
+			name:           "Team size parameter overrides details and respects minimum",
+			achievement:    competition,
+			details:        map[string]interface{}{"team_size": float64(2)},
+			teamSize:       10,
+			expectedPoints: 10, // 20 * 0.5
+		},
+		{
+			name:           "Rubric base overrides type default",
+			achievement:    publication,
+			details:        map[string]interface{}{"indexation": "scopus_q1"},
+			expectedPoints: 80,
+		},
+		{
+			name:           "Organization position",
+			achievement:    organization,
+			details:        map[string]interface{}{"position": " Ketua "},
+			expectedPoints: 25,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := calculatePoints(testRubric(), tc.achievement, tc.details, tc.teamSize)
+			assert.Equal(t, tc.expectedPoints, result.Points)
+			assert.Equal(t, 2, result.RubricVersion)
+			assert.NotEmpty(t, result.Breakdown)
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+	"UAS/scheduler"
+	"UAS/storage"
+)
+
+const (
+	// blobPrefix is the storage key prefix of content-addressed attachment files
+	blobPrefix = "blobs/sha256/"
+	// defaultStorageQuotaMB is the attachment storage of a student unless STORAGE_QUOTA_MB is set
+	defaultStorageQuotaMB = 1024
+	// blobGCGrace keeps unreferenced blobs a while, so outbox entries still pointing at them are
+	// applied before the file disappears
+	blobGCGrace = time.Hour
+	// blobGCBatch caps the blobs collected per run
+	blobGCBatch = 500
+)
+
+// errStorageQuota marks uploads that would take a student over their storage quota
+var errStorageQuota = errors.New("storage quota exceeded")
+
+// blobStore keeps attachment files by the SHA-256 of their content, so a certificate uploaded to
+// several drafts is stored once, and charges them to the storage quota of the achievement's owner
+type blobStore struct {
+	repo        *repository.BlobRepository
+	studentRepo *repository.StudentRepository
+	blobs       storage.BlobStore
+}
+
+func newBlobStore() *blobStore {
+	return &blobStore{
+		repo:        repository.NewBlobRepository(),
+		studentRepo: repository.NewStudentRepository(),
+		blobs:       storage.Default,
+	}
+}
+
+// RegisterBlobJobs registers the collection of blobs no attachment references any more
+func RegisterBlobJobs(s *scheduler.Scheduler) {
+	b := newBlobStore()
+	s.Register(scheduler.Job{
+		Name:        "blob-gc",
+		Schedule:    "30 * * * *",
+		Description: "Delete attachment files no attachment references any more",
+		Run: func(ctx context.Context) (string, error) {
+			deleted, err := b.collect(ctx, time.Now().Add(-blobGCGrace))
+			return fmt.Sprintf("%d unreferenced files deleted", deleted), err
+		},
+	})
+}
+
+// acquire references the blob with the attachment's content inside the transaction that records
+// the attachment, storing the content when the blob store does not have it yet. The owner's quota
+// is checked under a per-student lock; content the owner already has costs nothing. open supplies
+// the content and is only called for new content
+func (b *blobStore) acquire(ctx context.Context, tx *gorm.DB, achievement *models.AchievementReference, attachment *models.Attachment, open func() (io.ReadCloser, error)) error {
+	if err := b.repo.LockStudent(tx, achievement.StudentID); err != nil {
+		return err
+	}
+	if quota := b.quota(achievement.StudentID); quota > 0 {
+		owned, err := b.repo.StudentHasContent(tx, achievement.StudentID, attachment.SHA256)
+		if err != nil {
+			return err
+		}
+		used, _, _, err := b.repo.Usage(tx, achievement.StudentID)
+		if err != nil {
+			return err
+		}
+		if !owned && used+attachment.FileSize > quota {
+			return quotaExceeded(used, attachment.FileSize, quota)
+		}
+	}
+
+	blob := &models.StoredBlob{
+		SHA256:      attachment.SHA256,
+		StorageKey:  blobKey(attachment.SHA256),
+		Size:        attachment.FileSize,
+		ContentType: attachment.FileType,
+	}
+	if err := b.repo.Acquire(tx, blob); err != nil {
+		return err
+	}
+	err := b.repo.AddReference(tx, &models.BlobReference{
+		AttachmentID:  attachment.ID,
+		AchievementID: achievement.ID,
+		StudentID:     achievement.StudentID,
+		SHA256:        attachment.SHA256,
+		Size:          attachment.FileSize,
+	})
+	if err != nil {
+		return err
+	}
+	// A quarantined blob is not restored; the malware scan marks the attachment infected
+	attachment.StorageKey = blob.StorageKey
+	if blob.Quarantined || blob.RefCount > 1 {
+		return nil
+	}
+
+	// New or collected content. A blob being collected is locked until its file is deleted, so
+	// checking for the file here cannot race with the collector
+	if _, err := b.blobs.Stat(ctx, blob.StorageKey); err == nil {
+		return nil
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	r, err := open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return b.blobs.Put(ctx, blob.StorageKey, r, blob.Size, blob.ContentType)
+}
+
+// release drops the reference of an attachment inside the transaction that removes it
+func (b *blobStore) release(tx *gorm.DB, attachment *models.Attachment) error {
+	return b.repo.Release(tx, attachment.ID)
+}
+
+// releaseAchievement drops the references of all attachments of an achievement being deleted
+func (b *blobStore) releaseAchievement(tx *gorm.DB, achievementID string) error {
+	return b.repo.ReleaseAchievement(tx, achievementID)
+}
+
+// usage reports the storage used by a student, identified by user ID
+func (b *blobStore) usage(studentID string) (*models.StorageUsage, error) {
+	used, files, attachments, err := b.repo.Usage(nil, studentID)
+	if err != nil {
+		return nil, err
+	}
+	usage := &models.StorageUsage{
+		StudentID:   studentID,
+		UsedBytes:   used,
+		QuotaBytes:  b.quota(studentID),
+		Files:       files,
+		Attachments: attachments,
+	}
+	if usage.QuotaBytes > 0 {
+		remaining := max(usage.QuotaBytes-used, 0)
+		usage.RemainingBytes = &remaining
+	}
+	return usage, nil
+}
+
+// checkQuota reports an upload of size bytes that cannot fit in a student's quota, before the
+// file is sent. Duplicates of files the student has are not known yet and count in full
+func (b *blobStore) checkQuota(studentID string, size int64) error {
+	usage, err := b.usage(studentID)
+	if err != nil {
+		return err
+	}
+	if usage.QuotaBytes > 0 && usage.UsedBytes+size > usage.QuotaBytes {
+		return quotaExceeded(usage.UsedBytes, size, usage.QuotaBytes)
+	}
+	return nil
+}
+
+// quota returns the storage quota of a student in bytes, 0 for unlimited
+func (b *blobStore) quota(studentID string) int64 {
+	if student, err := b.studentRepo.FindByUserID(studentID); err == nil && student.StorageQuota != nil {
+		return *student.StorageQuota
+	}
+	return defaultStorageQuota()
+}
+
+// collect deletes the files and records of blobs unreferenced since before. Quarantined files are
+// kept for inspection, only their record goes
+func (b *blobStore) collect(ctx context.Context, before time.Time) (int, error) {
+	candidates, err := b.repo.FindUnreferenced(before, blobGCBatch)
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, candidate := range candidates {
+		ok, err := b.repo.DeleteUnreferenced(candidate.SHA256, func(blob *models.StoredBlob) error {
+			if blob.Quarantined {
+				return nil
+			}
+			return b.blobs.Delete(ctx, blob.StorageKey)
+		})
+		if err != nil {
+			return deleted, err
+		}
+		if ok {
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// defaultStorageQuota returns STORAGE_QUOTA_MB in bytes; 0 disables the quota
+func defaultStorageQuota() int64 {
+	if mb, err := strconv.ParseInt(os.Getenv("STORAGE_QUOTA_MB"), 10, 64); err == nil && mb >= 0 {
+		return mb << 20
+	}
+	return defaultStorageQuotaMB << 20
+}
+
+// blobKey returns the storage key of the content with the given SHA-256
+func blobKey(sha256 string) string {
+	return blobPrefix + sha256[:2] + "/" + sha256
+}
+
+// isBlobKey reports whether a storage key is content-addressed, also after quarantine. Such files
+// are shared and only removed by the collector; older attachments own their file
+func isBlobKey(key string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(key, quarantinePrefix), blobPrefix)
+}
+
+// quotaExceeded describes an upload of size bytes that does not fit in quota with used taken
+func quotaExceeded(used, size, quota int64) error {
+	return fmt.Errorf("%w: %s of %s used, the file needs %s", errStorageQuota, formatBytes(used), formatBytes(quota), formatBytes(size))
+}
+
+// formatBytes formats a size in MB, or KB for small sizes
+func formatBytes(n int64) string {
+	if n < 1<<20 {
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+}
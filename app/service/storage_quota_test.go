@@ -0,0 +1,57 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"UAS/app/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSHA256 = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+// TestBlobKey tests content-addressed storage keys
+func TestBlobKey(t *testing.T) {
+	key := blobKey(testSHA256)
+	assert.Equal(t, "blobs/sha256/9f/"+testSHA256, key)
+	assert.True(t, isBlobKey(key))
+	assert.True(t, isBlobKey(quarantineKey(key)))
+	assert.False(t, isBlobKey("achievements/a1/f1.pdf"))
+	assert.False(t, isBlobKey(quarantineKey("achievements/a1/f1.pdf")))
+}
+
+// TestAttachmentBlobKeysShared tests that shared files are released rather than deleted
+func TestAttachmentBlobKeysShared(t *testing.T) {
+	attachment := &models.Attachment{ID: "f1", StorageKey: blobKey(testSHA256), SHA256: testSHA256}
+	assert.Empty(t, attachmentBlobKeys(attachment))
+
+	attachment.Preview = &models.AttachmentPreview{StorageKey: previewKey("a1", "f1")}
+	assert.Equal(t, []string{"previews/achievements/a1/f1.jpg"}, attachmentBlobKeys(attachment))
+}
+
+// TestQuotaExceeded tests the quota error and its response
+func TestQuotaExceeded(t *testing.T) {
+	err := quotaExceeded(1000<<20, 30<<20, 1024<<20)
+	assert.True(t, errors.Is(err, errStorageQuota))
+	assert.Equal(t, "storage quota exceeded: 1000.0MB of 1024.0MB used, the file needs 30.0MB", err.Error())
+
+	rerr := attachError(err)
+	assert.Equal(t, 413, rerr.code)
+	assert.Equal(t, err.Error(), rerr.message)
+	assert.Equal(t, 500, attachError(errors.New("connection refused")).code)
+
+	assert.Equal(t, "512.0KB", formatBytes(512<<10))
+}
+
+// TestDefaultStorageQuota tests the quota configured with STORAGE_QUOTA_MB
+func TestDefaultStorageQuota(t *testing.T) {
+	t.Setenv("STORAGE_QUOTA_MB", "")
+	assert.Equal(t, int64(1024<<20), defaultStorageQuota())
+	t.Setenv("STORAGE_QUOTA_MB", "2048")
+	assert.Equal(t, int64(2048<<20), defaultStorageQuota())
+	t.Setenv("STORAGE_QUOTA_MB", "0")
+	assert.Equal(t, int64(0), defaultStorageQuota())
+	t.Setenv("STORAGE_QUOTA_MB", "-5")
+	assert.Equal(t, int64(1024<<20), defaultStorageQuota())
+}
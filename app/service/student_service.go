@@ -21,6 +21,8 @@ type StudentService interface {
 	UpdateStudentProfile(c *fiber.Ctx) error
 	GetStudentAchievements(c *fiber.Ctx) error
 	SetAdvisor(c *fiber.Ctx) error
+	GetStorageUsage(c *fiber.Ctx) error
+	SetStorageQuota(c *fiber.Ctx) error
 }
 
 type studentServiceImpl struct {
@@ -28,6 +30,9 @@ type studentServiceImpl struct {
 	userRepo     *repository.UserRepository
 	roleRepo     *repository.RoleRepository
 	lecturerRepo *repository.LecturerRepository
+	notifier     *notifier
+	webhooks     *webhookPublisher
+	store        *blobStore
 }
 
 func NewStudentService() StudentService {
@@ -36,6 +41,9 @@ func NewStudentService() StudentService {
 		userRepo:     repository.NewUserRepository(),
 		roleRepo:     repository.NewRoleRepository(),
 		lecturerRepo: repository.NewLecturerRepository(),
+		notifier:     newNotifier(),
+		webhooks:     newWebhookPublisher(),
+		store:        newBlobStore(),
 	}
 }
 
@@ -224,6 +232,7 @@ func (s *studentServiceImpl) SetAdvisor(c *fiber.Ctx) error {
 	}
 
 	// Set advisor_id to lecturer.ID (not user_id)
+	previousAdvisorID := student.AdvisorID
 	student.AdvisorID = lecturer.ID
 	student.UpdatedAt = time.Now()
 
@@ -231,6 +240,26 @@ func (s *studentServiceImpl) SetAdvisor(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to set advisor")
 	}
 
+	if previousAdvisorID != lecturer.ID {
+		recipients := []string{student.UserID, lecturer.UserID}
+		if previous, err := s.lecturerRepo.FindByID(previousAdvisorID); err == nil {
+			recipients = append(recipients, previous.UserID)
+		}
+		s.notifier.notify(models.NotificationAdvisorChanged, recipients,
+			"Advisor changed",
+			advisor.FullName+" is now the academic advisor of student "+student.StudentID+".",
+			map[string]interface{}{"student_id": student.ID, "advisor_id": lecturer.ID, "previous_advisor_id": previousAdvisorID})
+
+		s.webhooks.publish(models.WebhookAdvisorChanged, map[string]interface{}{
+			"student_id":          student.UserID,
+			"student_nim":         student.StudentID,
+			"advisor_id":          lecturer.ID,
+			"advisor_user_id":     lecturer.UserID,
+			"advisor_nip":         lecturer.LecturerID,
+			"previous_advisor_id": previousAdvisorID,
+		})
+	}
+
 	return utils.SuccessResponse(c, "advisor set successfully", fiber.Map{
 		"student_id":   student.ID,
 		"advisor_id":   lecturer.ID,
@@ -244,9 +273,10 @@ func (s *studentServiceImpl) SetAdvisor(c *fiber.Ctx) error {
 // @Description Get paginated list of all students. Dosen Wali only sees their advisees.
 // @Tags Students
 // @Produce json
-// @Param page query int false "Page number" default(1)
+// @Param cursor query string false "Cursor from the next/prev link of the previous page"
 // @Param limit query int false "Items per page" default(10)
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /students [get]
 // @Security Bearer
@@ -268,24 +298,25 @@ func (s *studentServiceImpl) ListStudents(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to get user role")
 	}
 
+	params, err := utils.GetCursorParams(c, models.SortOldestFirst)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
 	// Fetch students based on role
-	var students []models.Student
+	advisorID := ""
 	if role.Name == "Dosen Wali" {
 		// Dosen Wali can only see their advisees
 		lecturer, err := s.lecturerRepo.FindByUserID(userID)
 		if err != nil {
 			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "lecturer profile not found")
 		}
-		students, err = s.studentRepo.FindByAdvisorID(lecturer.ID)
-		if err != nil {
-			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to fetch advisees")
-		}
-	} else {
-		// Admin and others can see all students
-		students, err = s.studentRepo.FindAll()
-		if err != nil {
-			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to fetch students")
-		}
+		advisorID = lecturer.ID
+	}
+	// Admin and others can see all students
+	students, total, more, err := s.studentRepo.FindPage(advisorID, params.Cursor, params.Limit)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to fetch students")
 	}
 
 	// Enrich with user data
@@ -321,10 +352,15 @@ func (s *studentServiceImpl) ListStudents(c *fiber.Ctx) error {
 		})
 	}
 
-	return utils.SuccessResponse(c, "students retrieved successfully", fiber.Map{
-		"students": results,
-		"total":    len(results),
+	first, last := utils.PageBounds(students, func(student models.Student) *models.Cursor {
+		return &models.Cursor{Key: models.CursorTime(student.CreatedAt), ID: student.ID}
 	})
+	pagination := utils.CursorPagination(c, params, first, last, more)
+	pagination["total"] = total
+	return utils.CursorResponse(c, "students retrieved successfully", fiber.Map{
+		"students": results,
+		"total":    total,
+	}, pagination)
 }
 
 // FunctionName godoc
@@ -449,3 +485,86 @@ func (s *studentServiceImpl) GetStudentAchievements(c *fiber.Ctx) error {
 		"data": achievements,
 	})
 }
+
+// FunctionName godoc
+// @Summary Get student storage usage
+// @Description Attachment storage used by a student against their quota. Identical files count once. Students can only see their own usage, Dosen Wali their advisees'
+// @Tags Students
+// @Produce json
+// @Param id path string true "Student ID or User ID"
+// @Success 200 {object} models.StorageUsage
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /students/{id}/storage [get]
+// @Security Bearer
+func (s *studentServiceImpl) GetStorageUsage(c *fiber.Ctx) error {
+	id := c.Params("id")
+	student, err := s.studentRepo.FindByID(id)
+	if err != nil {
+		student, err = s.studentRepo.FindByUserID(id)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, "student not found")
+		}
+	}
+
+	userID := c.Locals("userID").(string)
+	switch c.Locals("role") {
+	case "Mahasiswa":
+		if student.UserID != userID {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "you can only view your own storage usage")
+		}
+	case "Dosen Wali":
+		lecturer, err := s.lecturerRepo.FindByUserID(userID)
+		if err != nil || student.AdvisorID != lecturer.ID {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "you can only view the storage usage of your own advisees")
+		}
+	}
+
+	usage, err := s.store.usage(student.UserID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to get storage usage")
+	}
+	return utils.SuccessResponse(c, "storage usage retrieved successfully", usage)
+}
+
+// FunctionName godoc
+// @Summary Set student storage quota
+// @Description Set the attachment storage quota of a student in bytes; 0 is unlimited and null restores the default (STORAGE_QUOTA_MB). Files already stored are kept when the quota is lowered
+// @Tags Students
+// @Accept json
+// @Produce json
+// @Param id path string true "Student UUID ID (from database, not NIM)"
+// @Param body body map[string]interface{} true "{\"quota_bytes\": 2147483648}"
+// @Success 200 {object} models.StorageUsage
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /students/{id}/storage-quota [put]
+// @Security Bearer
+func (s *studentServiceImpl) SetStorageQuota(c *fiber.Ctx) error {
+	var req struct {
+		QuotaBytes *int64 `json:"quota_bytes"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "invalid request body")
+	}
+	if req.QuotaBytes != nil && *req.QuotaBytes < 0 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "quota_bytes must not be negative")
+	}
+
+	student, err := s.studentRepo.FindByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, "student not found")
+		}
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to find student")
+	}
+	if err := s.studentRepo.SetStorageQuota(student.ID, req.QuotaBytes); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to set storage quota")
+	}
+
+	usage, err := s.store.usage(student.UserID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to get storage usage")
+	}
+	return utils.SuccessResponse(c, "storage quota set successfully", usage)
+}
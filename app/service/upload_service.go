@@ -0,0 +1,517 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+	"UAS/scheduler"
+	"UAS/storage"
+	"UAS/utils"
+)
+
+const (
+	// tusVersion is the tus protocol version the upload endpoint speaks
+	tusVersion = "1.0.0"
+	// tusExtensions are the supported tus extensions
+	tusExtensions = "creation,expiration,termination"
+	// maxUploadChunkSize caps the body of one PATCH request; clients must send larger files in chunks
+	maxUploadChunkSize = 10 * 1024 * 1024
+	// defaultUploadTTL is how long an unfinished upload is kept unless UPLOAD_EXPIRY is set
+	defaultUploadTTL = 24 * time.Hour
+	// uploadChunkPrefix is the storage key prefix of the chunks of unfinished uploads
+	uploadChunkPrefix = "tus/"
+)
+
+// UploadService implements resumable attachment uploads with the tus protocol (https://tus.io):
+// creation, expiration and termination extensions
+type UploadService interface {
+	UploadOptions(c *fiber.Ctx) error
+	CreateUpload(c *fiber.Ctx) error
+	GetUploadOffset(c *fiber.Ctx) error
+	PatchUpload(c *fiber.Ctx) error
+	DeleteUpload(c *fiber.Ctx) error
+}
+
+type uploadServiceImpl struct {
+	pgRepo   *repository.AchievementRepository
+	uploads  *repository.AttachmentUploadRepository
+	uploader *attachmentUploader
+	blobs    storage.BlobStore
+}
+
+func NewUploadService() UploadService {
+	return newUploadService()
+}
+
+func newUploadService() *uploadServiceImpl {
+	return &uploadServiceImpl{
+		pgRepo:   repository.NewAchievementRepository(),
+		uploads:  repository.NewAttachmentUploadRepository(),
+		uploader: newAttachmentUploader(),
+		blobs:    storage.Default,
+	}
+}
+
+// RegisterUploadJobs registers the hourly cleanup of expired uploads and their chunks
+func RegisterUploadJobs(s *scheduler.Scheduler) {
+	svc := newUploadService()
+	s.Register(scheduler.Job{
+		Name:        "upload-cleanup",
+		Schedule:    "0 * * * *",
+		Description: "Delete expired resumable attachment uploads and their stored chunks",
+		Run: func(ctx context.Context) (string, error) {
+			removed, err := svc.cleanupExpired(ctx, time.Now())
+			return fmt.Sprintf("%d expired uploads removed", removed), err
+		},
+	})
+}
+
+// FunctionName godoc
+// @Summary Resumable upload capabilities
+// @Description tus discovery: supported version, extensions and the largest accepted upload
+// @Tags Achievements
+// @Param id path string true "Achievement ID"
+// @Success 204
+// @Router /achievements/{id}/uploads [options]
+// @Security Bearer
+func (s *uploadServiceImpl) UploadOptions(c *fiber.Ctx) error {
+	c.Set("Tus-Resumable", tusVersion)
+	c.Set("Tus-Version", tusVersion)
+	c.Set("Tus-Extension", tusExtensions)
+	c.Set("Tus-Max-Size", strconv.FormatInt(maxAttachmentUploadSize, 10))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// FunctionName godoc
+// @Summary Start a resumable attachment upload
+// @Description tus creation. Upload-Length is the file size; Upload-Metadata carries filename (required), filetype, caption and kind. The declared type is checked against the achievement type's allowlist and its size limit; the content is checked again when the upload completes. Returns the upload URL in Location
+// @Tags Achievements
+// @Param id path string true "Achievement ID"
+// @Param Tus-Resumable header string true "1.0.0"
+// @Param Upload-Length header int true "File size in bytes"
+// @Param Upload-Metadata header string true "tus metadata, e.g. filename c2VydGlmaWthdC5wZGY="
+// @Success 201
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{} "File too large or storage quota exceeded"
+// @Failure 415 {object} map[string]interface{}
+// @Router /achievements/{id}/uploads [post]
+// @Security Bearer
+func (s *uploadServiceImpl) CreateUpload(c *fiber.Ctx) error {
+	if terr := tusRequest(c); terr != nil {
+		return utils.ErrorResponse(c, terr.code, terr.message)
+	}
+
+	length, err := strconv.ParseInt(c.Get("Upload-Length"), 10, 64)
+	if err != nil || length <= 0 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Upload-Length must be a positive number")
+	}
+	metadata, err := parseUploadMetadata(c.Get("Upload-Metadata"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if metadata["filename"] == "" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "filename metadata is required")
+	}
+	caption, kind, err := normalizeAttachmentMeta(metadata["caption"], metadata["kind"])
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	achievement, err := s.pgRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "achievement not found")
+	}
+	userID := c.Locals("userID").(string)
+	if aerr := authorizeAttachmentChange(achievement, userID, c.Locals("role").(string)); aerr != nil {
+		return utils.ErrorResponse(c, aerr.code, aerr.message)
+	}
+
+	// Refuse what cannot be accepted before the client sends hundreds of megabytes
+	declared := declaredAttachmentType(metadata["filename"], metadata["filetype"])
+	if declared == "" {
+		return utils.ErrorResponse(c, fiber.StatusUnsupportedMediaType, errAttachmentType.Error()+": only PDF, JPEG, PNG, WebP, MP4 and MOV files are accepted")
+	}
+	if !attachmentTypeAllowed(s.uploader.allowedTypes(c.UserContext(), achievement), declared) {
+		return utils.ErrorResponse(c, fiber.StatusUnsupportedMediaType, fmt.Sprintf("%s: %s files are not accepted for this achievement type", errAttachmentType, declared))
+	}
+	if length > attachmentSizeLimit(declared) {
+		return utils.ErrorResponse(c, fiber.StatusRequestEntityTooLarge, fmt.Sprintf("%s files are limited to %dMB", declared, attachmentSizeLimit(declared)>>20))
+	}
+	if err := s.uploader.store.checkQuota(achievement.StudentID, length); errors.Is(err, errStorageQuota) {
+		return utils.ErrorResponse(c, fiber.StatusRequestEntityTooLarge, err.Error())
+	} else if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to check storage quota")
+	}
+
+	upload := &models.AttachmentUpload{
+		ID:            uuid.New().String(),
+		AchievementID: achievement.ID,
+		UserID:        userID,
+		FileName:      metadata["filename"],
+		FileType:      declared,
+		Caption:       caption,
+		Kind:          kind,
+		Length:        length,
+		Status:        models.UploadInProgress,
+		ExpiresAt:     time.Now().Add(uploadTTL()),
+	}
+	if err := s.uploads.Create(upload); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to create upload")
+	}
+
+	c.Set(fiber.HeaderLocation, "/api/v1/achievements/"+achievement.ID+"/uploads/"+upload.ID)
+	c.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	return c.SendStatus(fiber.StatusCreated)
+}
+
+// FunctionName godoc
+// @Summary Resumable upload offset
+// @Description tus HEAD: how many bytes of the upload the server has, so an interrupted upload continues from there
+// @Tags Achievements
+// @Param id path string true "Achievement ID"
+// @Param uploadId path string true "Upload ID"
+// @Success 200
+// @Failure 404 {object} map[string]interface{}
+// @Failure 410 {object} map[string]interface{}
+// @Router /achievements/{id}/uploads/{uploadId} [head]
+// @Security Bearer
+func (s *uploadServiceImpl) GetUploadOffset(c *fiber.Ctx) error {
+	c.Set("Tus-Resumable", tusVersion)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	upload, lerr := s.loadUpload(c)
+	if lerr == nil {
+		lerr = uploadUnavailable(upload, time.Now())
+	}
+	if lerr != nil {
+		return c.SendStatus(lerr.code)
+	}
+
+	setUploadHeaders(c, upload)
+	c.Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// FunctionName godoc
+// @Summary Send a chunk of a resumable upload
+// @Description tus PATCH with Content-Type application/offset+octet-stream. Upload-Offset must equal the current offset and a chunk may be at most 10MB. The request that completes the upload validates the whole file, attaches it to the achievement with the upload ID as attachment ID and queues its malware scan
+// @Tags Achievements
+// @Accept octet-stream
+// @Param id path string true "Achievement ID"
+// @Param uploadId path string true "Upload ID"
+// @Param Upload-Offset header int true "Offset of this chunk"
+// @Success 204
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 410 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{}
+// @Failure 415 {object} map[string]interface{}
+// @Router /achievements/{id}/uploads/{uploadId} [patch]
+// @Security Bearer
+func (s *uploadServiceImpl) PatchUpload(c *fiber.Ctx) error {
+	if terr := tusRequest(c); terr != nil {
+		return utils.ErrorResponse(c, terr.code, terr.message)
+	}
+	if c.Get(fiber.HeaderContentType) != "application/offset+octet-stream" {
+		return utils.ErrorResponse(c, fiber.StatusUnsupportedMediaType, "Content-Type must be application/offset+octet-stream")
+	}
+	offset, err := strconv.ParseInt(c.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Upload-Offset must be a number")
+	}
+
+	upload, lerr := s.loadUpload(c)
+	if lerr == nil {
+		lerr = uploadUnavailable(upload, time.Now())
+	}
+	if lerr != nil {
+		return utils.ErrorResponse(c, lerr.code, lerr.message)
+	}
+	if offset != upload.Offset {
+		return utils.ErrorResponse(c, fiber.StatusConflict, "Upload-Offset does not match the upload, ask for the current offset with HEAD")
+	}
+	if upload.Status == models.UploadCompleted {
+		setUploadHeaders(c, upload)
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	body := c.Body()
+	if len(body) > maxUploadChunkSize {
+		return utils.ErrorResponse(c, fiber.StatusRequestEntityTooLarge, "chunks are limited to 10MB")
+	}
+	if offset+int64(len(body)) > upload.Length {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "chunk goes past Upload-Length")
+	}
+	if len(body) == 0 {
+		setUploadHeaders(c, upload)
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	// A chunk resent after a failure overwrites the stored one at the same offset
+	ctx := c.UserContext()
+	if err := s.blobs.Put(ctx, uploadChunkKey(upload.ID, offset), bytes.NewReader(body), int64(len(body)), "application/octet-stream"); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to store chunk")
+	}
+	chunks := append(upload.Chunks, offset)
+
+	if offset+int64(len(body)) == upload.Length {
+		return s.complete(c, upload, chunks)
+	}
+
+	upload.Chunks = chunks
+	upload.Offset = offset + int64(len(body))
+	upload.UpdatedAt = time.Now()
+	err = s.uploads.Advance(upload, offset)
+	if errors.Is(err, repository.ErrUploadOffsetMismatch) {
+		return utils.ErrorResponse(c, fiber.StatusConflict, "the upload moved on while this chunk was stored, ask for the current offset with HEAD")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to record chunk")
+	}
+
+	setUploadHeaders(c, upload)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// FunctionName godoc
+// @Summary Cancel a resumable upload
+// @Description tus termination: deletes an upload and its stored chunks. An attachment made from a completed upload is kept
+// @Tags Achievements
+// @Param id path string true "Achievement ID"
+// @Param uploadId path string true "Upload ID"
+// @Success 204
+// @Failure 404 {object} map[string]interface{}
+// @Router /achievements/{id}/uploads/{uploadId} [delete]
+// @Security Bearer
+func (s *uploadServiceImpl) DeleteUpload(c *fiber.Ctx) error {
+	if terr := tusRequest(c); terr != nil {
+		return utils.ErrorResponse(c, terr.code, terr.message)
+	}
+	upload, lerr := s.loadUpload(c)
+	if lerr != nil {
+		return utils.ErrorResponse(c, lerr.code, lerr.message)
+	}
+
+	s.deleteChunks(c.UserContext(), upload.ID, upload.Chunks)
+	if err := s.uploads.Delete(upload.ID); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to delete upload")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// complete joins the chunks of a fully received upload, validates and stores the file and records it
+// as an attachment, marking the upload completed in the same transaction. A storage or database
+// failure or a full storage quota leaves the upload at the offset of its last chunk so the client
+// can resend that chunk; a rejected file fails the upload
+func (s *uploadServiceImpl) complete(c *fiber.Ctx, upload *models.AttachmentUpload, chunks []int64) error {
+	ctx := c.UserContext()
+	achievement, err := s.pgRepo.FindByID(upload.AchievementID)
+	if err != nil {
+		return s.reject(c, upload, chunks, newReviewError(fiber.StatusNotFound, "achievement not found"))
+	}
+	if aerr := authorizeAttachmentChange(achievement, upload.UserID, c.Locals("role").(string)); aerr != nil {
+		return s.reject(c, upload, chunks, aerr)
+	}
+
+	keys := make([]string, 0, len(chunks))
+	for _, offset := range chunks {
+		keys = append(keys, uploadChunkKey(upload.ID, offset))
+	}
+	open := func() (io.ReadCloser, error) { return newChunkReader(ctx, s.blobs, keys), nil }
+	attachment, aerr := s.uploader.prepare(ctx, achievement, upload.ID, upload.FileName, open, upload.Length)
+	if aerr != nil {
+		if aerr.code >= fiber.StatusInternalServerError {
+			return utils.ErrorResponse(c, aerr.code, aerr.message)
+		}
+		return s.reject(c, upload, chunks, aerr)
+	}
+	attachment.Caption = upload.Caption
+	attachment.Kind = upload.Kind
+
+	err = s.uploader.attach(ctx, achievement, attachment, nil, func(tx *gorm.DB) error {
+		return s.uploads.Complete(tx, upload, upload.Offset)
+	})
+	if errors.Is(err, repository.ErrUploadOffsetMismatch) {
+		return utils.ErrorResponse(c, fiber.StatusConflict, "the upload was completed by another request")
+	}
+	if err != nil {
+		// Over quota the upload stays open, so it can be finished once space is freed
+		aerr := attachError(err)
+		return utils.ErrorResponse(c, aerr.code, aerr.message)
+	}
+
+	s.deleteChunks(ctx, upload.ID, chunks)
+	upload.Offset = upload.Length
+	upload.Status = models.UploadCompleted
+	setUploadHeaders(c, upload)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// reject fails an upload whose file cannot be accepted and removes its chunks
+func (s *uploadServiceImpl) reject(c *fiber.Ctx, upload *models.AttachmentUpload, chunks []int64, rerr *reviewError) error {
+	if err := s.uploads.MarkFailed(upload.ID, rerr.message); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to update upload")
+	}
+	s.deleteChunks(c.UserContext(), upload.ID, chunks)
+	return utils.ErrorResponse(c, rerr.code, rerr.message)
+}
+
+// loadUpload loads the upload in the uploadId parameter. Uploads are only visible to the user who
+// created them, under the achievement they belong to
+func (s *uploadServiceImpl) loadUpload(c *fiber.Ctx) (*models.AttachmentUpload, *reviewError) {
+	upload, err := s.uploads.FindByID(c.Params("uploadId"))
+	if err != nil || upload.AchievementID != c.Params("id") || upload.UserID != c.Locals("userID").(string) {
+		return nil, newReviewError(fiber.StatusNotFound, "upload not found")
+	}
+	return upload, nil
+}
+
+// cleanupExpired deletes uploads past their expiry together with their chunks
+func (s *uploadServiceImpl) cleanupExpired(ctx context.Context, now time.Time) (int, error) {
+	uploads, err := s.uploads.FindExpired(now)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for i := range uploads {
+		s.deleteChunks(ctx, uploads[i].ID, uploads[i].Chunks)
+		if err := s.uploads.Delete(uploads[i].ID); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// deleteChunks removes the stored chunks of an upload; a chunk left behind only costs storage
+func (s *uploadServiceImpl) deleteChunks(ctx context.Context, uploadID string, chunks []int64) {
+	for _, offset := range chunks {
+		s.blobs.Delete(ctx, uploadChunkKey(uploadID, offset))
+	}
+}
+
+// tusRequest checks the protocol version of a tus request and marks the response as tus
+func tusRequest(c *fiber.Ctx) *reviewError {
+	c.Set("Tus-Resumable", tusVersion)
+	if c.Get("Tus-Resumable") != tusVersion {
+		c.Set("Tus-Version", tusVersion)
+		return newReviewError(fiber.StatusPreconditionFailed, "unsupported tus version, use Tus-Resumable: "+tusVersion)
+	}
+	return nil
+}
+
+// uploadUnavailable explains why an upload cannot be continued, nil when it can
+func uploadUnavailable(upload *models.AttachmentUpload, now time.Time) *reviewError {
+	switch {
+	case upload.Status == models.UploadFailed:
+		return newReviewError(fiber.StatusGone, "upload was rejected: "+upload.Error)
+	case upload.Status == models.UploadInProgress && now.After(upload.ExpiresAt):
+		return newReviewError(fiber.StatusGone, "upload has expired")
+	}
+	return nil
+}
+
+// setUploadHeaders reports the offset of an upload and, while it can be continued, its expiry
+func setUploadHeaders(c *fiber.Ctx, upload *models.AttachmentUpload) {
+	c.Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	if upload.Status == models.UploadInProgress {
+		c.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+// parseUploadMetadata decodes the tus Upload-Metadata header: comma-separated pairs of a key and a
+// base64 value, the value being optional
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errors.New("invalid Upload-Metadata")
+		}
+		if _, dup := metadata[key]; dup {
+			return nil, fmt.Errorf("duplicate Upload-Metadata key %q", key)
+		}
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("Upload-Metadata value of %q is not base64", key)
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+// uploadTTL returns UPLOAD_EXPIRY (e.g. "48h"), how long an unfinished upload can be continued
+func uploadTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("UPLOAD_EXPIRY")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return defaultUploadTTL
+}
+
+// uploadChunkKey is the storage key of the chunk of an upload that starts at offset
+func uploadChunkKey(uploadID string, offset int64) string {
+	return fmt.Sprintf("%s%s/%020d", uploadChunkPrefix, uploadID, offset)
+}
+
+// chunkReader reads stored chunks one after the other as a single file
+type chunkReader struct {
+	ctx     context.Context
+	blobs   storage.BlobStore
+	keys    []string
+	current io.ReadCloser
+}
+
+func newChunkReader(ctx context.Context, blobs storage.BlobStore, keys []string) *chunkReader {
+	return &chunkReader{ctx: ctx, blobs: blobs, keys: keys}
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.keys) == 0 {
+				return 0, io.EOF
+			}
+			rc, _, err := r.blobs.Open(r.ctx, r.keys[0])
+			if err != nil {
+				return 0, err
+			}
+			r.current, r.keys = rc, r.keys[1:]
+		}
+		n, err := r.current.Read(p)
+		if errors.Is(err, io.EOF) {
+			r.current.Close()
+			r.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+// Close closes the chunk being read
+func (r *chunkReader) Close() error {
+	if r.current != nil {
+		return r.current.Close()
+	}
+	return nil
+}
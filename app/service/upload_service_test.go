@@ -0,0 +1,81 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"UAS/app/models"
+	"UAS/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseUploadMetadata tests decoding of the tus Upload-Metadata header
+func TestParseUploadMetadata(t *testing.T) {
+	metadata, err := parseUploadMetadata("filename c2VydGlmaWthdCBsb21iYS5wZGY=, filetype YXBwbGljYXRpb24vcGRm,caption")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"filename": "sertifikat lomba.pdf", "filetype": "application/pdf", "caption": ""}, metadata)
+
+	metadata, err = parseUploadMetadata("")
+	assert.NoError(t, err)
+	assert.Empty(t, metadata)
+
+	_, err = parseUploadMetadata("filename not-base64!")
+	assert.Error(t, err)
+	_, err = parseUploadMetadata("filename YQ==,filename Yg==")
+	assert.Error(t, err)
+	_, err = parseUploadMetadata("filename YQ==,,")
+	assert.Error(t, err)
+}
+
+// TestUploadUnavailable tests which uploads can no longer be continued
+func TestUploadUnavailable(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	upload := &models.AttachmentUpload{Status: models.UploadInProgress, ExpiresAt: now.Add(time.Hour)}
+	assert.Nil(t, uploadUnavailable(upload, now))
+
+	upload.ExpiresAt = now.Add(-time.Second)
+	assert.Equal(t, 410, uploadUnavailable(upload, now).code)
+
+	// A completed upload stays readable until it is cleaned up
+	upload.Status = models.UploadCompleted
+	assert.Nil(t, uploadUnavailable(upload, now))
+
+	upload = &models.AttachmentUpload{Status: models.UploadFailed, Error: "malformed video: the file is truncated", ExpiresAt: now.Add(time.Hour)}
+	rerr := uploadUnavailable(upload, now)
+	assert.Equal(t, 410, rerr.code)
+	assert.Contains(t, rerr.message, "the file is truncated")
+}
+
+// TestChunkReader tests that stored chunks are read back as one file
+func TestChunkReader(t *testing.T) {
+	ctx := context.Background()
+	blobs := &storage.LocalStore{Root: t.TempDir()}
+	var keys []string
+	var want []byte
+	for i, chunk := range []string{"first chunk,", "", "second,", "third"} {
+		key := uploadChunkKey("u1", int64(i))
+		assert.NoError(t, blobs.Put(ctx, key, bytes.NewReader([]byte(chunk)), int64(len(chunk)), "application/octet-stream"))
+		keys = append(keys, key)
+		want = append(want, chunk...)
+	}
+
+	r := newChunkReader(ctx, blobs, keys)
+	got, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.NoError(t, r.Close())
+
+	// A missing chunk fails the read
+	_, err = io.ReadAll(newChunkReader(ctx, blobs, append(keys, uploadChunkKey("u1", 99))))
+	assert.Error(t, err)
+}
+
+// TestUploadChunkKey tests that chunk keys sort by offset
+func TestUploadChunkKey(t *testing.T) {
+	assert.Equal(t, "tus/u1/00000000000010485760", uploadChunkKey("u1", 10<<20))
+	assert.Less(t, uploadChunkKey("u1", 9), uploadChunkKey("u1", 10))
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -33,6 +34,7 @@ type userServiceImpl struct {
 	studentRepo          *repository.StudentRepository
 	achievementRepo      *repository.AchievementRepository
 	mongoAchievementRepo *repository.MongoAchievementRepository
+	notifier             *notifier
 }
 
 func NewUserService() UserService {
@@ -42,6 +44,7 @@ func NewUserService() UserService {
 		studentRepo:          repository.NewStudentRepository(),
 		achievementRepo:      repository.NewAchievementRepository(),
 		mongoAchievementRepo: repository.NewMongoAchievementRepository(),
+		notifier:             newNotifier(),
 	}
 }
 
@@ -146,15 +149,18 @@ func (s *userServiceImpl) UpdateUser(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to find user")
 	}
 
+	var changed []string
 	if req.Email != "" && req.Email != user.Email {
 		if existing, _ := s.userRepo.FindByEmail(req.Email); existing != nil {
 			return utils.ErrorResponse(c, fiber.StatusBadRequest, "email already exists")
 		}
 		user.Email = req.Email
+		changed = append(changed, "email")
 	}
 
-	if req.FullName != "" {
+	if req.FullName != "" && req.FullName != user.FullName {
 		user.FullName = req.FullName
+		changed = append(changed, "full_name")
 	}
 
 	if req.RoleID != "" && req.RoleID != user.RoleID {
@@ -162,10 +168,12 @@ func (s *userServiceImpl) UpdateUser(c *fiber.Ctx) error {
 			return utils.ErrorResponse(c, fiber.StatusBadRequest, "role not found")
 		}
 		user.RoleID = req.RoleID
+		changed = append(changed, "role")
 	}
 
-	if req.IsActive != nil {
+	if req.IsActive != nil && *req.IsActive != user.IsActive {
 		user.IsActive = *req.IsActive
+		changed = append(changed, "is_active")
 	}
 
 	user.UpdatedAt = time.Now()
@@ -173,6 +181,13 @@ func (s *userServiceImpl) UpdateUser(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to update user")
 	}
 
+	if len(changed) > 0 {
+		s.notifier.notify(models.NotificationAccountUpdated, []string{user.ID},
+			"Account updated",
+			"An administrator changed your account: "+strings.Join(changed, ", ")+".",
+			map[string]interface{}{"changed": changed, "updated_by": c.Locals("userID")})
+	}
+
 	role, _ := s.roleRepo.FindByID(user.RoleID)
 	return utils.SuccessResponse(c, "user updated successfully", &models.UserResponse{
 		ID:       user.ID,
@@ -251,18 +266,43 @@ func (s *userServiceImpl) GetUserByID(c *fiber.Ctx) error {
 // @Description Get paginated list of all users
 // @Tags Users
 // @Produce json
-// @Param page query int false "Page number" default(1)
+// @Param cursor query string false "Cursor from the next/prev link of the previous page"
+// @Param page query int false "Page number (offset pagination, deprecated in favour of cursor)"
 // @Param limit query int false "Items per page" default(10)
 // @Success 200 {array} models.UserResponse
+// @Failure 400 {object} map[string]interface{}
 // @Router /users [get]
 // @Security Bearer
 func (s *userServiceImpl) ListUsers(c *fiber.Ctx) error {
-	pagination := utils.GetPaginationParams(c)
-	users, total, err := s.userRepo.FindAll(pagination.Page, pagination.Limit)
+	// A page number keeps the older offset pagination
+	if c.Query("page") != "" {
+		pagination := utils.GetPaginationParams(c)
+		users, total, err := s.userRepo.FindAll(pagination.Page, pagination.Limit)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to fetch users")
+		}
+		return utils.PaginatedResponse(c, fiber.Map{"users": s.userResponses(users)}, total, pagination.Page, pagination.Limit)
+	}
+
+	params, err := utils.GetCursorParams(c, models.SortOldestFirst)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	users, total, more, err := s.userRepo.FindPage(params.Cursor, params.Limit)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to fetch users")
 	}
 
+	first, last := utils.PageBounds(users, func(u *models.User) *models.Cursor {
+		return &models.Cursor{Key: models.CursorTime(u.CreatedAt), ID: u.ID}
+	})
+	pagination := utils.CursorPagination(c, params, first, last, more)
+	pagination["total"] = total
+	return utils.CursorResponse(c, "success", fiber.Map{"users": s.userResponses(users)}, pagination)
+}
+
+// userResponses renders users with their role
+func (s *userServiceImpl) userResponses(users []*models.User) []*models.UserResponse {
 	var responses []*models.UserResponse
 	for _, user := range users {
 		role, _ := s.roleRepo.FindByID(user.RoleID)
@@ -276,8 +316,7 @@ func (s *userServiceImpl) ListUsers(c *fiber.Ctx) error {
 			IsActive: user.IsActive,
 		})
 	}
-
-	return utils.PaginatedResponse(c, fiber.Map{"users": responses}, total, pagination.Page, pagination.Limit)
+	return responses
 }
 
 // This endpoint is documented in achievement_service.go as GET /achievements
@@ -387,6 +426,11 @@ func (s *userServiceImpl) UpdateUserRole(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to update user role")
 	}
 
+	s.notifier.notify(models.NotificationAccountUpdated, []string{user.ID},
+		"Role changed",
+		"Your role was changed to "+role.Name+".",
+		map[string]interface{}{"changed": []string{"role"}, "role": role.Name, "updated_by": c.Locals("userID")})
+
 	return utils.SuccessResponse(c, "user role updated successfully", nil)
 }
 
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+	"UAS/queue"
+)
+
+const (
+	// webhookDeliveryJob is the queue job type that sends one webhook delivery
+	webhookDeliveryJob = "webhook-delivery"
+	// webhookMaxAttempts spans roughly 40 minutes of retries with the queue backoff
+	webhookMaxAttempts = 8
+	webhookTimeout     = 10 * time.Second
+	// webhookResponseLimit is how much of a receiver's response body is kept in the delivery log
+	webhookResponseLimit = 2048
+)
+
+// webhookEvent is the JSON body posted to receivers
+type webhookEvent struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	CreatedAt time.Time              `json:"created_at"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// webhookPublisher turns domain events into deliveries for the subscriptions that want them
+type webhookPublisher struct {
+	repo *repository.WebhookRepository
+}
+
+func newWebhookPublisher() *webhookPublisher {
+	return &webhookPublisher{repo: repository.NewWebhookRepository()}
+}
+
+// publish queues a delivery of the event to every active subscription of its type
+// Failures are only logged: a webhook never fails the action that raised it
+func (p *webhookPublisher) publish(eventType string, data map[string]interface{}) {
+	subscriptions, err := p.repo.FindActive()
+	if err != nil {
+		log.Println("Failed to load webhook subscriptions for "+eventType+":", err)
+		return
+	}
+
+	event := webhookEvent{ID: uuid.New().String(), Type: eventType, CreatedAt: time.Now(), Data: data}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Println("Failed to encode webhook event "+eventType+":", err)
+		return
+	}
+
+	for i := range subscriptions {
+		if !subscriptions[i].Subscribes(eventType) {
+			continue
+		}
+		delivery := &models.WebhookDelivery{
+			ID:             uuid.New().String(),
+			SubscriptionID: subscriptions[i].ID,
+			EventID:        event.ID,
+			EventType:      eventType,
+			Payload:        string(payload),
+			Status:         models.WebhookDeliveryPending,
+			CreatedAt:      event.CreatedAt,
+			UpdatedAt:      event.CreatedAt,
+		}
+		if err := p.enqueue(delivery); err != nil {
+			log.Println("Failed to queue webhook delivery to "+subscriptions[i].URL+":", err)
+		}
+	}
+}
+
+// enqueue stores a delivery and queues its first attempt
+func (p *webhookPublisher) enqueue(delivery *models.WebhookDelivery) error {
+	if err := p.repo.CreateDelivery(delivery); err != nil {
+		return err
+	}
+	_, err := queue.Enqueue(webhookDeliveryJob, webhookDeliveryPayload{DeliveryID: delivery.ID})
+	return err
+}
+
+// webhookDeliveryPayload is the payload of a webhook-delivery job
+type webhookDeliveryPayload struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+// RegisterWebhookJobs registers the webhook delivery handler
+func RegisterWebhookJobs(q *queue.Queue) {
+	repo := repository.NewWebhookRepository()
+	client := &http.Client{Timeout: webhookTimeout}
+	q.Register(webhookDeliveryJob, func(ctx context.Context, job *models.QueueJob) error {
+		return deliverWebhook(ctx, repo, client, job)
+	}, queue.HandlerOptions{Concurrency: 4, MaxAttempts: webhookMaxAttempts, Timeout: 2 * webhookTimeout})
+}
+
+// deliverWebhook posts a delivery to its subscription and records the attempt
+// A non-2xx answer returns an error so the queue retries it with backoff
+func deliverWebhook(ctx context.Context, repo *repository.WebhookRepository, client *http.Client, job *models.QueueJob) error {
+	var payload webhookDeliveryPayload
+	if err := queue.Decode(job, &payload); err != nil {
+		return err
+	}
+	delivery, err := repo.FindDeliveryByID(payload.DeliveryID)
+	if err != nil {
+		return err
+	}
+	subscription, err := repo.FindByID(delivery.SubscriptionID)
+	if err != nil || !subscription.IsActive {
+		// Deleted or disabled subscriptions are not retried
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.Error = "subscription deleted or inactive"
+		delivery.UpdatedAt = time.Now()
+		return repo.UpdateDelivery(delivery)
+	}
+
+	start := time.Now()
+	status, body, sendErr := sendWebhook(ctx, client, subscription, delivery)
+
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = body
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	delivery.UpdatedAt = time.Now()
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.Error = ""
+		delivery.DeliveredAt = &delivery.UpdatedAt
+	case job.Attempts >= job.MaxAttempts:
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.Error = sendErr.Error()
+	default:
+		delivery.Status = models.WebhookDeliveryPending
+		delivery.Error = sendErr.Error()
+	}
+	if err := repo.UpdateDelivery(delivery); err != nil {
+		log.Printf("Failed to record webhook delivery %s: %v", delivery.ID, err)
+	}
+	return sendErr
+}
+
+// sendWebhook posts the signed payload and returns the response status and the start of its body
+func sendWebhook(ctx context.Context, client *http.Client, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, string, error) {
+	timestamp := time.Now().Unix()
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "UAS-Webhooks/1.0")
+	req.Header.Set("X-Webhook-Id", delivery.ID)
+	req.Header.Set("X-Webhook-Event-Id", delivery.EventID)
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", signWebhook(subscription.Secret, timestamp, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(respBody), fmt.Errorf("receiver answered %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(respBody), nil
+}
+
+// signWebhook computes the X-Webhook-Signature header: HMAC-SHA256 over "<timestamp>.<body>"
+// keyed with the subscription secret. Receivers should reject old timestamps to stop replays
+func signWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newWebhookSecret generates a random signing secret
+func newWebhookSecret() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
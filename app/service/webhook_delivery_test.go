@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"UAS/app/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSignWebhook tests the HMAC signature over timestamp and body
+func TestSignWebhook(t *testing.T) {
+	signature := signWebhook("whsec_test", 1700000000, []byte(`{"id":"evt"}`))
+	assert.Equal(t, "sha256=a94cea056df1fbb92eadafcf2c5cd541dbe0c6ef736e4748202dd53f86694a3e", signature)
+	assert.NotEqual(t, signature, signWebhook("whsec_other", 1700000000, []byte(`{"id":"evt"}`)))
+	assert.NotEqual(t, signature, signWebhook("whsec_test", 1700000001, []byte(`{"id":"evt"}`)))
+}
+
+// TestSendWebhook tests the request sent to receivers and how their answers are treated
+func TestSendWebhook(t *testing.T) {
+	testCases := []struct {
+		name          string
+		status        int
+		expectedError bool
+	}{
+		{name: "Receiver accepts", status: http.StatusOK},
+		{name: "Receiver accepts with no content", status: http.StatusNoContent},
+		{name: "Receiver fails", status: http.StatusInternalServerError, expectedError: true},
+		{name: "Receiver rejects", status: http.StatusGone, expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			subscription := &models.WebhookSubscription{Secret: "whsec_test"}
+			delivery := &models.WebhookDelivery{ID: "d1", EventID: "e1", EventType: models.WebhookAchievementVerified, Payload: `{"id":"e1"}`}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				timestamp, _ := strconv.ParseInt(r.Header.Get("X-Webhook-Timestamp"), 10, 64)
+				assert.Equal(t, signWebhook("whsec_test", timestamp, body), r.Header.Get("X-Webhook-Signature"))
+				assert.Equal(t, models.WebhookAchievementVerified, r.Header.Get("X-Webhook-Event"))
+				assert.Equal(t, "d1", r.Header.Get("X-Webhook-Id"))
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				w.WriteHeader(tc.status)
+				w.Write([]byte("ok"))
+			}))
+			defer server.Close()
+			subscription.URL = server.URL
+
+			status, _, err := sendWebhook(context.Background(), server.Client(), subscription, delivery)
+			assert.Equal(t, tc.status, status)
+			if tc.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestValidateWebhookRequest tests subscription URL and event validation
+func TestValidateWebhookRequest(t *testing.T) {
+	testCases := []struct {
+		name          string
+		req           models.WebhookRequest
+		expectedError bool
+	}{
+		{
+			name: "Valid subscription",
+			req:  models.WebhookRequest{URL: "https://skpi.example.ac.id/hooks", Events: []string{models.WebhookAchievementVerified}},
+		},
+		{
+			name:          "Relative URL",
+			req:           models.WebhookRequest{URL: "/hooks", Events: []string{models.WebhookAchievementVerified}},
+			expectedError: true,
+		},
+		{
+			name:          "Unsupported scheme",
+			req:           models.WebhookRequest{URL: "ftp://example.com/hooks", Events: []string{models.WebhookAchievementVerified}},
+			expectedError: true,
+		},
+		{
+			name:          "No events",
+			req:           models.WebhookRequest{URL: "https://example.com/hooks"},
+			expectedError: true,
+		},
+		{
+			name:          "Unknown event",
+			req:           models.WebhookRequest{URL: "https://example.com/hooks", Events: []string{"achievement.deleted"}},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateWebhookRequest(tc.req)
+			if tc.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
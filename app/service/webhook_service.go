@@ -0,0 +1,331 @@
+package service
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+	"UAS/utils"
+)
+
+// WebhookService defines webhook subscription management operations (Admin only)
+type WebhookService interface {
+	ListEvents(c *fiber.Ctx) error
+	ListWebhooks(c *fiber.Ctx) error
+	GetWebhook(c *fiber.Ctx) error
+	CreateWebhook(c *fiber.Ctx) error
+	UpdateWebhook(c *fiber.Ctx) error
+	DeleteWebhook(c *fiber.Ctx) error
+	RotateSecret(c *fiber.Ctx) error
+	ListDeliveries(c *fiber.Ctx) error
+	GetDelivery(c *fiber.Ctx) error
+	Redeliver(c *fiber.Ctx) error
+}
+
+type webhookServiceImpl struct {
+	repo      *repository.WebhookRepository
+	publisher *webhookPublisher
+}
+
+func NewWebhookService() WebhookService {
+	return &webhookServiceImpl{
+		repo:      repository.NewWebhookRepository(),
+		publisher: newWebhookPublisher(),
+	}
+}
+
+// validateWebhookRequest checks the target URL and event types of a subscription
+func validateWebhookRequest(req models.WebhookRequest) error {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	if len(req.Events) == 0 {
+		return errors.New("events is required")
+	}
+	for _, event := range req.Events {
+		known := false
+		for _, e := range models.WebhookEvents {
+			if e == event {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return errors.New("unknown webhook event: " + event)
+		}
+	}
+	return nil
+}
+
+// FunctionName godoc
+// @Summary List webhook events
+// @Description Get the event types webhook subscriptions can receive (Admin only)
+// @Tags Webhooks
+// @Produce json
+// @Success 200 {array} string
+// @Router /webhooks/events [get]
+// @Security Bearer
+func (s *webhookServiceImpl) ListEvents(c *fiber.Ctx) error {
+	return utils.SuccessResponse(c, "webhook events retrieved successfully", models.WebhookEvents)
+}
+
+// FunctionName godoc
+// @Summary List webhooks
+// @Description Get every webhook subscription (Admin only)
+// @Tags Webhooks
+// @Produce json
+// @Success 200 {array} models.WebhookSubscription
+// @Router /webhooks [get]
+// @Security Bearer
+func (s *webhookServiceImpl) ListWebhooks(c *fiber.Ctx) error {
+	subscriptions, err := s.repo.FindAll()
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve webhooks")
+	}
+	return utils.SuccessResponse(c, "webhooks retrieved successfully", subscriptions)
+}
+
+// FunctionName godoc
+// @Summary Get webhook
+// @Description Get a webhook subscription (Admin only)
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} models.WebhookSubscription
+// @Failure 404 {object} map[string]interface{}
+// @Router /webhooks/{id} [get]
+// @Security Bearer
+func (s *webhookServiceImpl) GetWebhook(c *fiber.Ctx) error {
+	subscription, err := s.repo.FindByID(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "webhook not found")
+	}
+	return utils.SuccessResponse(c, "webhook retrieved successfully", subscription)
+}
+
+// FunctionName godoc
+// @Summary Create webhook
+// @Description Register an endpoint for achievement events. The signing secret is only returned in this response (Admin only)
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param body body models.WebhookRequest true "Webhook data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /webhooks [post]
+// @Security Bearer
+func (s *webhookServiceImpl) CreateWebhook(c *fiber.Ctx) error {
+	var req models.WebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "invalid request body")
+	}
+	if err := validateWebhookRequest(req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	subscription := &models.WebhookSubscription{
+		ID:          uuid.New().String(),
+		URL:         req.URL,
+		Description: req.Description,
+		Events:      req.Events,
+		Secret:      newWebhookSecret(),
+		IsActive:    true,
+		CreatedBy:   c.Locals("userID").(string),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.Create(subscription); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to create webhook")
+	}
+
+	return utils.CreatedResponse(c, "webhook created successfully", fiber.Map{
+		"webhook": subscription,
+		"secret":  subscription.Secret,
+	})
+}
+
+// FunctionName godoc
+// @Summary Update webhook
+// @Description Change the URL, events, description or active flag of a webhook subscription (Admin only)
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param body body models.WebhookRequest true "Webhook data"
+// @Success 200 {object} models.WebhookSubscription
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /webhooks/{id} [put]
+// @Security Bearer
+func (s *webhookServiceImpl) UpdateWebhook(c *fiber.Ctx) error {
+	var req models.WebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "invalid request body")
+	}
+	if err := validateWebhookRequest(req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	subscription, err := s.repo.FindByID(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "webhook not found")
+	}
+
+	subscription.URL = req.URL
+	subscription.Description = req.Description
+	subscription.Events = req.Events
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+	subscription.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(subscription); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to update webhook")
+	}
+	return utils.SuccessResponse(c, "webhook updated successfully", subscription)
+}
+
+// FunctionName godoc
+// @Summary Delete webhook
+// @Description Delete a webhook subscription. Its delivery log is kept (Admin only)
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /webhooks/{id} [delete]
+// @Security Bearer
+func (s *webhookServiceImpl) DeleteWebhook(c *fiber.Ctx) error {
+	if _, err := s.repo.FindByID(c.Params("id")); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "webhook not found")
+	}
+	if err := s.repo.Delete(c.Params("id")); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to delete webhook")
+	}
+	return utils.DeletedResponse(c, "webhook deleted successfully")
+}
+
+// FunctionName godoc
+// @Summary Rotate webhook secret
+// @Description Replace the signing secret of a webhook subscription. The new secret is only returned in this response (Admin only)
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /webhooks/{id}/rotate-secret [post]
+// @Security Bearer
+func (s *webhookServiceImpl) RotateSecret(c *fiber.Ctx) error {
+	subscription, err := s.repo.FindByID(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "webhook not found")
+	}
+
+	subscription.Secret = newWebhookSecret()
+	subscription.UpdatedAt = time.Now()
+	if err := s.repo.Update(subscription); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to rotate webhook secret")
+	}
+	return utils.SuccessResponse(c, "webhook secret rotated successfully", fiber.Map{"secret": subscription.Secret})
+}
+
+// FunctionName godoc
+// @Summary List webhook deliveries
+// @Description Get the delivery log of a webhook subscription, newest first (Admin only)
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param status query string false "Filter by status (pending, succeeded, failed)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /webhooks/{id}/deliveries [get]
+// @Security Bearer
+func (s *webhookServiceImpl) ListDeliveries(c *fiber.Ctx) error {
+	if _, err := s.repo.FindByID(c.Params("id")); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "webhook not found")
+	}
+
+	pagination := utils.GetPaginationParams(c)
+	deliveries, total, err := s.repo.FindDeliveries(c.Params("id"), c.Query("status"), pagination.Offset, pagination.Limit)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to retrieve webhook deliveries")
+	}
+	return utils.PaginatedResponse(c, fiber.Map{"deliveries": deliveries}, total, pagination.Page, pagination.Limit)
+}
+
+// FunctionName godoc
+// @Summary Get webhook delivery
+// @Description Get one delivery with its payload and the receiver's last response (Admin only)
+// @Tags Webhooks
+// @Produce json
+// @Param deliveryId path string true "Delivery ID"
+// @Success 200 {object} models.WebhookDelivery
+// @Failure 404 {object} map[string]interface{}
+// @Router /webhooks/deliveries/{deliveryId} [get]
+// @Security Bearer
+func (s *webhookServiceImpl) GetDelivery(c *fiber.Ctx) error {
+	delivery, err := s.repo.FindDeliveryByID(c.Params("deliveryId"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "webhook delivery not found")
+	}
+	return utils.SuccessResponse(c, "webhook delivery retrieved successfully", delivery)
+}
+
+// FunctionName godoc
+// @Summary Redeliver webhook
+// @Description Send the payload of a delivery again as a new delivery with the same event ID (Admin only)
+// @Tags Webhooks
+// @Produce json
+// @Param deliveryId path string true "Delivery ID"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /webhooks/deliveries/{deliveryId}/redeliver [post]
+// @Security Bearer
+func (s *webhookServiceImpl) Redeliver(c *fiber.Ctx) error {
+	original, err := s.repo.FindDeliveryByID(c.Params("deliveryId"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "webhook delivery not found")
+	}
+	subscription, err := s.repo.FindByID(original.SubscriptionID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "webhook has been deleted")
+	}
+	if !subscription.IsActive {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "webhook is inactive")
+	}
+
+	now := time.Now()
+	delivery := &models.WebhookDelivery{
+		ID:             uuid.New().String(),
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         models.WebhookDeliveryPending,
+		RedeliveryOf:   original.ID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.publisher.enqueue(delivery); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "failed to queue redelivery")
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"status":  true,
+		"message": "webhook redelivery queued",
+		"data":    delivery,
+	})
+}
@@ -0,0 +1,118 @@
+// Command reconcile compares achievement references in PostgreSQL with their MongoDB documents.
+// It is a dry run by default: the report lists every inconsistency and the repair that would fix it.
+// Pass -repair to apply the repairs, which includes requeueing failed outbox entries.
+//
+//	go run ./cmd/reconcile              # report only
+//	go run ./cmd/reconcile -repair      # report and repair
+//	go run ./cmd/reconcile -json        # machine readable report
+//	go run ./cmd/reconcile -requeue 42  # requeue failed outbox entry 42 after checking it by hand
+//
+// Exit status is 0 when both stores agree (or every issue was repaired), 1 on errors and
+// 2 when inconsistencies remain.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"UAS/app/models"
+	"UAS/app/service"
+	"UAS/database"
+)
+
+func main() {
+	repair := flag.Bool("repair", false, "apply the repairs (default is a dry run)")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	requeue := flag.Int64("requeue", 0, "requeue the failed outbox entry with this ID and exit")
+	timeout := flag.Duration("timeout", 10*time.Minute, "maximum duration of the run")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: No .env file found")
+	}
+
+	database.ConnectPostgres()
+	database.ConnectMongoDB()
+	defer database.DisconnectMongoDB()
+
+	if *requeue > 0 {
+		if err := service.RequeueOutboxEntry(*requeue); err != nil {
+			log.Println("Requeue failed:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Outbox entry %d requeued\n", *requeue)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	report, err := service.ReconcileAchievements(ctx, *repair)
+	if err != nil {
+		log.Println("Reconciliation failed:", err)
+		os.Exit(1)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		printReport(report)
+	}
+
+	if remaining(report) > 0 {
+		os.Exit(2)
+	}
+}
+
+func printReport(report *models.ReconcileReport) {
+	if len(report.Issues) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\tACHIEVEMENT\tDOCUMENT\tDETAIL\tREPAIR\tRESULT")
+		for _, issue := range report.Issues {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				issue.Kind, orDash(issue.AchievementID), orDash(issue.MongoID), issue.Detail, orDash(issue.Repair), result(report, issue))
+		}
+		w.Flush()
+		fmt.Println()
+	}
+	fmt.Println(service.ReconcileSummary(report))
+	if report.DryRun && len(report.Issues) > report.NeedsManualAttention {
+		fmt.Println("Run again with -repair to apply the repairs.")
+	}
+}
+
+// remaining counts the issues that are still present after the run
+func remaining(report *models.ReconcileReport) int {
+	return len(report.Issues) - report.Repaired
+}
+
+func result(report *models.ReconcileReport, issue models.ReconcileIssue) string {
+	switch {
+	case issue.Repair == "":
+		return "needs manual attention"
+	case issue.Repaired:
+		return "repaired"
+	case issue.Error != "":
+		return "failed: " + issue.Error
+	case report.DryRun:
+		return "would repair"
+	}
+	return "-"
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -12,6 +12,8 @@ import (
 func RunMigrations(db *gorm.DB) {
 	log.Println("Running database migrations...")
 
+	fillListingColumns(db)
+
 	// AutoMigrate akan membuat tabel jika belum ada
 	err := db.AutoMigrate(
 		&models.User{},
@@ -21,6 +23,23 @@ func RunMigrations(db *gorm.DB) {
 		&models.Student{},
 		&models.Lecturer{},
 		&models.AchievementReference{},
+		&models.AchievementType{},
+		&models.ScoringRubric{},
+		&models.AchievementParticipant{},
+		&models.AchievementDuplicateFlag{},
+		&models.ReviewSLA{},
+		&models.ReviewEscalation{},
+		&models.JobRun{},
+		&models.QueueJob{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.RealtimeEvent{},
+		&models.OutboxEntry{},
+		&models.AttachmentUpload{},
+		&models.StoredBlob{},
+		&models.BlobReference{},
 	)
 
 	if err != nil {
@@ -28,4 +47,23 @@ func RunMigrations(db *gorm.DB) {
 	}
 
 	log.Println("Migrations completed successfully")
+
+	// Seed registry data required by the services
+	SeedAchievementTypes(db)
+	SeedScoringRubric(db)
+	SeedReviewSLAs(db)
+}
+
+// fillListingColumns replaces NULL titles and types of achievement references, left by the first
+// migration that added the columns as nullable, so they can become NOT NULL
+func fillListingColumns(db *gorm.DB) {
+	for _, column := range []string{"title", "achievement_type"} {
+		if !db.Migrator().HasColumn(&models.AchievementReference{}, column) {
+			continue
+		}
+		err := db.Model(&models.AchievementReference{}).Where(column+" IS NULL").Update(column, "").Error
+		if err != nil {
+			log.Fatal("Failed to fill achievement listing columns: ", err)
+		}
+	}
 }
@@ -50,12 +50,15 @@ func CreateMongoCollections() {
 	// Create achievement collection if not exists
 	achievementCollection := MongoDB.Collection("achievements")
 
-	// Create index on student_id for faster queries
-	indexModel := mongo.IndexModel{
-		Keys: bson.D{{Key: "student_id", Value: 1}},
+	// Create index on student_id for faster queries, plus the fields used by duplicate detection
+	indexModels := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "student_id", Value: 1}}},
+		{Keys: bson.D{{Key: "normalized_title", Value: 1}}},
+		{Keys: bson.D{{Key: "details.certification_number", Value: 1}}},
+		{Keys: bson.D{{Key: "attachments.sha256", Value: 1}}},
 	}
 
-	_, err := achievementCollection.Indexes().CreateOne(ctx, indexModel)
+	_, err := achievementCollection.Indexes().CreateMany(ctx, indexModels)
 	if err != nil {
 		log.Println("Failed to create index on achievements:", err)
 	} else {
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"log"
+	"time"
+
+	"UAS/app/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// competitionLevels are the levels accepted for competition achievements
+var competitionLevels = []string{"international", "national", "provincial", "regional", "city", "local", "school"}
+
+// publicationIndexations are the indexation tiers accepted for publications
+var publicationIndexations = []string{"scopus_q1", "scopus_q2", "scopus_q3", "scopus_q4", "sinta_1", "sinta_2", "sinta_3", "sinta_4", "sinta_5", "sinta_6", "none"}
+
+// defaultAchievementTypes mirrors the types that used to be hard-coded in the services
+func defaultAchievementTypes() []models.AchievementType {
+	return []models.AchievementType{
+		{
+			Code:          "academic",
+			Labels:        map[string]string{"id": "Akademik", "en": "Academic"},
+			DefaultPoints: 10,
+			Fields: []models.AchievementField{
+				{Name: "event_date", Type: models.FieldTypeDate},
+				{Name: "organizer", Type: models.FieldTypeString},
+				{Name: "score", Type: models.FieldTypeNumber},
+			},
+		},
+		{
+			Code:          "competition",
+			Labels:        map[string]string{"id": "Kompetisi", "en": "Competition"},
+			DefaultPoints: 20,
+			Fields: []models.AchievementField{
+				{Name: "competition_name", Type: models.FieldTypeString, Required: true},
+				{Name: "competition_level", Type: models.FieldTypeString, Required: true, AllowedValues: competitionLevels},
+				{Name: "rank", Type: models.FieldTypeInteger},
+				{Name: "medal_type", Type: models.FieldTypeString, AllowedValues: []string{"gold", "silver", "bronze"}},
+				{Name: "event_date", Type: models.FieldTypeDate},
+				{Name: "location", Type: models.FieldTypeString},
+				{Name: "organizer", Type: models.FieldTypeString},
+				{Name: "team_size", Type: models.FieldTypeInteger},
+			},
+		},
+		{
+			Code:          "organization",
+			Labels:        map[string]string{"id": "Organisasi", "en": "Organization"},
+			DefaultPoints: 10,
+			Fields: []models.AchievementField{
+				{Name: "organization_name", Type: models.FieldTypeString, Required: true},
+				{Name: "position", Type: models.FieldTypeString, Required: true},
+				{Name: "start_date", Type: models.FieldTypeDate},
+				{Name: "end_date", Type: models.FieldTypeDate},
+				{Name: "organizer", Type: models.FieldTypeString},
+				{Name: "location", Type: models.FieldTypeString},
+			},
+		},
+		{
+			Code:          "publication",
+			Labels:        map[string]string{"id": "Publikasi", "en": "Publication"},
+			DefaultPoints: 25,
+			Fields: []models.AchievementField{
+				{Name: "publication_type", Type: models.FieldTypeString, Required: true, AllowedValues: []string{"journal", "conference", "book"}},
+				{Name: "publication_title", Type: models.FieldTypeString, Required: true},
+				{Name: "authors", Type: models.FieldTypeArray},
+				{Name: "publisher", Type: models.FieldTypeString},
+				{Name: "issn", Type: models.FieldTypeString},
+				{Name: "indexation", Type: models.FieldTypeString, AllowedValues: publicationIndexations},
+				{Name: "event_date", Type: models.FieldTypeDate},
+				{Name: "score", Type: models.FieldTypeNumber},
+			},
+		},
+		{
+			Code:          "certification",
+			Labels:        map[string]string{"id": "Sertifikasi", "en": "Certification"},
+			DefaultPoints: 15,
+			Fields: []models.AchievementField{
+				{Name: "certification_name", Type: models.FieldTypeString, Required: true},
+				{Name: "issued_by", Type: models.FieldTypeString, Required: true},
+				{Name: "certification_number", Type: models.FieldTypeString},
+				{Name: "valid_until", Type: models.FieldTypeString},
+				{Name: "event_date", Type: models.FieldTypeDate},
+				{Name: "score", Type: models.FieldTypeNumber},
+			},
+		},
+		{
+			Code:          "other",
+			Labels:        map[string]string{"id": "Lainnya", "en": "Other"},
+			DefaultPoints: 5,
+		},
+	}
+}
+
+// SeedAchievementTypes inserts the default achievement types when the registry is empty
+func SeedAchievementTypes(db *gorm.DB) {
+	var count int64
+	if err := db.Model(&models.AchievementType{}).Count(&count).Error; err != nil {
+		log.Println("Failed to count achievement types:", err)
+		return
+	}
+	if count > 0 {
+		return
+	}
+
+	now := time.Now()
+	for _, t := range defaultAchievementTypes() {
+		t.IsActive = true
+		t.CreatedAt = now
+		t.UpdatedAt = now
+		if err := db.Create(&t).Error; err != nil {
+			log.Println("Failed to seed achievement type "+t.Code+":", err)
+		}
+	}
+
+	log.Println("Default achievement types seeded")
+}
+
+// defaultRubricRules is the initial scoring rubric (version 1)
+func defaultRubricRules() models.RubricRules {
+	return models.RubricRules{
+		TypeBasePoints: map[string]int{},
+		LevelMultipliers: map[string]float64{
+			"international": 4,
+			"national":      3,
+			"provincial":    2,
+			"regional":      2,
+			"city":          1.5,
+			"local":         1,
+			"school":        1,
+		},
+		RankBonus:  map[string]int{"1": 15, "2": 10, "3": 5},
+		MedalBonus: map[string]int{"gold": 15, "silver": 10, "bronze": 5},
+		IndexationBonus: map[string]int{
+			"scopus_q1": 50, "scopus_q2": 40, "scopus_q3": 30, "scopus_q4": 20,
+			"sinta_1": 25, "sinta_2": 20, "sinta_3": 15, "sinta_4": 10, "sinta_5": 5, "sinta_6": 5,
+		},
+		PositionBonus: map[string]int{
+			"ketua": 15, "chairman": 15, "president": 15,
+			"wakil ketua": 10, "vice chairman": 10,
+			"sekretaris": 8, "secretary": 8,
+			"bendahara": 8, "treasurer": 8,
+		},
+		TeamSizeDecay:         0.1,
+		MinTeamSizeMultiplier: 0.5,
+	}
+}
+
+// SeedScoringRubric inserts rubric version 1 when no rubric exists
+func SeedScoringRubric(db *gorm.DB) {
+	var count int64
+	if err := db.Model(&models.ScoringRubric{}).Count(&count).Error; err != nil {
+		log.Println("Failed to count scoring rubrics:", err)
+		return
+	}
+	if count > 0 {
+		return
+	}
+
+	rubric := models.ScoringRubric{
+		ID:          uuid.New().String(),
+		Version:     1,
+		Description: "Default rubric",
+		Rules:       defaultRubricRules(),
+		IsActive:    true,
+		CreatedAt:   time.Now(),
+	}
+	if err := db.Create(&rubric).Error; err != nil {
+		log.Println("Failed to seed scoring rubric:", err)
+		return
+	}
+
+	log.Println("Default scoring rubric seeded")
+}
+
+// defaultReviewSLAs are the review deadlines in hours used until an admin changes them
+var defaultReviewSLAs = map[string]int{
+	models.ReviewStageSubmitted: 72,
+	models.ReviewStageClaimed:   48,
+}
+
+// SeedReviewSLAs inserts the default deadline of every review stage that has none yet
+func SeedReviewSLAs(db *gorm.DB) {
+	for stage, hours := range defaultReviewSLAs {
+		sla := models.ReviewSLA{Stage: stage, DeadlineHours: hours, UpdatedAt: time.Now()}
+		if err := db.Where("stage = ?", stage).FirstOrCreate(&sla).Error; err != nil {
+			log.Println("Failed to seed review SLA "+stage+":", err)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileMailer writes every message as an .eml file instead of sending it, for local development
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+// Send writes the message to Dir
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	data, err := build(msg, m.From)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.Dir, 0755); err != nil {
+		return err
+	}
+	name := time.Now().Format("20060102-150405") + "-" + randomID() + ".eml"
+	return os.WriteFile(filepath.Join(m.Dir, name), data, 0644)
+}
+
+// MemoryMailer keeps sent messages in memory, for tests
+type MemoryMailer struct {
+	From string
+
+	mu   sync.Mutex
+	sent []Message
+}
+
+// Send records the message
+func (m *MemoryMailer) Send(ctx context.Context, msg Message) error {
+	if _, err := build(msg, m.From); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// Messages returns the messages sent so far
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.sent...)
+}
+
+// Reset forgets the messages sent so far
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = nil
+}
@@ -0,0 +1,151 @@
+// Package mail sends transactional email through a pluggable transport.
+// Messages are rendered from the embedded Indonesian and English templates with Render and
+// delivered by a Mailer: SMTPMailer in production, FileMailer or MemoryMailer for local
+// development and tests.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message is an email ready to be sent
+type Message struct {
+	From    string // defaults to the mailer's sender
+	To      []string
+	Subject string
+	Text    string // plain text body
+	HTML    string // optional HTML alternative
+}
+
+// Mailer delivers messages
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewFromEnv creates the mailer selected by MAIL_TRANSPORT:
+//   - smtp: SMTP_HOST, SMTP_PORT (default 587), SMTP_USERNAME, SMTP_PASSWORD
+//   - file: writes .eml files to MAIL_DIR (default ./tmp/mail), the default transport
+//   - memory: keeps messages in memory
+//
+// MAIL_FROM sets the sender address of every transport
+func NewFromEnv() (Mailer, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "Sistem Prestasi <no-reply@localhost>"
+	}
+
+	switch transport := os.Getenv("MAIL_TRANSPORT"); transport {
+	case "smtp":
+		port := 587
+		if p := os.Getenv("SMTP_PORT"); p != "" {
+			var err error
+			if port, err = strconv.Atoi(p); err != nil {
+				return nil, fmt.Errorf("invalid SMTP_PORT: %s", p)
+			}
+		}
+		if os.Getenv("SMTP_HOST") == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required for the smtp mail transport")
+		}
+		return &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}, nil
+	case "", "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "./tmp/mail"
+		}
+		return &FileMailer{Dir: dir, From: from}, nil
+	case "memory":
+		return &MemoryMailer{From: from}, nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_TRANSPORT: %s", transport)
+	}
+}
+
+// build encodes a message as a MIME email, multipart/alternative when it has an HTML body
+func build(msg Message, from string) ([]byte, error) {
+	if msg.From != "" {
+		from = msg.From
+	}
+	if len(msg.To) == 0 {
+		return nil, fmt.Errorf("mail: message has no recipient")
+	}
+
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		buf.WriteString(key + ": " + value + "\r\n")
+	}
+	header("From", from)
+	header("To", strings.Join(msg.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", "<"+randomID()+"@"+domainOf(from)+">")
+	header("MIME-Version", "1.0")
+
+	if msg.HTML == "" {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "8bit")
+		buf.WriteString("\r\n" + normalizeNewlines(msg.Text))
+		return buf.Bytes(), nil
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		pw.Write([]byte(normalizeNewlines(part.content)))
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	header("Content-Type", "multipart/alternative; boundary="+w.Boundary())
+	buf.WriteString("\r\n")
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
+
+// normalizeNewlines converts line endings to CRLF as required by SMTP
+func normalizeNewlines(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
+
+// domainOf returns the domain of the address in "Name <user@domain>" or "user@domain"
+func domainOf(address string) string {
+	address = strings.TrimSuffix(strings.TrimSpace(address), ">")
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return address[i+1:]
+	}
+	return "localhost"
+}
+
+func randomID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,110 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRender tests that every template renders in both locales
+func TestRender(t *testing.T) {
+	data := map[string]interface{}{
+		"title":          "Juara 1 Lomba Programming",
+		"points":         85,
+		"rejection_note": "Bukti kurang lengkap",
+		"student_name":   "Budi",
+	}
+	digest := []DigestItem{{Title: "Achievement verified", Message: "Awarded 85 points", CreatedAt: time.Now()}}
+
+	testCases := []struct {
+		name     string
+		locale   string
+		subject  string
+		contains string
+	}{
+		{name: "submission_received", locale: LocaleIndonesian, subject: "Prestasi diterima: Juara 1 Lomba Programming", contains: "menunggu verifikasi"},
+		{name: "submission_received", locale: LocaleEnglish, subject: "Achievement received: Juara 1 Lomba Programming", contains: "waiting for your advisor"},
+		{name: "review_pending", locale: LocaleIndonesian, subject: "Prestasi menunggu verifikasi: Juara 1 Lomba Programming", contains: "Budi"},
+		{name: "review_pending", locale: LocaleEnglish, subject: "Achievement awaiting verification: Juara 1 Lomba Programming", contains: "Budi"},
+		{name: "achievement_verified", locale: LocaleIndonesian, subject: "Prestasi diverifikasi: Juara 1 Lomba Programming", contains: "85 poin"},
+		{name: "achievement_verified", locale: LocaleEnglish, subject: "Achievement verified: Juara 1 Lomba Programming", contains: "85 points"},
+		{name: "achievement_rejected", locale: LocaleIndonesian, subject: "Prestasi ditolak: Juara 1 Lomba Programming", contains: "Bukti kurang lengkap"},
+		{name: "achievement_rejected", locale: LocaleEnglish, subject: "Achievement rejected: Juara 1 Lomba Programming", contains: "Bukti kurang lengkap"},
+		{name: "weekly_digest", locale: LocaleIndonesian, subject: "Ringkasan mingguan: 1 notifikasi", contains: "Awarded 85 points"},
+		{name: "weekly_digest", locale: LocaleEnglish, subject: "Weekly digest: 1 notifications", contains: "Awarded 85 points"},
+		{name: "achievement_verified", locale: "fr", subject: "Prestasi diverifikasi: Juara 1 Lomba Programming", contains: "Halo Siti"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name+"."+tc.locale, func(t *testing.T) {
+			msg, err := Render(tc.name, TemplateData{Locale: tc.locale, Name: "Siti", Data: data, Notifications: digest, Unread: 1})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.subject, msg.Subject)
+			assert.Contains(t, msg.Text, tc.contains)
+			assert.Contains(t, msg.HTML, "Siti")
+			assert.NotContains(t, msg.Text, "<no value>")
+		})
+	}
+}
+
+// TestRenderEscapesHTML tests that event data cannot inject markup into the HTML body
+func TestRenderEscapesHTML(t *testing.T) {
+	msg, err := Render("achievement_rejected", TemplateData{
+		Locale: LocaleEnglish,
+		Name:   "Siti",
+		Data:   map[string]interface{}{"title": "<b>x</b>", "rejection_note": "<script>alert(1)</script>"},
+	})
+	assert.NoError(t, err)
+	assert.NotContains(t, msg.HTML, "<script>")
+	assert.Contains(t, msg.Text, "<script>alert(1)</script>")
+
+	_, err = Render("unknown", TemplateData{Locale: LocaleEnglish})
+	assert.Error(t, err)
+}
+
+// TestBuild tests MIME encoding of messages
+func TestBuild(t *testing.T) {
+	data, err := build(Message{To: []string{"siti@example.com"}, Subject: "Prestasi diterima ✓", Text: "line 1\nline 2", HTML: "<p>hi</p>"}, "Sistem <no-reply@example.com>")
+	assert.NoError(t, err)
+	raw := string(data)
+	assert.Contains(t, raw, "From: Sistem <no-reply@example.com>\r\n")
+	assert.Contains(t, raw, "To: siti@example.com\r\n")
+	assert.Contains(t, raw, "Subject: =?utf-8?q?")
+	assert.Contains(t, raw, "@example.com>\r\n")
+	assert.Contains(t, raw, "multipart/alternative")
+	assert.Contains(t, raw, "line 1\r\nline 2")
+
+	data, err = build(Message{To: []string{"siti@example.com"}, Subject: "Plain", Text: "only text"}, "no-reply@example.com")
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "Content-Type: text/plain; charset=utf-8")
+
+	_, err = build(Message{Subject: "No recipient"}, "no-reply@example.com")
+	assert.Error(t, err)
+}
+
+// TestLocalMailers tests the file and in-memory transports
+func TestLocalMailers(t *testing.T) {
+	msg := Message{To: []string{"siti@example.com"}, Subject: "Hello", Text: "Body"}
+
+	memory := &MemoryMailer{From: "no-reply@example.com"}
+	assert.NoError(t, memory.Send(context.Background(), msg))
+	assert.Equal(t, []Message{msg}, memory.Messages())
+	memory.Reset()
+	assert.Empty(t, memory.Messages())
+
+	dir := t.TempDir()
+	file := &FileMailer{Dir: filepath.Join(dir, "mail"), From: "no-reply@example.com"}
+	assert.NoError(t, file.Send(context.Background(), msg))
+	entries, err := os.ReadDir(file.Dir)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.True(t, strings.HasSuffix(entries[0].Name(), ".eml"))
+		content, _ := os.ReadFile(filepath.Join(file.Dir, entries[0].Name()))
+		assert.Contains(t, string(content), "Subject: Hello")
+	}
+}
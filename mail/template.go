@@ -0,0 +1,129 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+)
+
+// Supported template locales
+const (
+	LocaleIndonesian = "id"
+	LocaleEnglish    = "en"
+	DefaultLocale    = LocaleIndonesian
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// TemplateData is the input of every email template
+type TemplateData struct {
+	Locale        string
+	Name          string                 // recipient's full name
+	AppURL        string                 // optional link to the application
+	Data          map[string]interface{} // event details, e.g. title, points, rejection_note
+	Notifications []DigestItem           // weekly digest entries
+	Unread        int64                  // weekly digest unread count
+}
+
+// DigestItem is one notification listed in the weekly digest
+type DigestItem struct {
+	Title     string
+	Message   string
+	CreatedAt time.Time
+}
+
+type compiledTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+var (
+	loadOnce  sync.Once
+	loadErr   error
+	templates map[string]compiledTemplate // keyed by name.locale
+)
+
+// loadTemplates parses every embedded template together with the shared layout.
+// Subject and text bodies use text/template; the HTML body uses html/template so data is escaped
+func loadTemplates() {
+	templates = make(map[string]compiledTemplate)
+	layout, err := templateFS.ReadFile("templates/_layout.tmpl")
+	if err != nil {
+		loadErr = err
+		return
+	}
+
+	entries, err := templateFS.ReadDir("templates")
+	if err != nil {
+		loadErr = err
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, "_") {
+			continue
+		}
+		content, err := templateFS.ReadFile("templates/" + name)
+		if err != nil {
+			loadErr = err
+			return
+		}
+
+		text, err := texttemplate.New(name).Parse(string(layout) + string(content))
+		if err != nil {
+			loadErr = fmt.Errorf("mail: parse %s: %w", name, err)
+			return
+		}
+		html, err := htmltemplate.New(name).Parse(string(layout) + string(content))
+		if err != nil {
+			loadErr = fmt.Errorf("mail: parse %s: %w", name, err)
+			return
+		}
+		templates[strings.TrimSuffix(name, ".tmpl")] = compiledTemplate{text: text, html: html}
+	}
+}
+
+// Render renders the subject, text and HTML body of a template. Locales without
+// a translation fall back to Indonesian
+func Render(name string, data TemplateData) (Message, error) {
+	loadOnce.Do(loadTemplates)
+	if loadErr != nil {
+		return Message{}, loadErr
+	}
+
+	tmpl, ok := templates[name+"."+data.Locale]
+	if !ok {
+		data.Locale = DefaultLocale
+		if tmpl, ok = templates[name+"."+DefaultLocale]; !ok {
+			return Message{}, fmt.Errorf("mail: unknown template %s", name)
+		}
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "html", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+// IsLocale reports whether templates are available in a locale
+func IsLocale(locale string) bool {
+	return locale == LocaleIndonesian || locale == LocaleEnglish
+}
@@ -1,11 +1,24 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"UAS/app/service"
 	"UAS/database"
 	_ "UAS/docs" // Import docs untuk Swagger (underscore karena hanya butuh side effect)
+	"UAS/mail"
+	"UAS/preview"
+	"UAS/queue"
+	"UAS/realtime"
 	"UAS/routes"
+	"UAS/scanner"
+	"UAS/scheduler"
+	"UAS/storage"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -41,6 +54,32 @@ func main() {
 		log.Println("Warning: No .env file found")
 	}
 
+	service.LoadCertificationConfig()
+
+	mailer, err := mail.NewFromEnv()
+	if err != nil {
+		log.Fatal("Failed to configure mail transport: ", err)
+	}
+
+	// Attachment storage must be configured before the services are created in SetupRoutes
+	blobs, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatal("Failed to configure attachment storage: ", err)
+	}
+	storage.Default = blobs
+
+	virusScanner, err := scanner.NewFromEnv()
+	if err != nil {
+		log.Fatal("Failed to configure malware scanner: ", err)
+	}
+	scanner.Default = virusScanner
+
+	previews, err := preview.NewFromEnv()
+	if err != nil {
+		log.Fatal("Failed to configure attachment previews: ", err)
+	}
+	preview.Default = previews
+
 	// Connect PostgreSQL
 	database.ConnectPostgres()
 
@@ -48,8 +87,19 @@ func main() {
 	database.ConnectMongoDB()
 	defer database.DisconnectMongoDB()
 
+	// Achievement lists filter and sort on columns copied from MongoDB; fill them before serving
+	backfillCtx, cancelBackfill := context.WithTimeout(context.Background(), 5*time.Minute)
+	if synced, err := service.BackfillListing(backfillCtx); err != nil {
+		log.Printf("Warning: achievement listing backfill incomplete after %d achievements, the scheduled job retries: %v", synced, err)
+	} else if synced > 0 {
+		log.Printf("Achievement listing backfilled for %d achievements", synced)
+	}
+	cancelBackfill()
+
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
+		// Single-request attachment uploads and resumable upload chunks are at most 10MB
+		BodyLimit: 12 * 1024 * 1024,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			if e, ok := err.(*fiber.Error); ok {
@@ -64,17 +114,35 @@ func main() {
 	// Middleware
 	app.Use(logger.New())
 	app.Use(recover.New())
-	app.Use(cors.New())
+	app.Use(cors.New(cors.Config{
+		// Resumable upload clients read the tus headers
+		ExposeHeaders: "Location, Upload-Offset, Upload-Length, Upload-Expires, Tus-Resumable, Tus-Version, Tus-Extension, Tus-Max-Size",
+	}))
 
 	// Swagger endpoint - Ganti dengan swagger handler yang benar
 	app.Get("/swagger/*", swagger.WrapHandler)
 
-	// Serve static files for uploads
-	app.Static("/uploads", "./uploads")
-
 	// Setup routes
 	routes.SetupRoutes(app)
 
+	// Register recurring background jobs and start the scheduler and job queue workers
+	service.RegisterReviewQueueJobs(scheduler.Default)
+	service.RegisterQueueJobs(scheduler.Default)
+	service.RegisterCertificationJobs(scheduler.Default, queue.Default)
+	service.RegisterMailJobs(scheduler.Default, queue.Default, mailer)
+	service.RegisterWebhookJobs(queue.Default)
+	service.RegisterScanJobs(queue.Default)
+	service.RegisterPreviewJobs(queue.Default)
+	service.RegisterUploadJobs(scheduler.Default)
+	service.RegisterBlobJobs(scheduler.Default)
+	service.RegisterRealtimeJobs(scheduler.Default)
+	service.RegisterOutboxJobs(scheduler.Default, queue.Default)
+	service.RegisterReconcileJobs(scheduler.Default)
+	service.RegisterListingJobs(scheduler.Default)
+	scheduler.Default.Start()
+	queue.Default.Start()
+	realtime.Default.Start()
+
 	// Health check endpoint
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
@@ -84,9 +152,28 @@ func main() {
 	})
 
 	// Start server
-	log.Println("Server running on http://localhost:8080")
-	log.Println("Swagger documentation: http://localhost:8080/swagger/index.html")
-	if err := app.Listen(":8080"); err != nil {
-		log.Fatal(err)
+	go func() {
+		log.Println("Server running on http://localhost:8080")
+		log.Println("Swagger documentation: http://localhost:8080/swagger/index.html")
+		if err := app.Listen(":8080"); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	// Graceful shutdown: stop accepting requests, then let background work finish
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down...")
+
+	// Close open event streams first; the HTTP server waits for them otherwise
+	realtime.Default.Stop()
+	if err := app.ShutdownWithTimeout(10 * time.Second); err != nil {
+		log.Println("HTTP server shutdown error:", err)
 	}
+	scheduler.Default.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	queue.Default.Stop(ctx)
 }
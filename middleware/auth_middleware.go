@@ -6,6 +6,8 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+
+	"UAS/utils"
 )
 
 // AuthMiddleware validates JWT token
@@ -51,6 +53,15 @@ func AuthMiddleware(c *fiber.Ctx) error {
 		})
 	}
 
+	// Stream tokens only open the event stream, see StreamAuthMiddleware
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && claims["type"] == "stream" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status": "error",
+			"code":   401,
+			"error":  "stream tokens can only open the event stream",
+		})
+	}
+
 	// Store claims in locals for use in handlers
 	if claims, ok := token.Claims.(jwt.MapClaims); ok {
 		c.Locals("userID", claims["user_id"])
@@ -62,3 +73,28 @@ func AuthMiddleware(c *fiber.Ctx) error {
 
 	return c.Next()
 }
+
+// StreamAuthMiddleware authenticates the real-time event stream. EventSource cannot set headers, so
+// besides the Authorization header it accepts a short-lived stream token (POST /realtime/tokens) as
+// the access_token query parameter. Only routes using this middleware accept such tokens
+func StreamAuthMiddleware(c *fiber.Ctx) error {
+	tokenString := c.Query("access_token")
+	if c.Get("Authorization") != "" || tokenString == "" {
+		return AuthMiddleware(c)
+	}
+
+	claims, err := utils.ValidateStreamToken(tokenString)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status": "error",
+			"code":   401,
+			"error":  "invalid or expired stream token",
+		})
+	}
+
+	c.Locals("userID", claims["user_id"])
+	c.Locals("role", claims["role"])
+	c.Locals("permissions", claims["permissions"])
+
+	return c.Next()
+}
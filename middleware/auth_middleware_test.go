@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"UAS/app/models"
+	"UAS/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// authStatus requests target on an app that serves /events behind StreamAuthMiddleware and /other
+// behind AuthMiddleware, and returns the response status
+func authStatus(t *testing.T, target, authorization string) int {
+	app := fiber.New()
+	ok := func(c *fiber.Ctx) error { return c.SendString(c.Locals("userID").(string)) }
+	app.Get("/events", StreamAuthMiddleware, ok)
+	app.Get("/other", AuthMiddleware, ok)
+
+	req := httptest.NewRequest("GET", target, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	return resp.StatusCode
+}
+
+// TestStreamTokenScope tests that only short-lived stream tokens are accepted in the query string,
+// and only by the event stream
+func TestStreamTokenScope(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+	access, err := utils.GenerateJWT(&models.User{ID: "u1"}, models.Role{Name: "Mahasiswa"}, nil)
+	assert.NoError(t, err)
+	stream, _, err := utils.GenerateStreamToken("u1", "Mahasiswa", nil, time.Minute)
+	assert.NoError(t, err)
+	expired, _, err := utils.GenerateStreamToken("u1", "Mahasiswa", nil, -time.Minute)
+	assert.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, authStatus(t, "/events?access_token="+stream, ""))
+	assert.Equal(t, fiber.StatusOK, authStatus(t, "/events", "Bearer "+access))
+	assert.Equal(t, fiber.StatusUnauthorized, authStatus(t, "/events?access_token="+access, ""))
+	assert.Equal(t, fiber.StatusUnauthorized, authStatus(t, "/events?access_token="+expired, ""))
+	assert.Equal(t, fiber.StatusUnauthorized, authStatus(t, "/other?access_token="+access, ""))
+	assert.Equal(t, fiber.StatusUnauthorized, authStatus(t, "/other?access_token="+stream, ""))
+	assert.Equal(t, fiber.StatusUnauthorized, authStatus(t, "/other", "Bearer "+stream))
+}
@@ -0,0 +1,64 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PdftoppmRenderer renders PDF pages with pdftoppm from poppler-utils
+type PdftoppmRenderer struct {
+	Path    string        // pdftoppm binary
+	Timeout time.Duration // per file; zero means no deadline besides the context
+}
+
+// FirstPage writes the PDF to a temporary directory and has pdftoppm render page one to PNG
+func (p *PdftoppmRenderer) FirstPage(ctx context.Context, pdf io.Reader, size int) (image.Image, error) {
+	dir, err := os.MkdirTemp("", "preview-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "input.pdf")
+	f, err := os.Create(input)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(f, pdf)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+	root := filepath.Join(dir, "page")
+	cmd := exec.CommandContext(ctx, p.Path, "-f", "1", "-l", "1", "-singlefile", "-png", "-scale-to", strconv.Itoa(size), input, root)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("pdftoppm: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	out, err := os.Open(root + ".png")
+	if err != nil {
+		return nil, fmt.Errorf("pdftoppm: no page rendered: %w", err)
+	}
+	defer out.Close()
+	return png.Decode(out)
+}
@@ -0,0 +1,149 @@
+// Package preview renders JPEG previews of attachments so reviewers can look at them without
+// downloading the file: thumbnails of JPEG, PNG and WebP images and an image of the first page of
+// PDFs. Images are handled in-process; PDFs need a PDFRenderer, PdftoppmRenderer runs poppler's
+// pdftoppm. Without a renderer PDFs get no preview.
+package preview
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// ThumbnailSize is the longest side of image thumbnails, in pixels
+	ThumbnailSize = 320
+	// PageSize is the longest side of PDF page previews, large enough to read a certificate
+	PageSize = 1024
+	// jpegQuality is the quality previews are encoded with
+	jpegQuality = 80
+	// MaxImagePixels is the largest image decoded for a preview, the same limit uploads are held to.
+	// Decoding allocates the whole bitmap, so larger images could exhaust the worker's memory
+	MaxImagePixels = 50_000_000
+)
+
+var (
+	// ErrUnsupported is returned for files no preview can be made of
+	ErrUnsupported = errors.New("preview: unsupported file type")
+	// ErrTooLarge is returned for images whose dimensions exceed MaxImagePixels
+	ErrTooLarge = errors.New("preview: image dimensions are too large")
+)
+
+// Image is a rendered preview
+type Image struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// PDFRenderer rasterizes the first page of a PDF
+type PDFRenderer interface {
+	// FirstPage renders page one with its longest side at most size pixels
+	FirstPage(ctx context.Context, pdf io.Reader, size int) (image.Image, error)
+}
+
+// Generator makes previews of attachment files
+type Generator struct {
+	PDF PDFRenderer // nil when PDFs get no preview
+}
+
+// Default is the generator used by the API process. main replaces it with the configured one
+var Default = &Generator{}
+
+// NewFromEnv creates the generator with the PDF renderer selected by PREVIEW_PDF_RENDERER:
+//   - none: PDFs get no preview (default)
+//   - pdftoppm: the first page is rendered by PDFTOPPM_PATH (default "pdftoppm" from PATH) with
+//     PREVIEW_PDF_TIMEOUT per file (default 30s)
+func NewFromEnv() (*Generator, error) {
+	switch renderer := os.Getenv("PREVIEW_PDF_RENDERER"); renderer {
+	case "", "none":
+		return &Generator{}, nil
+	case "pdftoppm":
+		r := &PdftoppmRenderer{Path: "pdftoppm", Timeout: 30 * time.Second}
+		if path := os.Getenv("PDFTOPPM_PATH"); path != "" {
+			r.Path = path
+		}
+		if v := os.Getenv("PREVIEW_PDF_TIMEOUT"); v != "" {
+			timeout, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid PREVIEW_PDF_TIMEOUT: %s", v)
+			}
+			r.Timeout = timeout
+		}
+		return &Generator{PDF: r}, nil
+	default:
+		return nil, fmt.Errorf("unknown PREVIEW_PDF_RENDERER: %s", renderer)
+	}
+}
+
+// Generate renders the preview of a file of the given (sniffed) content type. It returns
+// ErrUnsupported when no preview can be made of the type
+func (g *Generator) Generate(ctx context.Context, contentType string, r io.Reader) (*Image, error) {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/webp":
+		// Check the declared size before decoding; the header bytes read are replayed to the decoder
+		var header bytes.Buffer
+		cfg, _, err := image.DecodeConfig(io.TeeReader(r, &header))
+		if err != nil {
+			return nil, fmt.Errorf("preview: decoding image: %w", err)
+		}
+		if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxImagePixels {
+			return nil, ErrTooLarge
+		}
+		img, _, err := image.Decode(io.MultiReader(&header, r))
+		if err != nil {
+			return nil, fmt.Errorf("preview: decoding image: %w", err)
+		}
+		return encode(Thumbnail(img, ThumbnailSize))
+	case "application/pdf":
+		if g.PDF == nil {
+			return nil, ErrUnsupported
+		}
+		page, err := g.PDF.FirstPage(ctx, r, PageSize)
+		if err != nil {
+			return nil, err
+		}
+		return encode(Thumbnail(page, PageSize))
+	}
+	return nil, ErrUnsupported
+}
+
+// Thumbnail scales img down so its longest side is at most size pixels, keeping the aspect ratio.
+// Transparent areas become white since previews are JPEGs
+func Thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > size || height > size {
+		if width >= height {
+			width, height = size, max(1, height*size/width)
+		} else {
+			width, height = max(1, width*size/height), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+	return dst
+}
+
+func encode(img image.Image) (*Image, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, fmt.Errorf("preview: encoding: %w", err)
+	}
+	bounds := img.Bounds()
+	return &Image{Data: buf.Bytes(), ContentType: "image/jpeg", Width: bounds.Dx(), Height: bounds.Dy()}, nil
+}
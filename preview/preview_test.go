@@ -0,0 +1,122 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pngFile(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, 0, color.NRGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// TestThumbnail tests that images are scaled to fit the size, keeping the aspect ratio
+func TestThumbnail(t *testing.T) {
+	assert.Equal(t, image.Rect(0, 0, 320, 180), Thumbnail(image.NewRGBA(image.Rect(0, 0, 1920, 1080)), 320).Bounds())
+	assert.Equal(t, image.Rect(0, 0, 240, 320), Thumbnail(image.NewRGBA(image.Rect(0, 0, 600, 800)), 320).Bounds())
+	assert.Equal(t, image.Rect(0, 0, 320, 1), Thumbnail(image.NewRGBA(image.Rect(0, 0, 5000, 2)), 320).Bounds())
+	// Small images are not enlarged
+	assert.Equal(t, image.Rect(0, 0, 100, 50), Thumbnail(image.NewRGBA(image.Rect(0, 0, 100, 50)), 320).Bounds())
+
+	// Transparency becomes white
+	r, g, b, _ := Thumbnail(image.NewNRGBA(image.Rect(0, 0, 10, 10)), 320).At(5, 5).RGBA()
+	assert.Equal(t, []uint32{0xffff, 0xffff, 0xffff}, []uint32{r, g, b})
+}
+
+// TestGenerateImage tests that image thumbnails are JPEGs of the thumbnail size
+func TestGenerateImage(t *testing.T) {
+	result, err := Default.Generate(context.Background(), "image/png", bytes.NewReader(pngFile(t, 1000, 500)))
+	assert.NoError(t, err)
+	assert.Equal(t, "image/jpeg", result.ContentType)
+	assert.Equal(t, ThumbnailSize, result.Width)
+	assert.Equal(t, ThumbnailSize/2, result.Height)
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(result.Data))
+	assert.NoError(t, err)
+	assert.Equal(t, ThumbnailSize, cfg.Width)
+
+	_, err = Default.Generate(context.Background(), "image/png", strings.NewReader("not an image"))
+	assert.Error(t, err)
+}
+
+// TestGenerateRejectsHugeImages tests that images declaring more than MaxImagePixels are not decoded
+func TestGenerateRejectsHugeImages(t *testing.T) {
+	// A tiny PNG whose header claims 100000x100000 pixels
+	data := pngFile(t, 1, 1)
+	binary.BigEndian.PutUint32(data[16:], 100000)
+	binary.BigEndian.PutUint32(data[20:], 100000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	_, err := Default.Generate(context.Background(), "image/png", bytes.NewReader(data))
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+// TestGenerateUnsupported tests the types no preview is made of
+func TestGenerateUnsupported(t *testing.T) {
+	_, err := Default.Generate(context.Background(), "application/pdf", strings.NewReader("%PDF-1.4"))
+	assert.ErrorIs(t, err, ErrUnsupported)
+	_, err = Default.Generate(context.Background(), "application/zip", strings.NewReader("PK"))
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+// TestPdftoppmRenderer tests the pdftoppm invocation against a stand-in script
+func TestPdftoppmRenderer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell")
+	}
+	dir := t.TempDir()
+	page := filepath.Join(dir, "rendered.png")
+	assert.NoError(t, os.WriteFile(page, pngFile(t, 724, 1024), 0o644))
+
+	// The stand-in checks the arguments and copies the rendered page to <root>.png
+	script := filepath.Join(dir, "pdftoppm")
+	assert.NoError(t, os.WriteFile(script, []byte(`#!/bin/sh
+[ "$1 $2 $3 $4 $5 $6 $7 $8" = "-f 1 -l 1 -singlefile -png -scale-to 1024" ] || { echo "bad arguments: $*" >&2; exit 99; }
+head -c 5 "$9" | grep -q '%PDF-' || { echo "Syntax Error" >&2; exit 1; }
+cp `+page+` "${10}.png"
+`), 0o755))
+
+	g := &Generator{PDF: &PdftoppmRenderer{Path: script, Timeout: 10 * time.Second}}
+	result, err := g.Generate(context.Background(), "application/pdf", strings.NewReader("%PDF-1.4\n..."))
+	assert.NoError(t, err)
+	assert.Equal(t, 724, result.Width)
+	assert.Equal(t, PageSize, result.Height)
+
+	_, err = g.Generate(context.Background(), "application/pdf", strings.NewReader("garbage"))
+	assert.ErrorContains(t, err, "Syntax Error")
+}
+
+// TestNewFromEnv tests renderer selection
+func TestNewFromEnv(t *testing.T) {
+	g, err := NewFromEnv()
+	assert.NoError(t, err)
+	assert.Nil(t, g.PDF)
+
+	t.Setenv("PREVIEW_PDF_RENDERER", "pdftoppm")
+	t.Setenv("PDFTOPPM_PATH", "/usr/local/bin/pdftoppm")
+	g, err = NewFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, &PdftoppmRenderer{Path: "/usr/local/bin/pdftoppm", Timeout: 30 * time.Second}, g.PDF)
+
+	t.Setenv("PREVIEW_PDF_RENDERER", "other")
+	_, err = NewFromEnv()
+	assert.Error(t, err)
+}
@@ -0,0 +1,320 @@
+// Package queue is a durable background job queue stored in PostgreSQL.
+// Any service can enqueue work with Enqueue; handlers are registered per job type with a
+// concurrency limit. Workers claim jobs with SELECT ... FOR UPDATE SKIP LOCKED, failed jobs
+// are retried with exponential backoff and end in the dead state after their last attempt.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+)
+
+const (
+	defaultMaxAttempts = 5
+	defaultConcurrency = 1
+	defaultTimeout     = 5 * time.Minute
+	pollInterval       = 2 * time.Second
+	baseBackoff        = 10 * time.Second
+	maxBackoff         = time.Hour
+)
+
+// ErrUnknownType is returned when enqueueing a job type without a registered handler
+var ErrUnknownType = errors.New("no handler registered for job type")
+
+// Handler processes one job. Returning an error schedules a retry; returning Postpone runs the job
+// again later without using up an attempt
+type Handler func(ctx context.Context, job *models.QueueJob) error
+
+// postponeError asks the queue to run a job again after delay without counting the attempt
+type postponeError struct {
+	delay  time.Duration
+	reason error
+}
+
+func (e *postponeError) Error() string { return e.reason.Error() }
+func (e *postponeError) Unwrap() error { return e.reason }
+
+// Postpone is returned by a handler whose job cannot make progress yet, e.g. because it waits for
+// other work. The job runs again after delay and the attempt does not count towards MaxAttempts
+func Postpone(delay time.Duration, reason error) error {
+	return &postponeError{delay: delay, reason: reason}
+}
+
+// HandlerOptions configures how jobs of one type are processed
+type HandlerOptions struct {
+	Concurrency int           // workers per instance, default 1
+	MaxAttempts int           // attempts before the job is dead, default 5
+	Timeout     time.Duration // per attempt, default 5 minutes
+}
+
+// EnqueueOptions configures a single job
+type EnqueueOptions struct {
+	RunAt       time.Time // delay the job until this time
+	MaxAttempts int       // overrides the handler default
+	Tx          *gorm.DB  // enqueue inside an existing transaction
+}
+
+type registration struct {
+	handler Handler
+	opts    HandlerOptions
+	wake    chan struct{}
+}
+
+// Queue dispatches stored jobs to registered handlers
+type Queue struct {
+	mu       sync.Mutex
+	handlers map[string]*registration
+	repo     *repository.QueueJobRepository
+	instance string
+	started  bool
+	stop     chan struct{}
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// Default is the queue used by the API process
+var Default = New()
+
+// New creates a queue without handlers
+func New() *Queue {
+	instance, _ := os.Hostname()
+	return &Queue{
+		handlers: make(map[string]*registration),
+		repo:     repository.NewQueueJobRepository(),
+		instance: instance,
+	}
+}
+
+// Register sets the handler of a job type. It panics on duplicates since that is a programming error
+func (q *Queue) Register(jobType string, handler Handler, opts HandlerOptions) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultConcurrency
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, exists := q.handlers[jobType]; exists {
+		panic("queue: duplicate handler for " + jobType)
+	}
+	q.handlers[jobType] = &registration{handler: handler, opts: opts, wake: make(chan struct{}, 1)}
+}
+
+// Enqueue stores a job for background processing. The payload is JSON encoded
+func (q *Queue) Enqueue(jobType string, payload interface{}, opts ...EnqueueOptions) (*models.QueueJob, error) {
+	var o EnqueueOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	q.mu.Lock()
+	reg, ok := q.handlers[jobType]
+	q.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, jobType)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &models.QueueJob{
+		ID:          uuid.New().String(),
+		Type:        jobType,
+		Payload:     string(data),
+		Status:      models.QueueJobPending,
+		RunAt:       now,
+		MaxAttempts: reg.opts.MaxAttempts,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if !o.RunAt.IsZero() {
+		job.RunAt = o.RunAt
+	}
+	if o.MaxAttempts > 0 {
+		job.MaxAttempts = o.MaxAttempts
+	}
+
+	if err := q.repo.Create(o.Tx, job); err != nil {
+		return nil, err
+	}
+
+	// Wake a local worker; jobs inside a transaction are picked up by polling after commit
+	if o.Tx == nil {
+		select {
+		case reg.wake <- struct{}{}:
+		default:
+		}
+	}
+	return job, nil
+}
+
+// Decode unmarshals a job payload into v
+func Decode(job *models.QueueJob, v interface{}) error {
+	return json.Unmarshal([]byte(job.Payload), v)
+}
+
+// Start launches the workers of every registered job type
+func (q *Queue) Start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.started {
+		return
+	}
+	q.started = true
+	q.stop = make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+
+	for jobType, reg := range q.handlers {
+		for i := 0; i < reg.opts.Concurrency; i++ {
+			q.wg.Add(1)
+			go q.work(ctx, jobType, reg, fmt.Sprintf("%s/%s/%d", q.instance, jobType, i))
+		}
+	}
+	log.Printf("Job queue started with %d job types", len(q.handlers))
+}
+
+// Stop stops claiming new jobs and waits for running ones until ctx is done.
+// Jobs still running at the deadline are cancelled and retried later
+func (q *Queue) Stop(ctx context.Context) {
+	q.mu.Lock()
+	if !q.started {
+		q.mu.Unlock()
+		return
+	}
+	q.started = false
+	close(q.stop)
+	cancel := q.cancel
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		cancel()
+		<-done
+	}
+	cancel()
+}
+
+func (q *Queue) work(ctx context.Context, jobType string, reg *registration, workerID string) {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+
+		job, err := q.repo.Claim(jobType, workerID, time.Now().Add(-2*reg.opts.Timeout))
+		if err != nil {
+			log.Printf("Queue worker %s failed to claim job: %v", workerID, err)
+		}
+		if job != nil {
+			q.process(ctx, reg, job)
+			continue
+		}
+
+		select {
+		case <-q.stop:
+			return
+		case <-reg.wake:
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// process runs one job and records the outcome
+func (q *Queue) process(ctx context.Context, reg *registration, job *models.QueueJob) {
+	runCtx, cancel := context.WithTimeout(ctx, reg.opts.Timeout)
+	defer cancel()
+
+	err := safeHandle(runCtx, reg.handler, job)
+	if err == nil {
+		if err := q.repo.Complete(job.ID); err != nil {
+			log.Printf("Failed to complete job %s: %v", job.ID, err)
+		}
+		return
+	}
+
+	var postponed *postponeError
+	if errors.As(err, &postponed) {
+		if err := q.repo.Postpone(job.ID, err.Error(), time.Now().Add(withJitter(postponed.delay))); err != nil {
+			log.Printf("Failed to postpone job %s: %v", job.ID, err)
+		}
+		return
+	}
+
+	var retryAt *time.Time
+	if job.Attempts < job.MaxAttempts {
+		next := time.Now().Add(withJitter(Backoff(job.Attempts)))
+		retryAt = &next
+	} else {
+		log.Printf("Job %s (%s) is dead after %d attempts: %v", job.ID, job.Type, job.Attempts, err)
+	}
+	if err := q.repo.Fail(job.ID, err.Error(), retryAt); err != nil {
+		log.Printf("Failed to record failure of job %s: %v", job.ID, err)
+	}
+}
+
+func safeHandle(ctx context.Context, handler Handler, job *models.QueueJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// Backoff returns the delay before retrying after the given number of attempts
+// 10s, 20s, 40s, ... capped at one hour
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := baseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
+
+// withJitter adds up to 10% random delay so retries of many jobs do not line up
+func withJitter(d time.Duration) time.Duration {
+	return d + time.Duration(rand.Int63n(int64(d)/10+1))
+}
+
+// Enqueue stores a job on the default queue
+func Enqueue(jobType string, payload interface{}, opts ...EnqueueOptions) (*models.QueueJob, error) {
+	return Default.Enqueue(jobType, payload, opts...)
+}
@@ -0,0 +1,77 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"UAS/app/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBackoff tests exponential retry delays
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, Backoff(0))
+	assert.Equal(t, 10*time.Second, Backoff(1))
+	assert.Equal(t, 20*time.Second, Backoff(2))
+	assert.Equal(t, 80*time.Second, Backoff(4))
+	assert.Equal(t, time.Hour, Backoff(20))
+}
+
+// TestWithJitter tests that jitter stays within 10%
+func TestWithJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		d := withJitter(time.Minute)
+		assert.GreaterOrEqual(t, d, time.Minute)
+		assert.LessOrEqual(t, d, time.Minute+6*time.Second)
+	}
+}
+
+// TestRegisterAndEnqueueGuards tests handler registration defaults and unknown job types
+func TestRegisterAndEnqueueGuards(t *testing.T) {
+	q := New()
+	noop := func(ctx context.Context, job *models.QueueJob) error { return nil }
+	q.Register("email", noop, HandlerOptions{})
+
+	reg := q.handlers["email"]
+	assert.Equal(t, defaultConcurrency, reg.opts.Concurrency)
+	assert.Equal(t, defaultMaxAttempts, reg.opts.MaxAttempts)
+	assert.Equal(t, defaultTimeout, reg.opts.Timeout)
+
+	assert.Panics(t, func() { q.Register("email", noop, HandlerOptions{}) })
+
+	_, err := q.Enqueue("thumbnail", map[string]string{"id": "1"})
+	assert.ErrorIs(t, err, ErrUnknownType)
+}
+
+// TestPostpone tests that postponed errors keep their reason and are recognised through wrapping
+func TestPostpone(t *testing.T) {
+	reason := errors.New("waiting")
+	err := fmt.Errorf("apply: %w", Postpone(time.Minute, reason))
+
+	var postponed *postponeError
+	assert.True(t, errors.As(err, &postponed))
+	assert.Equal(t, time.Minute, postponed.delay)
+	assert.ErrorIs(t, err, reason)
+	assert.EqualError(t, err, "apply: waiting")
+}
+
+// TestSafeHandle tests that handler panics become errors
+func TestSafeHandle(t *testing.T) {
+	err := safeHandle(context.Background(), func(ctx context.Context, job *models.QueueJob) error {
+		panic("boom")
+	}, &models.QueueJob{})
+	assert.EqualError(t, err, "panic: boom")
+}
+
+// TestDecode tests payload decoding
+func TestDecode(t *testing.T) {
+	var payload struct {
+		AchievementID string `json:"achievement_id"`
+	}
+	assert.NoError(t, Decode(&models.QueueJob{Payload: `{"achievement_id":"a1"}`}, &payload))
+	assert.Equal(t, "a1", payload.AchievementID)
+}
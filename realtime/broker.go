@@ -0,0 +1,257 @@
+// Package realtime streams achievement events to connected clients.
+// Events are stored in PostgreSQL and every API instance polls for new rows, so a client
+// connected to any instance receives events published on all of them, and a reconnecting
+// client resumes after its Last-Event-ID by replaying the stored rows.
+package realtime
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+)
+
+const (
+	pollInterval = 500 * time.Millisecond
+	pollBatch    = 500
+	bufferSize   = 64
+	// gapTimeout is how long the broker waits for a missing event ID to commit before it gives up
+	// on it; rolled-back inserts leave gaps that never fill
+	gapTimeout = 30 * time.Second
+	// ReplayLimit caps how many missed events are replayed to a reconnecting client
+	ReplayLimit = 1000
+)
+
+// Filter decides whether a subscriber may receive an event
+type Filter func(event *models.RealtimeEvent) bool
+
+// Subscription receives the events accepted by its filter. Events is closed when the
+// subscriber falls too far behind or the broker stops; the client should then reconnect
+type Subscription struct {
+	Events <-chan models.RealtimeEvent
+
+	events chan models.RealtimeEvent
+	filter Filter
+	broker *Broker
+	once   sync.Once
+}
+
+// Close unsubscribes
+func (s *Subscription) Close() {
+	s.broker.remove(s)
+}
+
+// Broker fans stored events out to the subscribers connected to this instance
+type Broker struct {
+	repo *repository.RealtimeEventRepository
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	started     bool
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+// Default is the broker used by the API process
+var Default = NewBroker()
+
+// NewBroker creates a broker without subscribers
+func NewBroker() *Broker {
+	return &Broker{
+		repo:        repository.NewRealtimeEventRepository(),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish stores an event; subscribers on every instance receive it on their next poll
+func (b *Broker) Publish(event *models.RealtimeEvent) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	return b.repo.Create(event)
+}
+
+// Subscribe registers a subscriber for events published from now on
+func (b *Broker) Subscribe(filter Filter) *Subscription {
+	events := make(chan models.RealtimeEvent, bufferSize)
+	sub := &Subscription{Events: events, events: events, filter: filter, broker: b}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.started {
+		sub.once.Do(func() { close(events) })
+		return sub
+	}
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Replay returns the stored events after afterID accepted by the filter, oldest first.
+// complete is false when more than ReplayLimit events were missed
+func (b *Broker) Replay(afterID int64, filter Filter) (events []models.RealtimeEvent, complete bool, err error) {
+	rows, err := b.repo.FindAfter(afterID, ReplayLimit)
+	if err != nil {
+		return nil, false, err
+	}
+	for i := range rows {
+		if filter(&rows[i]) {
+			events = append(events, rows[i])
+		}
+	}
+	return events, len(rows) < ReplayLimit, nil
+}
+
+func (b *Broker) remove(sub *Subscription) {
+	b.mu.Lock()
+	delete(b.subscribers, sub)
+	b.mu.Unlock()
+	sub.once.Do(func() { close(sub.events) })
+}
+
+// Start begins polling for new events
+func (b *Broker) Start() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.started {
+		return
+	}
+
+	lastID, err := b.repo.LatestID()
+	if err != nil {
+		log.Println("Real-time broker failed to read the latest event:", err)
+	}
+	b.started = true
+	b.stop = make(chan struct{})
+	b.done = make(chan struct{})
+	go b.run(lastID)
+}
+
+// Stop stops polling and closes every subscription so open streams end
+func (b *Broker) Stop() {
+	b.mu.Lock()
+	if !b.started {
+		b.mu.Unlock()
+		return
+	}
+	b.started = false
+	close(b.stop)
+	b.mu.Unlock()
+	<-b.done
+
+	b.mu.Lock()
+	subs := make([]*Subscription, 0, len(b.subscribers))
+	for sub := range b.subscribers {
+		subs = append(subs, sub)
+	}
+	b.mu.Unlock()
+	for _, sub := range subs {
+		b.remove(sub)
+	}
+}
+
+func (b *Broker) run(lastID int64) {
+	defer close(b.done)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	cursor := newPollCursor(lastID)
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+		}
+
+		// Read everything after the cursor, which stays below uncommitted IDs, so events that
+		// commit out of ID order are still picked up; those already dispatched are skipped
+		from := cursor.after
+		for {
+			events, err := b.repo.FindAfter(from, pollBatch)
+			if err != nil {
+				log.Println("Real-time broker failed to poll events:", err)
+				break
+			}
+			for _, event := range cursor.accept(events, time.Now()) {
+				b.dispatch(&event)
+			}
+			if len(events) < pollBatch {
+				break
+			}
+			from = events[len(events)-1].ID
+		}
+	}
+}
+
+// dispatch delivers an event to every subscriber whose filter accepts it
+// Subscribers with a full buffer are dropped; they catch up by reconnecting with Last-Event-ID
+func (b *Broker) dispatch(event *models.RealtimeEvent) {
+	b.mu.Lock()
+	var slow []*Subscription
+	for sub := range b.subscribers {
+		if !sub.filter(event) {
+			continue
+		}
+		select {
+		case sub.events <- *event:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, sub := range slow {
+		b.remove(sub)
+	}
+}
+
+// pollCursor tracks which events the broker has dispatched. Event IDs come from a sequence and are
+// assigned before the inserting transaction commits, so a lower ID can become visible after a
+// higher one has been read. The cursor stays below such gaps until they fill or gapTimeout passes,
+// and remembers the events above it that were already dispatched
+type pollCursor struct {
+	after int64               // every ID up to after was dispatched or given up on
+	high  int64               // highest ID dispatched
+	seen  map[int64]struct{}  // IDs above after that were dispatched
+	gaps  map[int64]time.Time // IDs above after not seen yet, with when they were first missed
+}
+
+func newPollCursor(after int64) *pollCursor {
+	return &pollCursor{after: after, high: after, seen: make(map[int64]struct{}), gaps: make(map[int64]time.Time)}
+}
+
+// accept takes polled events, oldest first, and returns the ones not dispatched yet
+func (c *pollCursor) accept(events []models.RealtimeEvent, now time.Time) []models.RealtimeEvent {
+	var fresh []models.RealtimeEvent
+	for _, event := range events {
+		if _, ok := c.seen[event.ID]; ok || event.ID <= c.after {
+			continue
+		}
+		c.seen[event.ID] = struct{}{}
+		delete(c.gaps, event.ID)
+		for id := c.high + 1; id < event.ID; id++ {
+			c.gaps[id] = now
+		}
+		c.high = max(c.high, event.ID)
+		fresh = append(fresh, event)
+	}
+
+	for c.after < c.high {
+		id := c.after + 1
+		if _, ok := c.seen[id]; ok {
+			delete(c.seen, id)
+		} else if missed, ok := c.gaps[id]; ok && now.Sub(missed) >= gapTimeout {
+			delete(c.gaps, id)
+		} else {
+			break
+		}
+		c.after = id
+	}
+	return fresh
+}
+
+// Publish stores an event on the default broker
+func Publish(event *models.RealtimeEvent) error {
+	return Default.Publish(event)
+}
@@ -0,0 +1,98 @@
+package realtime
+
+import (
+	"testing"
+	"time"
+
+	"UAS/app/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func startedBroker() *Broker {
+	b := NewBroker()
+	b.started = true
+	return b
+}
+
+// TestDispatch tests that events reach only the subscribers whose filter accepts them
+func TestDispatch(t *testing.T) {
+	b := startedBroker()
+	mine := b.Subscribe(func(e *models.RealtimeEvent) bool { return e.StudentID == "u1" })
+	all := b.Subscribe(func(e *models.RealtimeEvent) bool { return true })
+	defer mine.Close()
+	defer all.Close()
+
+	b.dispatch(&models.RealtimeEvent{ID: 1, StudentID: "u1"})
+	b.dispatch(&models.RealtimeEvent{ID: 2, StudentID: "u2"})
+
+	assert.Len(t, mine.Events, 1)
+	assert.Len(t, all.Events, 2)
+	assert.Equal(t, int64(1), (<-mine.Events).ID)
+}
+
+// TestDispatchSlowSubscriber tests that a subscriber with a full buffer is dropped instead of blocking others
+func TestDispatchSlowSubscriber(t *testing.T) {
+	b := startedBroker()
+	slow := b.Subscribe(func(e *models.RealtimeEvent) bool { return true })
+
+	for i := 1; i <= bufferSize+1; i++ {
+		b.dispatch(&models.RealtimeEvent{ID: int64(i)})
+	}
+
+	received := 0
+	for range slow.Events {
+		received++
+	}
+	assert.Equal(t, bufferSize, received, "channel is closed after the buffered events")
+	assert.Empty(t, b.subscribers)
+	slow.Close() // closing again is safe
+}
+
+// TestSubscribeNotStarted tests that subscribing to a stopped broker ends the stream immediately
+func TestSubscribeNotStarted(t *testing.T) {
+	sub := NewBroker().Subscribe(func(e *models.RealtimeEvent) bool { return true })
+	_, ok := <-sub.Events
+	assert.False(t, ok)
+	sub.Close()
+}
+
+func eventIDs(events []models.RealtimeEvent) []int64 {
+	ids := []int64{}
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+// TestPollCursorOutOfOrderCommit tests that an event whose transaction commits after a higher ID was
+// read is still dispatched, and only once
+func TestPollCursorOutOfOrderCommit(t *testing.T) {
+	now := time.Now()
+	cursor := newPollCursor(0)
+
+	// 3 was assigned but not committed yet
+	assert.Equal(t, []int64{1, 2, 4}, eventIDs(cursor.accept([]models.RealtimeEvent{{ID: 1}, {ID: 2}, {ID: 4}}, now)))
+	assert.Equal(t, int64(2), cursor.after)
+
+	// 3 commits; the next poll reads from 2 again and skips 4
+	assert.Equal(t, []int64{3, 5}, eventIDs(cursor.accept([]models.RealtimeEvent{{ID: 3}, {ID: 4}, {ID: 5}}, now)))
+	assert.Equal(t, int64(5), cursor.after)
+	assert.Empty(t, cursor.seen)
+	assert.Empty(t, cursor.gaps)
+}
+
+// TestPollCursorRolledBackGap tests that the cursor moves past an ID that never commits once
+// gapTimeout has passed
+func TestPollCursorRolledBackGap(t *testing.T) {
+	now := time.Now()
+	cursor := newPollCursor(10)
+
+	assert.Equal(t, []int64{12}, eventIDs(cursor.accept([]models.RealtimeEvent{{ID: 12}}, now)))
+	assert.Empty(t, eventIDs(cursor.accept([]models.RealtimeEvent{{ID: 12}}, now.Add(gapTimeout/2))))
+	assert.Equal(t, int64(10), cursor.after)
+
+	assert.Equal(t, []int64{13}, eventIDs(cursor.accept([]models.RealtimeEvent{{ID: 12}, {ID: 13}}, now.Add(gapTimeout))))
+	assert.Equal(t, int64(13), cursor.after)
+	assert.Empty(t, cursor.gaps)
+}
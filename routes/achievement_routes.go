@@ -9,8 +9,21 @@ import (
 
 func SetupAchievementRoutes(app *fiber.App) {
 	svc := service.NewAchievementService()
+	participantSvc := service.NewParticipantService()
+	attachmentSvc := service.NewAttachmentService()
+	uploadSvc := service.NewUploadService()
+
+	// Signed attachment URLs carry their own authorization and need no token
+	app.Get("/api/v1/files/achievements/:id/:attachmentId", attachmentSvc.DownloadSignedAttachment)
+	app.Get("/api/v1/files/achievements/:id/:attachmentId/preview", attachmentSvc.DownloadSignedPreview)
+
 	g := app.Group("/api/v1/achievements", middleware.AuthMiddleware)
 
+	// Static paths must be registered before /:id
+	g.Get("/invitations", middleware.RBACMiddleware("achievement:read"), participantSvc.ListInvitations)
+	g.Post("/bulk/verify", middleware.RBACMiddleware("achievement:verify"), svc.BulkVerifyAchievements)
+	g.Post("/bulk/reject", middleware.RBACMiddleware("achievement:verify"), svc.BulkRejectAchievements)
+
 	g.Get("/", middleware.RBACMiddleware("achievement:read"), svc.ListAchievements)
 	g.Get("/:id", middleware.RBACMiddleware("achievement:read"), svc.GetAchievementDetail)
 	g.Post("/", middleware.RBACMiddleware("achievement:create"), svc.CreateAchievement)
@@ -20,5 +33,30 @@ func SetupAchievementRoutes(app *fiber.App) {
 	g.Post("/:id/verify", middleware.RBACMiddleware("achievement:verify"), svc.VerifyAchievement)
 	g.Post("/:id/reject", middleware.RBACMiddleware("achievement:verify"), svc.RejectAchievement)
 	g.Get("/:id/history", middleware.RBACMiddleware("achievement:read"), svc.GetAchievementHistory)
+	g.Get("/:id/attachments", middleware.RBACMiddleware("achievement:read"), attachmentSvc.ListAttachments)
 	g.Post("/:id/attachments", middleware.RBACMiddleware("achievement:update"), svc.UploadAttachment)
+	g.Put("/:id/attachments/order", middleware.RBACMiddleware("achievement:update"), attachmentSvc.ReorderAttachments)
+	g.Get("/:id/attachments/:attachmentId", middleware.RBACMiddleware("achievement:read"), attachmentSvc.DownloadAttachment)
+	g.Get("/:id/attachments/:attachmentId/preview", middleware.RBACMiddleware("achievement:read"), attachmentSvc.DownloadPreview)
+	g.Put("/:id/attachments/:attachmentId", middleware.RBACMiddleware("achievement:update"), attachmentSvc.ReplaceAttachment)
+	g.Patch("/:id/attachments/:attachmentId", middleware.RBACMiddleware("achievement:update"), attachmentSvc.UpdateAttachment)
+	g.Delete("/:id/attachments/:attachmentId", middleware.RBACMiddleware("achievement:update"), attachmentSvc.DeleteAttachment)
+
+	// Resumable attachment uploads (tus)
+	g.Options("/:id/uploads", middleware.RBACMiddleware("achievement:update"), uploadSvc.UploadOptions)
+	g.Post("/:id/uploads", middleware.RBACMiddleware("achievement:update"), uploadSvc.CreateUpload)
+	g.Head("/:id/uploads/:uploadId", middleware.RBACMiddleware("achievement:update"), uploadSvc.GetUploadOffset)
+	g.Patch("/:id/uploads/:uploadId", middleware.RBACMiddleware("achievement:update"), uploadSvc.PatchUpload)
+	g.Delete("/:id/uploads/:uploadId", middleware.RBACMiddleware("achievement:update"), uploadSvc.DeleteUpload)
+
+	g.Get("/:id/points-suggestion", middleware.RBACMiddleware("achievement:read"), svc.GetPointsSuggestion)
+	g.Get("/:id/duplicates", middleware.RBACMiddleware("achievement:read"), svc.ListDuplicateFlags)
+	g.Post("/:id/duplicates/:flagId/dismiss", middleware.RBACMiddleware("achievement:verify"), svc.DismissDuplicateFlag)
+
+	// Shared (team / co-authored) achievements
+	g.Get("/:id/participants", middleware.RBACMiddleware("achievement:read"), participantSvc.ListParticipants)
+	g.Post("/:id/participants", middleware.RBACMiddleware("achievement:update"), participantSvc.AddParticipant)
+	g.Delete("/:id/participants/:participantId", middleware.RBACMiddleware("achievement:update"), participantSvc.RemoveParticipant)
+	g.Post("/:id/invitation/accept", middleware.RBACMiddleware("achievement:read"), participantSvc.AcceptInvitation)
+	g.Post("/:id/invitation/decline", middleware.RBACMiddleware("achievement:read"), participantSvc.DeclineInvitation)
 }
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"UAS/app/service"
+	"UAS/middleware"
+)
+
+// SetupAchievementTypeRoutes sets up achievement type registry routes
+func SetupAchievementTypeRoutes(app *fiber.App) {
+	svc := service.NewAchievementTypeService()
+	g := app.Group("/api/v1/achievement-types", middleware.AuthMiddleware)
+
+	// Registry is readable by everyone, managed by Admin (user:manage permission)
+	g.Get("/", svc.ListAchievementTypes)
+	g.Get("/:code", svc.GetAchievementType)
+	g.Post("/", middleware.RBACMiddleware("user:manage"), svc.CreateAchievementType)
+	g.Put("/:code", middleware.RBACMiddleware("user:manage"), svc.UpdateAchievementType)
+	g.Delete("/:code", middleware.RBACMiddleware("user:manage"), svc.DeleteAchievementType)
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"UAS/app/service"
+	"UAS/middleware"
+)
+
+// SetupJobRoutes sets up background job administration routes (Admin only, user:manage permission)
+func SetupJobRoutes(app *fiber.App) {
+	svc := service.NewJobService()
+	g := app.Group("/api/v1/jobs", middleware.AuthMiddleware, middleware.RBACMiddleware("user:manage"))
+
+	g.Get("/", svc.ListJobs)
+	g.Get("/queue", svc.ListQueueJobs)
+	g.Post("/queue/:id/retry", svc.RetryQueueJob)
+	g.Get("/:name/runs", svc.ListJobRuns)
+	g.Post("/:name/trigger", svc.TriggerJob)
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"UAS/app/service"
+	"UAS/middleware"
+)
+
+// SetupNotificationRoutes sets up notification center routes
+// Every authenticated user manages only their own notifications, so no permission is required
+func SetupNotificationRoutes(app *fiber.App) {
+	svc := service.NewNotificationService()
+	g := app.Group("/api/v1/notifications", middleware.AuthMiddleware)
+
+	g.Get("/", svc.ListNotifications)
+	g.Get("/unread-count", svc.GetUnreadCount)
+	g.Get("/preferences", svc.GetPreferences)
+	g.Put("/preferences", svc.UpdatePreferences)
+	g.Post("/read", svc.MarkManyAsRead)
+	g.Post("/read-all", svc.MarkAllAsRead)
+	g.Post("/:id/read", svc.MarkAsRead)
+}
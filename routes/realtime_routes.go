@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"UAS/app/service"
+	"UAS/middleware"
+)
+
+// SetupRealtimeRoutes sets up the Server-Sent Events stream
+// Every authenticated user receives only the events they may see, so no permission is required
+func SetupRealtimeRoutes(app *fiber.App) {
+	svc := service.NewRealtimeService()
+	g := app.Group("/api/v1/realtime")
+
+	g.Post("/tokens", middleware.AuthMiddleware, svc.CreateStreamToken)
+	// Only the stream accepts stream tokens, which EventSource clients pass in the query string
+	g.Get("/events", middleware.StreamAuthMiddleware, svc.StreamEvents)
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"UAS/app/service"
+	"UAS/middleware"
+)
+
+// SetupReviewQueueRoutes sets up reviewer work queue routes
+func SetupReviewQueueRoutes(app *fiber.App) {
+	svc := service.NewReviewQueueService()
+	g := app.Group("/api/v1/review-queue", middleware.AuthMiddleware)
+
+	// Reviewers hold achievement:verify; SLA and escalation management is Admin only (user:manage permission)
+	g.Get("/", middleware.RBACMiddleware("achievement:verify"), svc.GetQueue)
+	g.Get("/sla", middleware.RBACMiddleware("achievement:verify"), svc.ListSLAs)
+	g.Put("/sla/:stage", middleware.RBACMiddleware("user:manage"), svc.UpdateSLA)
+	g.Get("/escalations", middleware.RBACMiddleware("user:manage"), svc.ListEscalations)
+	g.Post("/:id/claim", middleware.RBACMiddleware("achievement:verify"), svc.ClaimAchievement)
+	g.Post("/:id/release", middleware.RBACMiddleware("achievement:verify"), svc.ReleaseAchievement)
+}
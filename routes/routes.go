@@ -14,6 +14,9 @@ func SetupRoutes(app *fiber.App) {
 	// Setup achievement routes
 	SetupAchievementRoutes(app)
 
+	// Setup achievement type registry routes
+	SetupAchievementTypeRoutes(app)
+
 	// Setup user management routes
 	SetupUserRoutes(app)
 
@@ -25,4 +28,22 @@ func SetupRoutes(app *fiber.App) {
 
 	// Setup report and analytics routes
 	SetupReportRoutes(app)
+
+	// Setup scoring rubric routes
+	SetupScoringRoutes(app)
+
+	// Setup reviewer work queue routes
+	SetupReviewQueueRoutes(app)
+
+	// Setup background job administration routes
+	SetupJobRoutes(app)
+
+	// Setup notification center routes
+	SetupNotificationRoutes(app)
+
+	// Setup outbound webhook routes
+	SetupWebhookRoutes(app)
+
+	// Setup real-time event stream routes
+	SetupRealtimeRoutes(app)
 }
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"UAS/app/service"
+	"UAS/middleware"
+)
+
+// SetupScoringRoutes sets up scoring rubric routes
+func SetupScoringRoutes(app *fiber.App) {
+	svc := service.NewScoringService()
+	g := app.Group("/api/v1/scoring", middleware.AuthMiddleware)
+
+	// Everyone can read the active rubric; managing rubrics is Admin only (user:manage permission)
+	g.Get("/rubrics/active", svc.GetActiveRubric)
+	g.Get("/rubrics", middleware.RBACMiddleware("user:manage"), svc.ListRubrics)
+	g.Post("/rubrics", middleware.RBACMiddleware("user:manage"), svc.CreateRubric)
+	g.Post("/recompute", middleware.RBACMiddleware("user:manage"), svc.RecomputeScores)
+}
@@ -20,4 +20,6 @@ func SetupStudentRoutes(app *fiber.App) {
 	g.Put("/:id", middleware.RBACMiddleware("user:manage"), svc.UpdateStudentProfile)
 	g.Get("/:id/achievements", middleware.RBACMiddleware("achievement:read"), svc.GetStudentAchievements)
 	g.Put("/:id/advisor", middleware.RBACMiddleware("user:manage"), svc.SetAdvisor)
+	g.Get("/:id/storage", middleware.RBACMiddleware("achievement:read"), svc.GetStorageUsage)
+	g.Put("/:id/storage-quota", middleware.RBACMiddleware("user:manage"), svc.SetStorageQuota)
 }
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"UAS/app/service"
+	"UAS/middleware"
+)
+
+// SetupWebhookRoutes sets up outbound webhook administration routes (Admin only, user:manage permission)
+func SetupWebhookRoutes(app *fiber.App) {
+	svc := service.NewWebhookService()
+	g := app.Group("/api/v1/webhooks", middleware.AuthMiddleware, middleware.RBACMiddleware("user:manage"))
+
+	g.Get("/", svc.ListWebhooks)
+	g.Post("/", svc.CreateWebhook)
+	g.Get("/events", svc.ListEvents)
+	g.Get("/deliveries/:deliveryId", svc.GetDelivery)
+	g.Post("/deliveries/:deliveryId/redeliver", svc.Redeliver)
+	g.Get("/:id", svc.GetWebhook)
+	g.Put("/:id", svc.UpdateWebhook)
+	g.Delete("/:id", svc.DeleteWebhook)
+	g.Post("/:id/rotate-secret", svc.RotateSecret)
+	g.Get("/:id/deliveries", svc.ListDeliveries)
+}
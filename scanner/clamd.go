@@ -0,0 +1,93 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamdChunkSize is the size of the INSTREAM chunks; clamd rejects chunks above its StreamMaxLength
+const clamdChunkSize = 64 * 1024
+
+// ClamdScanner scans files with a ClamAV daemon using the INSTREAM command
+type ClamdScanner struct {
+	Network string // "tcp" or "unix"
+	Address string
+	Timeout time.Duration // per scan; zero means no deadline besides the context
+}
+
+// Scan streams r to clamd and parses its verdict
+func (s *ClamdScanner) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, s.Network, s.Address)
+	if err != nil {
+		return Result{}, fmt.Errorf("clamd: %w", err)
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if s.Timeout > 0 && (!ok || time.Now().Add(s.Timeout).Before(deadline)) {
+		deadline, ok = time.Now().Add(s.Timeout), true
+	}
+	if ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if err := writeInstream(conn, r); err != nil {
+		return Result{}, fmt.Errorf("clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		return Result{}, fmt.Errorf("clamd: reading reply: %w", err)
+	}
+	return parseClamdReply(reply)
+}
+
+// writeInstream sends the null-terminated INSTREAM command followed by length-prefixed chunks
+// and the zero-length chunk that ends the stream
+func writeInstream(w io.Writer, r io.Reader) error {
+	if _, err := io.WriteString(w, "zINSTREAM\x00"); err != nil {
+		return err
+	}
+	buf := make([]byte, 4+clamdChunkSize)
+	for {
+		n, err := r.Read(buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf, uint32(n))
+			if _, werr := w.Write(buf[:4+n]); werr != nil {
+				return werr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := w.Write([]byte{0, 0, 0, 0})
+	return err
+}
+
+// parseClamdReply parses "stream: OK", "stream: <signature> FOUND" and "<message> ERROR" replies
+func parseClamdReply(reply string) (Result, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	verdict := strings.TrimSpace(reply[strings.Index(reply, ":")+1:])
+	switch {
+	case verdict == "OK":
+		return Result{Engine: "clamd"}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return Result{Infected: true, Signature: strings.TrimSuffix(verdict, " FOUND"), Engine: "clamd"}, nil
+	case reply == "":
+		return Result{}, errors.New("clamd: empty reply")
+	default:
+		return Result{}, fmt.Errorf("clamd: %s", reply)
+	}
+}
@@ -0,0 +1,72 @@
+// Package scanner checks uploaded files for malware.
+// Uploads are scanned asynchronously by a job after they are stored; until a file is reported clean
+// nobody can download it. NoopScanner reports every file clean and is the default, ClamdScanner
+// sends files to a ClamAV daemon (clamd) over its INSTREAM protocol.
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Result is the verdict of a scan
+type Result struct {
+	Infected  bool
+	Signature string // name of the detected malware, empty when clean
+	Engine    string // scanner that produced the verdict
+}
+
+// Scanner scans file content
+type Scanner interface {
+	// Scan reads r to the end and reports whether it contains malware. An error means no verdict
+	// could be reached and the scan should be retried
+	Scan(ctx context.Context, r io.Reader) (Result, error)
+}
+
+// Default is the scanner used by the API process. main replaces it with the configured backend
+var Default Scanner = NoopScanner{}
+
+// NewFromEnv creates the scanner selected by SCANNER_BACKEND:
+//   - none: every file is reported clean (default)
+//   - clamd: files are sent to CLAMD_ADDRESS, "host:port" (default localhost:3310) or
+//     "unix:/path/to/clamd.sock", with CLAMD_TIMEOUT per scan (default 2m)
+func NewFromEnv() (Scanner, error) {
+	switch backend := os.Getenv("SCANNER_BACKEND"); backend {
+	case "", "none":
+		return NoopScanner{}, nil
+	case "clamd":
+		s := &ClamdScanner{Network: "tcp", Address: "localhost:3310", Timeout: 2 * time.Minute}
+		if addr := os.Getenv("CLAMD_ADDRESS"); addr != "" {
+			if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+				s.Network, s.Address = "unix", path
+			} else {
+				s.Address = addr
+			}
+		}
+		if v := os.Getenv("CLAMD_TIMEOUT"); v != "" {
+			timeout, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CLAMD_TIMEOUT: %s", v)
+			}
+			s.Timeout = timeout
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown SCANNER_BACKEND: %s", backend)
+	}
+}
+
+// NoopScanner reports every file clean, for deployments without a virus scanner
+type NoopScanner struct{}
+
+// Scan drains r and reports it clean
+func (NoopScanner) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return Result{}, err
+	}
+	return Result{Engine: "none"}, nil
+}
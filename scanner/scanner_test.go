@@ -0,0 +1,124 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd is a local clamd stand-in that flags the EICAR test string
+func fakeClamd(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				cmd, err := r.ReadString(0)
+				if err != nil || cmd != "zINSTREAM\x00" {
+					io.WriteString(conn, "UNKNOWN COMMAND\x00")
+					return
+				}
+				var data bytes.Buffer
+				for {
+					var size uint32
+					if binary.Read(r, binary.BigEndian, &size) != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					if size > 1<<20 {
+						io.WriteString(conn, "INSTREAM size limit exceeded. ERROR\x00")
+						return
+					}
+					io.CopyN(&data, r, int64(size))
+				}
+				if bytes.Contains(data.Bytes(), []byte("EICAR-STANDARD-ANTIVIRUS-TEST-FILE")) {
+					io.WriteString(conn, "stream: Win.Test.EICAR_HDB-1 FOUND\x00")
+					return
+				}
+				io.WriteString(conn, "stream: OK\x00")
+			}(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// TestClamdScanner tests clean and infected verdicts against a local clamd stand-in
+func TestClamdScanner(t *testing.T) {
+	s := &ClamdScanner{Network: "tcp", Address: fakeClamd(t), Timeout: 5 * time.Second}
+
+	result, err := s.Scan(context.Background(), strings.NewReader(strings.Repeat("%PDF-1.4 ", 20000)))
+	assert.NoError(t, err)
+	assert.False(t, result.Infected)
+	assert.Equal(t, "clamd", result.Engine)
+
+	result, err = s.Scan(context.Background(), strings.NewReader(eicar))
+	assert.NoError(t, err)
+	assert.True(t, result.Infected)
+	assert.Equal(t, "Win.Test.EICAR_HDB-1", result.Signature)
+}
+
+// TestClamdScannerUnavailable tests that an unreachable daemon is an error, not a verdict
+func TestClamdScannerUnavailable(t *testing.T) {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close()
+
+	s := &ClamdScanner{Network: "tcp", Address: addr, Timeout: time.Second}
+	_, err := s.Scan(context.Background(), strings.NewReader("data"))
+	assert.Error(t, err)
+}
+
+// TestParseClamdReply tests the reply formats of clamd
+func TestParseClamdReply(t *testing.T) {
+	result, err := parseClamdReply("stream: OK\x00")
+	assert.NoError(t, err)
+	assert.False(t, result.Infected)
+
+	result, err = parseClamdReply("stream: Eicar-Signature FOUND\x00")
+	assert.NoError(t, err)
+	assert.True(t, result.Infected)
+	assert.Equal(t, "Eicar-Signature", result.Signature)
+
+	_, err = parseClamdReply("INSTREAM size limit exceeded. ERROR\x00")
+	assert.EqualError(t, err, "clamd: INSTREAM size limit exceeded. ERROR")
+
+	_, err = parseClamdReply("")
+	assert.Error(t, err)
+}
+
+// TestNewFromEnv tests backend selection
+func TestNewFromEnv(t *testing.T) {
+	s, err := NewFromEnv()
+	assert.NoError(t, err)
+	assert.IsType(t, NoopScanner{}, s)
+
+	t.Setenv("SCANNER_BACKEND", "clamd")
+	t.Setenv("CLAMD_ADDRESS", "unix:/run/clamav/clamd.sock")
+	s, err = NewFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, &ClamdScanner{Network: "unix", Address: "/run/clamav/clamd.sock", Timeout: 2 * time.Minute}, s)
+
+	t.Setenv("SCANNER_BACKEND", "other")
+	_, err = NewFromEnv()
+	assert.Error(t, err)
+}
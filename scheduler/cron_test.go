@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseSchedule tests cron expression validation
+func TestParseSchedule(t *testing.T) {
+	valid := []string{"* * * * *", "*/15 * * * *", "0 8 * * 1-5", "30 2 1,15 * *", "@daily", "0 0 * * 7", "5-30/5 * * * *"}
+	for _, spec := range valid {
+		_, err := ParseSchedule(spec)
+		assert.NoError(t, err, spec)
+	}
+
+	invalid := []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@often"}
+	for _, spec := range invalid {
+		_, err := ParseSchedule(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+// TestScheduleNext tests next run time calculation
+func TestScheduleNext(t *testing.T) {
+	// Wednesday 2026-03-04 10:07:30
+	from := time.Date(2026, 3, 4, 10, 7, 30, 0, time.UTC)
+
+	testCases := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 4, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 4, 10, 15, 0, 0, time.UTC)},
+		{"0 8 * * *", time.Date(2026, 3, 5, 8, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 4, 11, 0, 0, 0, time.UTC)},
+		{"0 7 * * 1", time.Date(2026, 3, 9, 7, 0, 0, 0, time.UTC)},   // next Monday
+		{"0 0 1 * *", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},   // first of next month
+		{"0 0 31 * *", time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)}, // skips short months
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)}, // next leap day
+		{"0 0 13 * 5", time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)},  // day of month OR Friday
+		{"0 9 * * 7", time.Date(2026, 3, 8, 9, 0, 0, 0, time.UTC)},   // 7 is Sunday
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			schedule, err := ParseSchedule(tc.spec)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, schedule.Next(from))
+		})
+	}
+}
+
+// TestRegisterRejectsInvalidJobs tests job registration guards
+func TestRegisterRejectsInvalidJobs(t *testing.T) {
+	s := New()
+	s.Register(Job{Name: "cleanup", Schedule: "@daily"})
+	assert.True(t, s.HasJob("cleanup"))
+
+	assert.Panics(t, func() { s.Register(Job{Name: "cleanup", Schedule: "@daily"}) })
+	assert.Panics(t, func() { s.Register(Job{Name: "broken", Schedule: "61 * * * *"}) })
+	assert.ErrorIs(t, s.Trigger("missing", "admin"), ErrJobNotFound)
+}
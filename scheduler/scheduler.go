@@ -0,0 +1,347 @@
+// Package scheduler runs named recurring background jobs inside the API process.
+// Jobs are registered by the subsystems that own them. When several API instances run,
+// a PostgreSQL advisory lock plus a per-slot run record make each scheduled slot run once.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"UAS/app/models"
+	"UAS/app/repository"
+)
+
+// defaultJobTimeout bounds a job run when the job does not set its own timeout
+const defaultJobTimeout = 10 * time.Minute
+
+var (
+	// ErrJobNotFound is returned when triggering a job that is not registered
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobRunning is returned when triggering a job that is already running
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// JobFunc runs a job and returns a short human readable result
+type JobFunc func(ctx context.Context) (string, error)
+
+// Job describes a recurring background job
+type Job struct {
+	Name        string        // unique job name, also used as lock name
+	Schedule    string        // cron expression or descriptor
+	Description string        // shown in the admin job list
+	Timeout     time.Duration // defaults to 10 minutes
+	Run         JobFunc
+}
+
+// JobInfo is the admin view of a registered job
+type JobInfo struct {
+	Name        string         `json:"name"`
+	Schedule    string         `json:"schedule"`
+	Description string         `json:"description"`
+	NextRun     time.Time      `json:"next_run"`
+	Running     bool           `json:"running"`
+	LastRun     *models.JobRun `json:"last_run"`
+}
+
+type registeredJob struct {
+	Job
+	schedule *Schedule
+	next     time.Time
+	running  bool
+}
+
+// Scheduler runs registered jobs on their schedules
+type Scheduler struct {
+	mu       sync.Mutex
+	jobs     map[string]*registeredJob
+	runRepo  *repository.JobRunRepository
+	instance string
+	stop     chan struct{}
+	wg       sync.WaitGroup
+	now      func() time.Time
+}
+
+// Default is the scheduler used by the API process
+var Default = New()
+
+// New creates an empty scheduler
+func New() *Scheduler {
+	instance, _ := os.Hostname()
+	return &Scheduler{
+		jobs:     make(map[string]*registeredJob),
+		runRepo:  repository.NewJobRunRepository(),
+		instance: instance,
+		now:      time.Now,
+	}
+}
+
+// Register adds a job. It panics on an invalid schedule or duplicate name, since both are programming errors
+func (s *Scheduler) Register(job Job) {
+	schedule, err := ParseSchedule(job.Schedule)
+	if err != nil {
+		panic(fmt.Sprintf("scheduler: job %s: %v", job.Name, err))
+	}
+	if job.Timeout <= 0 {
+		job.Timeout = defaultJobTimeout
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.jobs[job.Name]; exists {
+		panic("scheduler: duplicate job " + job.Name)
+	}
+	s.jobs[job.Name] = &registeredJob{Job: job, schedule: schedule, next: schedule.Next(s.now())}
+}
+
+// Start begins running jobs on their schedules in the background
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	if s.stop != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.stop = make(chan struct{})
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go s.loop()
+	log.Printf("Scheduler started with %d jobs", len(s.Jobs()))
+}
+
+// Stop stops scheduling new runs and waits for running jobs to finish
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if s.stop == nil {
+		s.mu.Unlock()
+		return
+	}
+	close(s.stop)
+	s.stop = nil
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop() {
+	defer s.wg.Done()
+	s.mu.Lock()
+	stop := s.stop
+	s.mu.Unlock()
+
+	for {
+		// Wake at the next due job, at least once a minute so newly registered jobs are picked up
+		wait := time.Minute
+		now := s.now()
+		s.mu.Lock()
+		for _, job := range s.jobs {
+			if d := job.next.Sub(now); d < wait {
+				wait = d
+			}
+		}
+		s.mu.Unlock()
+		if wait < 0 {
+			wait = 0
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.runDue()
+	}
+}
+
+// runDue starts every job whose next run time has passed
+func (s *Scheduler) runDue() {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		if job.next.IsZero() || job.next.After(now) {
+			continue
+		}
+		slot := job.next
+		job.next = job.schedule.Next(now)
+		if job.running {
+			continue
+		}
+		job.running = true
+
+		s.wg.Add(1)
+		go func(job *registeredJob, slot time.Time) {
+			defer s.wg.Done()
+			s.execute(job, &slot, models.JobTriggerSchedule, "")
+		}(job, slot)
+	}
+}
+
+// Trigger runs a job now in the background, outside its schedule
+func (s *Scheduler) Trigger(name, triggeredBy string) error {
+	s.mu.Lock()
+	job, ok := s.jobs[name]
+	if !ok {
+		s.mu.Unlock()
+		return ErrJobNotFound
+	}
+	if job.running {
+		s.mu.Unlock()
+		return ErrJobRunning
+	}
+	job.running = true
+	s.mu.Unlock()
+
+	// Take the lock before returning so a run on another instance is reported to the caller
+	lock, err := s.lock(job)
+	if err != nil || lock == nil {
+		s.finish(job)
+		if err != nil {
+			return err
+		}
+		return ErrJobRunning
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.finish(job)
+		s.runLocked(job, lock, nil, models.JobTriggerManual, triggeredBy)
+	}()
+	return nil
+}
+
+// execute runs a scheduled slot of a job under its advisory lock
+// Slots being run or already run by another instance are skipped
+func (s *Scheduler) execute(job *registeredJob, slot *time.Time, trigger, triggeredBy string) {
+	defer s.finish(job)
+
+	lock, err := s.lock(job)
+	if err != nil {
+		log.Printf("Job %s could not be run: %v", job.Name, err)
+		return
+	}
+	if lock == nil {
+		log.Printf("Job %s skipped: running on another instance", job.Name)
+		return
+	}
+	s.runLocked(job, lock, slot, trigger, triggeredBy)
+}
+
+// lock takes the cross-instance lock of a job, nil when another instance holds it
+func (s *Scheduler) lock(job *registeredJob) (*repository.AdvisoryLock, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return s.runRepo.TryAdvisoryLock(ctx, "scheduler:"+job.Name)
+}
+
+// finish marks a job as no longer running on this instance
+func (s *Scheduler) finish(job *registeredJob) {
+	s.mu.Lock()
+	job.running = false
+	s.mu.Unlock()
+}
+
+// runLocked runs a job whose lock is held, records the run and releases the lock
+func (s *Scheduler) runLocked(job *registeredJob, lock *repository.AdvisoryLock, slot *time.Time, trigger, triggeredBy string) {
+	defer func() {
+		if err := lock.Release(); err != nil {
+			log.Printf("Job %s lock release failed: %v", job.Name, err)
+		}
+	}()
+
+	if slot != nil {
+		done, err := s.runRepo.SlotExists(job.Name, *slot)
+		if err != nil {
+			log.Printf("Job %s could not be run: %v", job.Name, err)
+		}
+		if err != nil || done {
+			return
+		}
+	}
+
+	run := &models.JobRun{
+		ID:           uuid.New().String(),
+		JobName:      job.Name,
+		ScheduledFor: slot,
+		Trigger:      trigger,
+		TriggeredBy:  triggeredBy,
+		Status:       models.JobRunRunning,
+		Instance:     s.instance,
+		StartedAt:    s.now(),
+	}
+	if err := s.runRepo.Create(run); err != nil {
+		log.Printf("Job %s could not be run: %v", job.Name, err)
+		return
+	}
+
+	result, runErr := s.safeRun(job)
+
+	finished := s.now()
+	run.FinishedAt = &finished
+	run.DurationMs = finished.Sub(run.StartedAt).Milliseconds()
+	run.Result = result
+	run.Status = models.JobRunSucceeded
+	if runErr != nil {
+		run.Status = models.JobRunFailed
+		run.Error = runErr.Error()
+		log.Printf("Job %s failed: %v", job.Name, runErr)
+	}
+	if err := s.runRepo.Update(run); err != nil {
+		log.Printf("Job %s run could not be recorded: %v", job.Name, err)
+	}
+}
+
+// safeRun runs a job with its timeout and turns a panic into an error
+func (s *Scheduler) safeRun(job *registeredJob) (result string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), job.Timeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+// Jobs lists registered jobs sorted by name with their last recorded run
+func (s *Scheduler) Jobs() []JobInfo {
+	latest, _ := s.runRepo.FindLatest()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	infos := make([]JobInfo, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		info := JobInfo{
+			Name:        job.Name,
+			Schedule:    job.Job.Schedule,
+			Description: job.Description,
+			NextRun:     job.next,
+			Running:     job.running,
+		}
+		if run, ok := latest[job.Name]; ok {
+			info.LastRun = &run
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// HasJob reports whether a job is registered
+func (s *Scheduler) HasJob(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.jobs[name]
+	return ok
+}
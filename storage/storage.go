@@ -0,0 +1,94 @@
+// Package storage keeps uploaded files in a pluggable blob store.
+// Files are addressed by a storage key such as "achievements/<id>/<file>"; records store the key,
+// never a path or URL, so the backend can change without touching them. LocalStore writes to a
+// directory for development and single-instance deployments, S3Store talks to Amazon S3 or any
+// S3-compatible server (MinIO, Ceph, ...) so every API instance sees the same files.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when a key does not exist
+var ErrNotFound = errors.New("storage: object not found")
+
+// ErrInvalidKey is returned for keys that are empty, absolute or escape the store
+var ErrInvalidKey = errors.New("storage: invalid key")
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// BlobStore stores and retrieves files by key
+type BlobStore interface {
+	// Put stores size bytes read from r under key, replacing an existing object
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open returns the content of an object; the caller closes it
+	Open(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error)
+	// Stat returns the metadata of an object
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// Delete removes an object; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// Default is the store used by the API process. main replaces it with the configured backend
+var Default BlobStore = &LocalStore{Root: "uploads"}
+
+// NewFromEnv creates the store selected by STORAGE_BACKEND:
+//   - local: files under STORAGE_DIR (default ./uploads), the default backend
+//   - s3: S3_BUCKET, S3_REGION (default us-east-1), S3_ACCESS_KEY_ID, S3_SECRET_ACCESS_KEY,
+//     S3_ENDPOINT for S3-compatible servers (default AWS) and S3_FORCE_PATH_STYLE
+//     (default true when S3_ENDPOINT is set, as MinIO expects)
+func NewFromEnv() (BlobStore, error) {
+	switch backend := os.Getenv("STORAGE_BACKEND"); backend {
+	case "", "local":
+		dir := os.Getenv("STORAGE_DIR")
+		if dir == "" {
+			dir = "./uploads"
+		}
+		return &LocalStore{Root: dir}, nil
+	case "s3":
+		region := os.Getenv("S3_REGION")
+		if region == "" {
+			region = "us-east-1"
+		}
+		endpoint := os.Getenv("S3_ENDPOINT")
+		pathStyle := endpoint != ""
+		if v := os.Getenv("S3_FORCE_PATH_STYLE"); v != "" {
+			pathStyle = v == "true"
+		}
+		return NewS3Store(S3Config{
+			Endpoint:        endpoint,
+			Region:          region,
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PathStyle:       pathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND: %s", backend)
+	}
+}
+
+// ValidateKey rejects keys that could escape the store or address a directory
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
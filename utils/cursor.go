@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"UAS/app/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrInvalidCursor is returned for cursors that are malformed, tampered with or issued for another sort or filter
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorParams holds keyset pagination parameters
+type CursorParams struct {
+	Sort   string         // sort (and filters) the cursors of the list are issued for
+	Limit  int            // rows per page
+	Cursor *models.Cursor // nil for the first page
+}
+
+// GetCursorParams extracts keyset pagination parameters of a list sorted by sort from the request:
+// the signed cursor and limit (or page_size), 1-100 with a default of 10
+func GetCursorParams(c *fiber.Ctx, sort string) (CursorParams, error) {
+	params := CursorParams{Sort: sort, Limit: 10}
+
+	limit := c.Query("limit", c.Query("page_size"))
+	if parsed, err := strconv.Atoi(limit); err == nil && parsed > 0 && parsed <= 100 {
+		params.Limit = parsed
+	}
+
+	if token := c.Query("cursor"); token != "" {
+		cursor, err := DecodeCursor(token)
+		if err != nil || cursor.Sort != sort {
+			return params, ErrInvalidCursor
+		}
+		params.Cursor = cursor
+	}
+	return params, nil
+}
+
+// EncodeCursor signs a cursor into an opaque URL-safe token
+func EncodeCursor(cursor models.Cursor) string {
+	payload, _ := json.Marshal(cursor)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + cursorSignature(encoded)
+}
+
+// DecodeCursor verifies and decodes a token made by EncodeCursor
+func DecodeCursor(token string) (*models.Cursor, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(cursorSignature(encoded))) {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor models.Cursor
+	if err := json.Unmarshal(payload, &cursor); err != nil || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// CursorPagination builds the pagination block of a keyset page: the limit and the cursors and
+// links of the next and previous pages, null at either end of the list. first and last are the
+// positions of the first and last row of the page, nil when it is empty; more reports whether rows
+// follow the page in the direction it was read
+func CursorPagination(c *fiber.Ctx, params CursorParams, first, last *models.Cursor, more bool) fiber.Map {
+	var next, prev *models.Cursor
+	backward := params.Cursor != nil && params.Cursor.Backward
+	switch {
+	case first == nil && params.Cursor != nil:
+		// Nothing left past the cursor; the way back starts at the cursor itself
+		flipped := params.Cursor.Flip()
+		if backward {
+			next = &flipped
+		} else {
+			prev = &flipped
+		}
+	case first != nil:
+		if more || backward {
+			cursor := models.Cursor{Sort: params.Sort, Key: last.Key, ID: last.ID}
+			next = &cursor
+		}
+		if (more && backward) || (params.Cursor != nil && !backward) {
+			cursor := models.Cursor{Sort: params.Sort, Key: first.Key, ID: first.ID, Backward: true}
+			prev = &cursor
+		}
+	}
+
+	pagination := fiber.Map{
+		"limit":       params.Limit,
+		"next_cursor": nil,
+		"prev_cursor": nil,
+		"next":        nil,
+		"prev":        nil,
+	}
+	if next != nil {
+		token := EncodeCursor(*next)
+		pagination["next_cursor"] = token
+		pagination["next"] = cursorLink(c, token)
+	}
+	if prev != nil {
+		token := EncodeCursor(*prev)
+		pagination["prev_cursor"] = token
+		pagination["prev"] = cursorLink(c, token)
+	}
+	return pagination
+}
+
+// PageBounds returns the positions of the first and last row of a page, nil when it is empty
+func PageBounds[T any](rows []T, position func(T) *models.Cursor) (first, last *models.Cursor) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return position(rows[0]), position(rows[len(rows)-1])
+}
+
+// CursorResponse returns a keyset-paginated response
+func CursorResponse(c *fiber.Ctx, message string, data interface{}, pagination fiber.Map) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     true,
+		"message":    message,
+		"data":       data,
+		"pagination": pagination,
+	})
+}
+
+// cursorLink returns the request URL with its cursor replaced by token
+func cursorLink(c *fiber.Ctx, token string) string {
+	link, err := url.Parse(c.OriginalURL())
+	if err != nil {
+		return ""
+	}
+	query := link.Query()
+	query.Set("cursor", token)
+	query.Del("page")
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+// cursorSignature is the HMAC-SHA256 of an encoded cursor, keyed with CURSOR_SECRET and falling
+// back to JWT_SECRET
+func cursorSignature(encoded string) string {
+	secret := os.Getenv("CURSOR_SECRET")
+	if secret == "" {
+		secret = os.Getenv("JWT_SECRET")
+	}
+	if secret == "" {
+		secret = "your-secret-key-change-in-production"
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"UAS/app/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCursorRoundTrip tests encoding and decoding signed cursors
+func TestCursorRoundTrip(t *testing.T) {
+	t.Setenv("CURSOR_SECRET", "secret")
+	cursor := models.Cursor{Sort: "title:asc", Key: "Lomba Robotik", ID: "a1", Backward: true}
+
+	decoded, err := DecodeCursor(EncodeCursor(cursor))
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, *decoded)
+}
+
+// TestDecodeCursorRejectsTampering tests that modified, foreign and malformed cursors are rejected
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+	t.Setenv("CURSOR_SECRET", "secret")
+	token := EncodeCursor(models.Cursor{Sort: "created_at:asc", Key: "2026-01-01T00:00:00Z", ID: "a1"})
+
+	// Another position under the signature of token
+	forged, _, _ := strings.Cut(EncodeCursor(models.Cursor{Sort: "created_at:asc", Key: "2026-01-01T00:00:00Z", ID: "a2"}), ".")
+	_, signature, _ := strings.Cut(token, ".")
+	_, err := DecodeCursor(forged + "." + signature)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	_, err = DecodeCursor("not-a-cursor")
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	t.Setenv("CURSOR_SECRET", "other")
+	_, err = DecodeCursor(token)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+// cursorPage runs a list handler that reads cursor parameters for sort and renders the pagination
+// block of a page bounded by first and last
+func cursorPage(t *testing.T, target, sort string, first, last *models.Cursor, more bool) (int, map[string]interface{}) {
+	app := fiber.New()
+	app.Get("/items", func(c *fiber.Ctx) error {
+		params, err := GetCursorParams(c, sort)
+		if err != nil {
+			return ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		}
+		return CursorResponse(c, "success", []string{}, CursorPagination(c, params, first, last, more))
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+	assert.NoError(t, err)
+	var body struct {
+		Pagination map[string]interface{} `json:"pagination"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return resp.StatusCode, body.Pagination
+}
+
+// linkCursor decodes the cursor of a next/prev link
+func linkCursor(t *testing.T, link interface{}) *models.Cursor {
+	s, ok := link.(string)
+	if !assert.True(t, ok, "link missing") {
+		return nil
+	}
+	u, err := url.Parse(s)
+	assert.NoError(t, err)
+	assert.Empty(t, u.Query().Get("page"))
+	assert.Equal(t, "verified", u.Query().Get("status"))
+	cursor, err := DecodeCursor(u.Query().Get("cursor"))
+	assert.NoError(t, err)
+	return cursor
+}
+
+// TestCursorPagination tests the next and prev links of keyset pages
+func TestCursorPagination(t *testing.T) {
+	t.Setenv("CURSOR_SECRET", "secret")
+	const sort = "created_at:asc"
+	first := &models.Cursor{Key: "2026-01-01T00:00:00Z", ID: "a1"}
+	last := &models.Cursor{Key: "2026-01-02T00:00:00Z", ID: "a2"}
+
+	t.Run("First page with more rows", func(t *testing.T) {
+		code, pagination := cursorPage(t, "/items?status=verified&limit=2&page=1", sort, first, last, true)
+		assert.Equal(t, fiber.StatusOK, code)
+		assert.Equal(t, float64(2), pagination["limit"])
+		assert.Nil(t, pagination["prev"])
+		next := linkCursor(t, pagination["next"])
+		assert.Equal(t, models.Cursor{Sort: sort, Key: last.Key, ID: last.ID}, *next)
+	})
+
+	t.Run("Last page read forward", func(t *testing.T) {
+		token := EncodeCursor(models.Cursor{Sort: sort, Key: "2025-12-31T00:00:00Z", ID: "a0"})
+		_, pagination := cursorPage(t, "/items?status=verified&cursor="+token, sort, first, last, false)
+		assert.Nil(t, pagination["next"])
+		prev := linkCursor(t, pagination["prev"])
+		assert.Equal(t, models.Cursor{Sort: sort, Key: first.Key, ID: first.ID, Backward: true}, *prev)
+	})
+
+	t.Run("First page read backward", func(t *testing.T) {
+		token := EncodeCursor(models.Cursor{Sort: sort, Key: "2026-01-03T00:00:00Z", ID: "a3", Backward: true})
+		_, pagination := cursorPage(t, "/items?status=verified&cursor="+token, sort, first, last, false)
+		assert.Nil(t, pagination["prev"])
+		next := linkCursor(t, pagination["next"])
+		assert.Equal(t, models.Cursor{Sort: sort, Key: last.Key, ID: last.ID}, *next)
+	})
+
+	t.Run("Empty page past the cursor", func(t *testing.T) {
+		cursor := models.Cursor{Sort: sort, Key: "2026-01-03T00:00:00Z", ID: "a3"}
+		_, pagination := cursorPage(t, "/items?status=verified&cursor="+EncodeCursor(cursor), sort, nil, nil, false)
+		assert.Nil(t, pagination["next"])
+		assert.Equal(t, cursor.Flip(), *linkCursor(t, pagination["prev"]))
+	})
+
+	t.Run("Cursor of another sort", func(t *testing.T) {
+		token := EncodeCursor(models.Cursor{Sort: "title:asc", Key: "A", ID: "a1"})
+		code, _ := cursorPage(t, "/items?cursor="+token, sort, first, last, false)
+		assert.Equal(t, fiber.StatusBadRequest, code)
+	})
+}
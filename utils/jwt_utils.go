@@ -103,3 +103,59 @@ func ValidateRefreshToken(tokenString string) (*models.User, error) {
 
 	return user, nil
 }
+
+// GenerateStreamToken generates a short-lived token that only opens the real-time event stream.
+// Browsers pass it in the query string, where it may be logged, so it carries no other access
+func GenerateStreamToken(userID, role string, permissions interface{}, ttl time.Duration) (string, time.Time, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		secret = "your-secret-key-change-in-production"
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	claims := jwt.MapClaims{
+		"user_id":     userID,
+		"role":        role,
+		"permissions": permissions,
+		"exp":         expiresAt.Unix(),
+		"iat":         time.Now().Unix(),
+		"type":        "stream",
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(secret))
+	return signed, expiresAt, err
+}
+
+// ValidateStreamToken validates a token from GenerateStreamToken and returns its claims
+func ValidateStreamToken(tokenString string) (jwt.MapClaims, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		secret = "your-secret-key-change-in-production"
+	}
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return []byte(secret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	if tokenType, _ := claims["type"].(string); tokenType != "stream" {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	if _, ok := claims["user_id"].(string); !ok {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}